@@ -3,24 +3,104 @@ package app
 import (
 	"time"
 
-	"zkp-auth/proof"
-	"zkp-auth/repository"
-	"zkp-auth/security"
-	"zkp-auth/verifier"
+	"github.com/threehook/zkp-auth/backend/proof"
+	"github.com/threehook/zkp-auth/backend/prover"
+	"github.com/threehook/zkp-auth/backend/repository"
+	"github.com/threehook/zkp-auth/backend/security"
+	"github.com/threehook/zkp-auth/backend/session"
+	"github.com/threehook/zkp-auth/backend/signing"
+	"github.com/threehook/zkp-auth/backend/tokenauth"
+	"github.com/threehook/zkp-auth/backend/verifier"
 )
 
 type Config struct {
-	JWTSecret  []byte
+	JWTSecret  []byte // HS256 only
 	ServerPort string
 	CorsOrigin string
 	ProofTTL   time.Duration
-	JWTExpiry  time.Duration
+	JWTExpiry  time.Duration // lifetime of access tokens
+
+	// Lifetime of a refresh token; each refresh issues a new one
+	RefreshTokenTTL time.Duration
+
+	// Access token signing: "EdDSA", "ES256" or "RS256" with keys from
+	// JWTKeyDir, generated there on first boot and rotated every
+	// JWTKeyRotation (0 never rotates), or "HS256" with JWTSecret. Keys are
+	// published JWTKeyOverlap before they sign and kept that long after
+	JWTSigningAlg  string
+	JWTKeyDir      string
+	JWTKeyRotation time.Duration
+	JWTKeyOverlap  time.Duration
+
+	// Claims of issued access tokens: iss, aud (empty leaves it out) and the
+	// scopes granted to every user
+	JWTIssuer   string
+	JWTAudience []string
+	TokenScopes []string
+
+	// Users whose tokens carry the "admin" scope the admin endpoints require.
+	// Registration refuses these names, so an account must exist before it
+	// is listed here
+	AdminUsers []string
+
+	// Bearer secret services present to the token introspection endpoint;
+	// empty disables the endpoint
+	IntrospectionSecret []byte
+
+	ChallengeTTL time.Duration
+	SaltTTL      time.Duration
+
+	// User storage: "memory" (lost on restart), "bolt" (embedded database at
+	// UserDBPath) or "sql" (UserDBDriver, "sqlite" or "pgx", connecting to UserDBDSN)
+	UserStore    string
+	UserDBPath   string
+	UserDBDriver string
+	UserDBDSN    string
+
+	// Registration: whether the server may still receive plaintext passwords
+	// (deprecated, off unless the operator opts in), and whether commitments
+	// must come with a proof of knowledge
+	AllowPlaintextRegistration bool
+	RequireRegistrationProof   bool
+
+	// Keys of the password-gnark circuit browsers prove with, loaded from
+	// CircuitKeyDir and served to the wasm prover. Only with
+	// CircuitKeyDevSetup are missing keys set up on first boot, by a
+	// single-party setup that lets this server forge proofs
+	CircuitKeyDir      string
+	CircuitKeyDevSetup bool
+
+	// Optional directory of circuit manifests and keys merged over the built-in
+	// password-gnark circuit by circuit ID and version
+	VerificationKeyDir string
+	KeyPollInterval    time.Duration
+
+	// Concurrent logins are batch-verified within this window; 0 disables batching
+	VerifyBatchWindow time.Duration
+	VerifyBatchSize   int
+
+	// Verification worker pool: 0 workers means GOMAXPROCS, 0 queue size four per worker
+	VerifyWorkers   int
+	VerifyQueueSize int
+	VerifyTimeout   time.Duration
+
+	// Reject proofs from circuits that do not commit to nonce, timestamp and username
+	RequireBoundProofs bool
 }
 
 type Dependencies struct {
 	Config          Config
+	Prover          *prover.Prover
 	UserRepo        repository.UserRepo
+	SaltStore       *repository.SaltStore
 	ProofValidator  *proof.Validator
-	ZKPVerifier     *verifier.Groth16Verifier
+	ZKPVerifier     verifier.Verifier
+	VerifierPool    *verifier.Pool
 	SecurityMonitor *security.SecurityMonitor
+	CircuitRegistry *verifier.Registry
+	Sessions        *session.Store
+	RefreshTokens   *session.RefreshStore
+	Revocations     *session.RevocationStore
+	TokenKeys       *signing.KeyRing
+	TokenVerifier   *tokenauth.Verifier
 }
@@ -1,6 +0,0 @@
-package circuits
-
-import "embed"
-
-//go:embed verification_key.json
-var VerificationKeyFS embed.FS
@@ -0,0 +1,122 @@
+//go:build js && wasm
+
+// Command prover-wasm is the Go prover compiled for the browser:
+//
+//	GOOS=js GOARCH=wasm go build -o prover.wasm ./cmd/prover-wasm
+//
+// Run with Go's wasm_exec.js, it sets globalThis.zkpProver to an object with
+//
+//	load(manifest, verificationKey, provingKey: Uint8Array): Promise<void>
+//	prove(username, password, salt, nonce: string, timestamp: number, proofType?: string): Promise<object>
+//
+// load takes the files the backend serves at /api/prover/:file. prove
+// resolves to the proof part of a login request, for the stored credential
+// Poseidon(password, salt)
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"syscall/js"
+	"testing/fstest"
+
+	"github.com/threehook/zkp-auth/backend/credential"
+	"github.com/threehook/zkp-auth/backend/proof"
+	"github.com/threehook/zkp-auth/backend/prover"
+	"github.com/threehook/zkp-auth/backend/verifier"
+)
+
+var (
+	mu     sync.Mutex
+	loaded *prover.Prover
+)
+
+func main() {
+	js.Global().Set("zkpProver", js.ValueOf(map[string]any{
+		"load":  js.FuncOf(load),
+		"prove": js.FuncOf(prove),
+	}))
+	select {}
+}
+
+func load(this js.Value, args []js.Value) any {
+	return promise(func() (any, error) {
+		if len(args) != 3 {
+			return nil, errors.New("load takes the manifest, verification key and proving key")
+		}
+		p, err := prover.LoadFS(fstest.MapFS{
+			"circuit.json":          {Data: bytes(args[0])},
+			"verification_key.json": {Data: bytes(args[1])},
+			prover.ProvingKeyFile:   {Data: bytes(args[2])},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		loaded = p
+		mu.Unlock()
+		return nil, nil
+	})
+}
+
+func prove(this js.Value, args []js.Value) any {
+	return promise(func() (any, error) {
+		if len(args) < 5 {
+			return nil, errors.New("prove takes username, password, salt, nonce and timestamp")
+		}
+		mu.Lock()
+		p := loaded
+		mu.Unlock()
+		if p == nil {
+			return nil, errors.New("no keys loaded")
+		}
+
+		username, password, salt := args[0].String(), args[1].String(), args[2].String()
+		commitment, err := credential.Compute(password, salt)
+		if err != nil {
+			return nil, err
+		}
+		request, err := p.Prove(username, password, verifier.Credential{Salt: salt, Commitment: commitment},
+			args[3].String(), int64(args[4].Int()))
+		if err != nil {
+			return nil, err
+		}
+		if len(args) > 5 && args[5].Truthy() {
+			request.ProofType = proof.ProofType(args[5].String())
+		}
+
+		// Round-trip through JSON for the field names clients send
+		data, err := json.Marshal(request)
+		if err != nil {
+			return nil, err
+		}
+		return js.Global().Get("JSON").Call("parse", string(data)), nil
+	})
+}
+
+func bytes(v js.Value) []byte {
+	b := make([]byte, v.Get("length").Int())
+	js.CopyBytesToGo(b, v)
+	return b
+}
+
+// promise runs work off the event loop, which proving would otherwise block
+// for its whole duration, and settles a JavaScript Promise with its result
+func promise(work func() (any, error)) js.Value {
+	executor := js.FuncOf(func(this js.Value, args []js.Value) any {
+		resolve, reject := args[0], args[1]
+		go func() {
+			result, err := work()
+			if err != nil {
+				reject.Invoke(js.Global().Get("Error").New(err.Error()))
+				return
+			}
+			resolve.Invoke(result)
+		}()
+		return nil
+	})
+	defer executor.Release()
+	return js.Global().Get("Promise").New(executor)
+}
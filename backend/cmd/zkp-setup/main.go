@@ -0,0 +1,35 @@
+// Command zkp-setup compiles the gnark password circuit, runs a development
+// Groth16 setup and writes the keys in the layout the backend loads:
+//
+//	go run ./cmd/zkp-setup -out circuits -version 1
+//
+// produces circuits/password-gnark/v1/{circuit.json,verification_key.json,proving_key.bin}
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/threehook/zkp-auth/backend/prover"
+)
+
+func main() {
+	out := flag.String("out", "circuits", "directory holding one subdirectory per circuit")
+	version := flag.Int("version", 1, "circuit version to register the keys as")
+	flag.Parse()
+
+	if *version < 1 {
+		log.Fatal("version must be positive")
+	}
+
+	p, err := prover.Setup(*version)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dir := prover.VersionDir(*out, *version)
+	if err := p.Save(dir); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("🔑 Wrote %s version %d keys to %s", prover.CircuitID, *version, dir)
+}
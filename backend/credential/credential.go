@@ -0,0 +1,83 @@
+// Package credential computes and parses the stored password credential
+// Poseidon(password, salt). It has no storage dependencies, so clients such as
+// the wasm prover build it too
+package credential
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/threehook/zkp-auth/backend/poseidon"
+)
+
+// MaxPasswordBytes keeps a packed password below the BN254 scalar field
+const MaxPasswordBytes = 31
+
+// MinSaltBits separates field-sized salts from the six-digit salts issued
+// before them. A uniformly random field element is shorter than this with
+// negligible probability
+const MinSaltBits = 128
+
+// PasswordToField packs the UTF-8 bytes of a password big-endian into a field
+// element, the encoding clients use for the circuit's private password input
+func PasswordToField(password string) (fr.Element, error) {
+	var e fr.Element
+	if len(password) == 0 || len(password) > MaxPasswordBytes {
+		return e, fmt.Errorf("password must be 1 to %d bytes", MaxPasswordBytes)
+	}
+	e.SetBytes([]byte(password))
+	return e, nil
+}
+
+// Compute returns the stored credential Poseidon(password, salt) as
+// a decimal string, matching what password.circom proves knowledge of
+func Compute(password, salt string) (string, error) {
+	passwordField, err := PasswordToField(password)
+	if err != nil {
+		return "", err
+	}
+
+	saltField, err := ParseFieldElement(salt)
+	if err != nil {
+		return "", fmt.Errorf("invalid salt: %w", err)
+	}
+
+	commitment, err := poseidon.Hash(passwordField, saltField)
+	if err != nil {
+		return "", err
+	}
+	return commitment.String(), nil
+}
+
+// ParseFieldElement parses a decimal string, the encoding snarkjs uses for
+// field elements, and rejects values that are not reduced modulo the field
+func ParseFieldElement(value string) (fr.Element, error) {
+	var e fr.Element
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok || n.Sign() < 0 || n.Cmp(fr.Modulus()) >= 0 || n.String() != value {
+		return e, fmt.Errorf("%q is not a canonical field element", value)
+	}
+	e.SetBigInt(n)
+	return e, nil
+}
+
+// GenerateSalt returns a uniformly random non-zero BN254 field element from
+// crypto/rand, as the decimal string snarkjs takes for circuit inputs
+func GenerateSalt() (string, error) {
+	max := new(big.Int).Sub(fr.Modulus(), big.NewInt(1))
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", fmt.Errorf("generate salt: %w", err)
+	}
+	return n.Add(n, big.NewInt(1)).String(), nil
+}
+
+// IsLegacySalt reports whether a stored salt is one of the short salts from
+// before GenerateSalt drew full field elements. Such credentials should be
+// re-keyed with a fresh salt the next time the user logs in
+func IsLegacySalt(salt string) bool {
+	n, ok := new(big.Int).SetString(salt, 10)
+	return !ok || n.BitLen() < MinSaltBits
+}
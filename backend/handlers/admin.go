@@ -1,30 +1,45 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"zkp-auth/security"
+	"github.com/threehook/zkp-auth/backend/repository"
+	"github.com/threehook/zkp-auth/backend/security"
+	"github.com/threehook/zkp-auth/backend/session"
+	"github.com/threehook/zkp-auth/backend/tokenauth"
+	"github.com/threehook/zkp-auth/backend/verifier"
 )
 
+// AdminScope is the token scope the admin endpoints require
+const AdminScope = "admin"
+
 type AdminHandler struct {
 	securityMonitor *security.SecurityMonitor
+	circuitRegistry *verifier.Registry
+	verifierPool    *verifier.Pool
+	userRepo        repository.UserRepo
+	sessions        *session.Store
 }
 
-func NewAdminHandler(securityMonitor *security.SecurityMonitor) *AdminHandler {
+func NewAdminHandler(securityMonitor *security.SecurityMonitor, circuitRegistry *verifier.Registry, verifierPool *verifier.Pool,
+	userRepo repository.UserRepo, sessions *session.Store) *AdminHandler {
 	return &AdminHandler{
 		securityMonitor: securityMonitor,
+		circuitRegistry: circuitRegistry,
+		verifierPool:    verifierPool,
+		userRepo:        userRepo,
+		sessions:        sessions,
 	}
 }
 
 func (h *AdminHandler) SecurityEvents(c *gin.Context) {
-	// Only allow admin users in production
-	username, _ := c.Get("username")
-
-	// Simple admin check - enhance this in production
-	if username != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	if !requireAdmin(c) {
 		return
 	}
 
@@ -41,10 +56,133 @@ func (h *AdminHandler) SecurityEvents(c *gin.Context) {
 		since = time.Now().Add(-1 * time.Hour)
 	}
 
-	events := h.securityMonitor.GetEvents(since)
+	events, err := h.securityMonitor.GetEvents(c.Request.Context(), since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not load security events"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"events": events,
 		"count":  len(events),
 		"since":  since,
 	})
 }
+
+func (h *AdminHandler) Circuits(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	circuits := h.circuitRegistry.List()
+	c.JSON(http.StatusOK, gin.H{
+		"circuits": circuits,
+		"count":    len(circuits),
+		"default":  h.circuitRegistry.DefaultID(),
+	})
+}
+
+func (h *AdminHandler) SetCircuitStatus(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req struct {
+		Status verifier.CircuitStatus `json:"status"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	circuitID := c.Param("id")
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid circuit version"})
+		return
+	}
+
+	if err := h.circuitRegistry.SetStatus(circuitID, version, req.Status); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	username := c.GetString("username")
+	h.securityMonitor.LogEvent(c.Request.Context(), "CIRCUIT_STATUS_CHANGED", username, c.ClientIP(), c.Request.UserAgent(), "", "",
+		fmt.Sprintf("circuit=%s version=%d status=%s", circuitID, version, req.Status), "WARN")
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":      circuitID,
+		"version": version,
+		"status":  req.Status,
+	})
+}
+
+// SetUserDisabled blocks or re-allows logins for a user. Disabling also
+// revokes every access and refresh token the user holds, so the account is
+// locked out at once rather than when its tokens expire
+func (h *AdminHandler) SetUserDisabled(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	var req struct {
+		Disabled *bool `json:"disabled"`
+	}
+	if err := c.BindJSON(&req); err != nil || req.Disabled == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	target := c.Param("username")
+	if err := h.userRepo.SetDisabled(ctx, target, *req.Disabled); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Printf("❌ Could not update user %s: %v", target, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update user"})
+		return
+	}
+	if *req.Disabled {
+		if _, err := h.sessions.RevokeAll(ctx, target); err != nil {
+			log.Printf("❌ Session revocation failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "User disabled but sessions could not be revoked"})
+			return
+		}
+	}
+
+	username := c.GetString("username")
+	h.securityMonitor.LogEvent(ctx, "USER_DISABLED_CHANGED", username, c.ClientIP(), c.Request.UserAgent(), "", "",
+		fmt.Sprintf("user=%s disabled=%t", target, *req.Disabled), "WARN")
+
+	c.JSON(http.StatusOK, gin.H{
+		"username": target,
+		"disabled": *req.Disabled,
+	})
+}
+
+// VerifierStats reports the verification pool's queue depth and latency
+func (h *AdminHandler) VerifierStats(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	if h.verifierPool == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Verification pool not enabled"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.verifierPool.Stats())
+}
+
+// requireAdmin aborts with 403 unless the caller's token carries the admin
+// scope, which the server only grants to Config.AdminUsers
+func requireAdmin(c *gin.Context) bool {
+	principal, ok := tokenauth.FromContext(c.Request.Context())
+	if !ok || !principal.HasScopes(AdminScope) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return false
+	}
+	return true
+}
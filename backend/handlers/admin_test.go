@@ -0,0 +1,87 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threehook/zkp-auth/backend/app"
+	"github.com/threehook/zkp-auth/backend/proof"
+)
+
+func TestRegisterRefusesReservedNames(t *testing.T) {
+	s := newTestServer(t, func(cfg *app.Config) {
+		cfg.AdminUsers = []string{"alice"}
+	})
+
+	for _, username := range []string{"admin", "Root", "alice", "ALICE"} {
+		status, response := s.do("POST", "/api/register/salt", "", gin.H{"username": username})
+		if status != http.StatusBadRequest {
+			t.Errorf("salt for %s = %d %v, want 400", username, status, response)
+		}
+	}
+
+	// The commitment path checks the name too, not just the salt endpoint
+	status, _ := s.do("POST", "/api/register", "", gin.H{
+		"username": "admin", "salt": "1", "commitment": "2",
+	})
+	if status != http.StatusBadRequest {
+		t.Errorf("register admin = %d, want 400", status)
+	}
+}
+
+func TestAdminEndpointsRequireAdminScope(t *testing.T) {
+	s := newTestServer(t, func(cfg *app.Config) {
+		cfg.AdminUsers = []string{"alice"}
+	})
+	s.createUser("alice", "alice password")
+	s.register("bob-user", "bob password")
+
+	aliceToken := s.login("alice", "alice password")["token"].(string)
+	if status, response := s.do("GET", "/api/admin/circuits", aliceToken, nil); status != http.StatusOK {
+		t.Fatalf("admin circuits as alice = %d %v, want 200", status, response)
+	}
+
+	bobToken := s.login("bob-user", "bob password")["token"].(string)
+	if status, _ := s.do("GET", "/api/admin/circuits", bobToken, nil); status != http.StatusForbidden {
+		t.Fatalf("admin circuits as bob = %d, want 403", status)
+	}
+}
+
+func TestAdminDisableRevokesSessions(t *testing.T) {
+	s := newTestServer(t, func(cfg *app.Config) {
+		cfg.AdminUsers = []string{"alice"}
+	})
+	s.createUser("alice", "alice password")
+	s.register("bob-user", "bob password")
+
+	aliceToken := s.login("alice", "alice password")["token"].(string)
+	bob := s.login("bob-user", "bob password")
+	bobToken, bobRefresh := bob["token"].(string), bob["refreshToken"].(string)
+
+	if status, _ := s.do("POST", "/api/admin/users/bob-user/disabled", bobToken, gin.H{"disabled": true}); status != http.StatusForbidden {
+		t.Fatalf("disable as bob = %d, want 403", status)
+	}
+	if status, _ := s.do("POST", "/api/admin/users/nobody/disabled", aliceToken, gin.H{"disabled": true}); status != http.StatusNotFound {
+		t.Fatalf("disable of a missing user = %d, want 404", status)
+	}
+	s.mustDo("POST", "/api/admin/users/bob-user/disabled", aliceToken, gin.H{"disabled": true})
+
+	// Tokens issued before the disable are dead at once, not when they expire
+	if status, _ := s.do("GET", "/api/protected", bobToken, nil); status != http.StatusUnauthorized {
+		t.Errorf("access token of a disabled user = %d, want 401", status)
+	}
+	if status, _ := s.do("POST", "/api/token/refresh", "", gin.H{"refreshToken": bobRefresh}); status != http.StatusUnauthorized {
+		t.Errorf("refresh token of a disabled user = %d, want 401", status)
+	}
+	if status, _ := s.do("POST", "/api/login", "", gin.H{"username": "bob-user", "proof": s.prove("bob-user", "bob password", s.storedCredential("bob-user"), proof.ProofTypeLogin)}); status != http.StatusForbidden {
+		t.Errorf("login of a disabled user = %d, want 403", status)
+	}
+
+	// Re-enabling lets bob log in again, but the old tokens stay revoked
+	s.mustDo("POST", "/api/admin/users/bob-user/disabled", aliceToken, gin.H{"disabled": false})
+	if status, _ := s.do("GET", "/api/protected", bobToken, nil); status != http.StatusUnauthorized {
+		t.Errorf("old access token after re-enabling = %d, want 401", status)
+	}
+	s.login("bob-user", "bob password")
+}
@@ -1,18 +1,28 @@
 package handlers
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
-	"math/rand"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
-	"zkp-auth/app"
-	"zkp-auth/proof"
-	"zkp-auth/repository"
-	"zkp-auth/validation"
+	"github.com/threehook/zkp-auth/backend/app"
+	"github.com/threehook/zkp-auth/backend/credential"
+	"github.com/threehook/zkp-auth/backend/proof"
+	"github.com/threehook/zkp-auth/backend/repository"
+	"github.com/threehook/zkp-auth/backend/session"
+	"github.com/threehook/zkp-auth/backend/tokenauth"
+	"github.com/threehook/zkp-auth/backend/validation"
+	"github.com/threehook/zkp-auth/backend/verifier"
 )
 
 type AuthHandler struct {
@@ -25,10 +35,76 @@ func NewAuthHandler(deps *app.Dependencies) *AuthHandler {
 	}
 }
 
-func (h *AuthHandler) Register(c *gin.Context) {
+// reservedUsernames cannot be registered, so nobody can pass for an operator
+var reservedUsernames = []string{"admin", "administrator", "root", "system"}
+
+// validateNewUsername checks the name of an account being registered. Admin
+// users are reserved too: listing a name in Config.AdminUsers before its
+// account exists must not hand the admin scope to whoever registers it first
+func (h *AuthHandler) validateNewUsername(validator *validation.Validator, username string) {
+	validator.ValidateUsername(username)
+	reserved := func(name string) bool { return strings.EqualFold(name, username) }
+	if slices.ContainsFunc(reservedUsernames, reserved) || slices.ContainsFunc(h.deps.Config.AdminUsers, reserved) {
+		validator.AddError("username", "username is reserved")
+	}
+}
+
+// RegisterSalt starts a registration by issuing the salt the client must use
+// to compute its credential commitment
+func (h *AuthHandler) RegisterSalt(c *gin.Context) {
 	var req struct {
 		Username string `json:"username"`
-		Password string `json:"password"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	validator := validation.New()
+	h.validateNewUsername(validator, req.Username)
+	if !validator.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": validator.Errors})
+		return
+	}
+
+	ctx := c.Request.Context()
+	exists, err := h.deps.UserRepo.UserExists(ctx, req.Username)
+	if err != nil {
+		log.Printf("❌ User lookup failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not issue salt"})
+		return
+	}
+	if exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
+		return
+	}
+
+	salt, expiresAt, err := h.deps.SaltStore.Issue(ctx, req.Username, c.ClientIP())
+	if err != nil {
+		respondSaltIssueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"salt":      salt,
+		"expiresAt": expiresAt.Unix(),
+	})
+}
+
+// Register completes a registration with the commitment Poseidon(password, salt)
+// computed by the client for the salt from RegisterSalt, optionally with a proof
+// of knowledge of its preimage. The client may also register commitments
+// Poseidon(code, salt) to one-time recovery codes for the same salt. The legacy
+// plaintext path is only served while Config.AllowPlaintextRegistration is set
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req struct {
+		Username      string         `json:"username"`
+		Salt          string         `json:"salt"`
+		Commitment    string         `json:"commitment"`
+		Proof         *proof.Request `json:"proof,omitempty"`
+		RecoveryCodes []string       `json:"recoveryCodes,omitempty"`
+		Password      string         `json:"password,omitempty"` // legacy plaintext registration
 	}
 
 	if err := c.BindJSON(&req); err != nil {
@@ -37,29 +113,162 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	if req.Password != "" {
+		h.registerPlaintext(c, req.Username, req.Password)
+		return
+	}
+
 	// Validation
-	if req.Username == "" || req.Password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username and password are required"})
+	validator := validation.New()
+	h.validateNewUsername(validator, req.Username)
+	h.validateCommitment(validator, req.Username, req.Salt, req.Commitment, req.Proof)
+	validator.ValidateRecoveryCodes(req.RecoveryCodes, repository.MaxRecoveryCodes)
+	if !validator.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": validator.Errors})
 		return
 	}
 
-	if len(req.Username) < 3 || len(req.Username) > 50 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username must be 3-50 characters"})
+	ipAddress := c.ClientIP()
+	userAgent := c.Request.UserAgent()
+
+	if err := h.deps.SaltStore.Consume(c.Request.Context(), req.Username, req.Salt); err != nil {
+		h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "REGISTRATION_FAILED", req.Username, ipAddress, userAgent, "", "",
+			err.Error(), "WARN")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or expired salt"})
+		return
+	}
+
+	if !h.verifyCommitmentProof(c, req.Username, req.Salt, req.Commitment, req.Proof, "REGISTRATION_PROOF_FAILED") {
+		return
+	}
+
+	recoveryCodes := make([]repository.RecoveryCode, len(req.RecoveryCodes))
+	for i, commitment := range req.RecoveryCodes {
+		recoveryCodes[i] = repository.RecoveryCode{Salt: req.Salt, Commitment: commitment}
+	}
+
+	h.createUser(c, req.Username, req.Salt, req.Commitment, recoveryCodes)
+}
+
+// registerPlaintext is the legacy path where the server derives the commitment
+// from a password it receives in the clear
+func (h *AuthHandler) registerPlaintext(c *gin.Context, username, password string) {
+	if !h.deps.Config.AllowPlaintextRegistration {
+		h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "PLAINTEXT_REGISTRATION_REJECTED", username, c.ClientIP(), c.Request.UserAgent(), "", "",
+			"Client sent a plaintext password while plaintext registration is disabled", "WARN")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Plaintext registration is disabled; submit a commitment instead"})
+		return
+	}
+
+	validator := validation.New()
+	h.validateNewUsername(validator, username)
+	validator.ValidatePassword(password)
+	if !validator.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": validator.Errors})
 		return
 	}
 
-	// Create user
-	user, err := h.deps.UserRepo.CreateUser(req.Username, req.Password)
+	h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "PLAINTEXT_REGISTRATION", username, c.ClientIP(), c.Request.UserAgent(), "", "",
+		"Deprecated plaintext registration: the server received the password", "WARN")
+
+	salt, err := credential.GenerateSalt()
+	if err != nil {
+		log.Printf("❌ Salt generation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not register user"})
+		return
+	}
+	commitment, err := credential.Compute(password, salt)
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.createUser(c, username, salt, commitment, nil)
+}
+
+func (h *AuthHandler) createUser(c *gin.Context, username, salt, commitment string, recoveryCodes []repository.RecoveryCode) {
+	ctx := c.Request.Context()
+	user, err := h.deps.UserRepo.CreateUser(ctx, username, salt, commitment)
+	if errors.Is(err, repository.ErrUserExists) {
 		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
 		return
 	}
+	var userErr *repository.UserError
+	if errors.As(err, &userErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": userErr.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Creating user %s failed: %v", username, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not create user"})
+		return
+	}
+
+	if len(recoveryCodes) > 0 {
+		if err := h.deps.UserRepo.SetRecoveryCodes(ctx, username, recoveryCodes); err != nil {
+			// Do not leave an account behind whose recovery codes the user
+			// believes are registered
+			log.Printf("❌ Storing recovery codes for %s failed: %v", username, err)
+			if err := h.deps.UserRepo.DeleteUser(ctx, username); err != nil {
+				log.Printf("❌ Removing %s after failed registration failed: %v", username, err)
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not register user"})
+			return
+		}
+	}
+
+	log.Printf("🔐 User registered - Username: %s, Salt: %s, Recovery codes: %d", user.Username, user.Salt, len(recoveryCodes))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "User registered successfully",
+		"salt":          user.Salt,
+		"recoveryCodes": len(recoveryCodes),
+	})
+}
+
+// LoginChallenge issues the single-use nonce the next login proof must carry,
+// or with purpose "auth" the next auth proof, such as the one a password
+// change takes. A challenge only redeems a proof of its own purpose.
+// Challenges are issued for unknown usernames too, so this endpoint does not
+// reveal which accounts exist
+func (h *AuthHandler) LoginChallenge(c *gin.Context) {
+	var req struct {
+		Username string          `json:"username"`
+		Purpose  proof.ProofType `json:"purpose,omitempty"` // login (default) or auth
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+	if req.Purpose == "" {
+		req.Purpose = proof.ProofTypeLogin
+	}
+
+	validator := validation.New()
+	validator.ValidateUsername(req.Username)
+	if req.Purpose != proof.ProofTypeLogin && req.Purpose != proof.ProofTypeAuth {
+		validator.AddError("purpose", fmt.Sprintf("purpose must be %q or %q", proof.ProofTypeLogin, proof.ProofTypeAuth))
+	}
+	if !validator.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": validator.Errors})
+		return
+	}
+
+	ipAddress := c.ClientIP()
+	challenge, err := h.deps.ProofValidator.GetChallengeStore().Issue(c.Request.Context(), req.Username, ipAddress, req.Purpose)
+	if err != nil {
+		log.Printf("❌ Challenge generation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not issue challenge"})
+		return
+	}
 
-	log.Printf("🔐 User registered - Username: %s, Salt: %s", user.Username, user.Salt)
+	h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "CHALLENGE_ISSUED", req.Username, ipAddress, c.Request.UserAgent(), "", challenge.Value,
+		fmt.Sprintf("Challenge issued for a %s proof", req.Purpose), "INFO")
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "User registered successfully",
-		"salt":    user.Salt,
+		"challenge": challenge.Value,
+		"expiresAt": challenge.ExpiresAt.Unix(),
 	})
 }
 
@@ -70,7 +279,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&req); err != nil {
-		h.deps.SecurityMonitor.LogEvent("INVALID_JSON", "", c.ClientIP(), c.Request.UserAgent(), "", "",
+		h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "INVALID_JSON", "", c.ClientIP(), c.Request.UserAgent(), "", "",
 			"Invalid JSON in login", "WARN")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
 		return
@@ -92,20 +301,34 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	validator.ValidatePublicSignals(req.Proof.PublicSignals)
+	validator.ValidateCircuit(req.Proof.CircuitID, req.Proof.CircuitVersion)
+	if req.Proof.ProofType != proof.ProofTypeLogin {
+		validator.AddError("proofType", fmt.Sprintf("proof type must be %q", proof.ProofTypeLogin))
+	}
+
+	// The proof may commit to a username hash, so both copies must agree
+	if req.Proof.Username != req.Username {
+		validator.AddError("username", "username does not match proof username")
+	}
 	validator.ValidateNonce(req.Proof.Nonce)
 	validator.ValidateTimestamp(req.Proof.Timestamp)
 
 	if !validator.Valid() {
-		h.deps.SecurityMonitor.LogEvent("VALIDATION_FAILED", req.Username, c.ClientIP(), c.Request.UserAgent(), "", req.Proof.Nonce,
+		h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "VALIDATION_FAILED", req.Username, c.ClientIP(), c.Request.UserAgent(), "", req.Proof.Nonce,
 			fmt.Sprintf("Validation errors: %v", validator.Errors), "WARN")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": validator.Errors})
 		return
 	}
 
 	// Verify user exists
-	user, exists := h.deps.UserRepo.GetUser(req.Username)
+	user, exists, err := h.deps.UserRepo.GetUser(c.Request.Context(), req.Username)
+	if err != nil {
+		log.Printf("❌ User lookup failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not load user"})
+		return
+	}
 	if !exists {
-		h.deps.SecurityMonitor.LogEvent("USER_NOT_FOUND", req.Username, c.ClientIP(), c.Request.UserAgent(), "", req.Proof.Nonce,
+		h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "USER_NOT_FOUND", req.Username, c.ClientIP(), c.Request.UserAgent(), "", req.Proof.Nonce,
 			"User not found during login", "WARN")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
 		return
@@ -115,93 +338,259 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	ipAddress := c.ClientIP()
 	userAgent := c.Request.UserAgent()
 
+	// Disabled accounts are refused before any proof work is spent on them
+	if user.Disabled {
+		h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "LOGIN_DISABLED_ACCOUNT", req.Username, ipAddress, userAgent, "", req.Proof.Nonce,
+			"Login attempt for a disabled account", "WARN")
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+		return
+	}
+
 	// Security logging - login attempt
-	h.deps.SecurityMonitor.LogEvent("LOGIN_ATTEMPT", req.Username, ipAddress, userAgent, "", req.Proof.Nonce,
+	h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "LOGIN_ATTEMPT", req.Username, ipAddress, userAgent, "", req.Proof.Nonce,
 		"Login attempt initiated", "INFO")
 
 	// Validate proof request with replay protection
-	if err := h.deps.ProofValidator.ValidateProofRequest(req.Proof, ipAddress, userAgent); err != nil {
-		h.deps.SecurityMonitor.LogEvent("LOGIN_FAILED", req.Username, ipAddress, userAgent, "", req.Proof.Nonce,
+	if err := h.deps.ProofValidator.ValidateProofRequest(c.Request.Context(), req.Proof, ipAddress, userAgent); err != nil {
+		h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "LOGIN_FAILED", req.Username, ipAddress, userAgent, "", req.Proof.Nonce,
 			fmt.Sprintf("Proof validation failed: %s", err.Error()), "WARN")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Proof validation failed: " + err.Error()})
 		return
 	}
 
 	// Verify ZKP proof
-	if !h.verifyZKProof(req.Proof, user) {
-		h.deps.SecurityMonitor.LogEvent("PROOF_VERIFICATION_FAILED", req.Username, ipAddress, userAgent, "", req.Proof.Nonce,
-			"ZKP proof verification failed", "ERROR")
+	result, err := h.verifyZKProof(c.Request.Context(), req.Proof, user)
+	if h.verifierUnavailable(c, req.Username, req.Proof.Nonce, result, err) {
+		return
+	}
+	if err != nil {
+		h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "PROOF_VERIFICATION_FAILED", req.Username, ipAddress, userAgent, "", req.Proof.Nonce,
+			"ZKP proof verification failed: "+verificationDetails(result, err), "ERROR")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "ZKP proof verification failed"})
 		return
 	}
 
-	// Generate JWT token
-	token := h.generateJWT(req.Username)
+	if !result.Bound {
+		h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "UNBOUND_PROOF", req.Username, ipAddress, userAgent, "", req.Proof.Nonce,
+			fmt.Sprintf("Circuit %s v%d does not bind nonce, timestamp and username", result.CircuitID, result.CircuitVersion), "WARN")
+	}
+
+	if result.Status == verifier.CircuitDeprecated {
+		h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "DEPRECATED_CIRCUIT", req.Username, ipAddress, userAgent, "", req.Proof.Nonce,
+			fmt.Sprintf("Proof generated with deprecated circuit %s v%d", result.CircuitID, result.CircuitVersion), "WARN")
+	}
+
+	if err := h.deps.UserRepo.RecordLogin(c.Request.Context(), req.Username, time.Now()); err != nil {
+		log.Printf("❌ Failed to record login for %s: %v", req.Username, err)
+	}
+
+	response := gin.H{
+		"user": req.Username,
+	}
+	if err := h.issueTokens(c.Request.Context(), req.Username, response); err != nil {
+		log.Printf("❌ Token issue failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not issue tokens"})
+		return
+	}
 
 	// Security logging - successful login
-	h.deps.SecurityMonitor.LogEvent("LOGIN_SUCCESS", req.Username, ipAddress, userAgent, "", req.Proof.Nonce,
+	h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "LOGIN_SUCCESS", req.Username, ipAddress, userAgent, "", req.Proof.Nonce,
 		"User authenticated successfully with ZKP", "INFO")
 
-	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"user":  req.Username,
-	})
+	// Credentials from before field-sized salts are re-keyed: the client gets
+	// a fresh salt and posts the new commitment to /api/credential/upgrade
+	if credential.IsLegacySalt(user.Salt) {
+		salt, expiresAt, err := h.deps.SaltStore.Issue(c.Request.Context(), req.Username, ipAddress)
+		if err != nil {
+			log.Printf("❌ Salt issue failed: %v", err)
+		} else {
+			h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "LEGACY_SALT_LOGIN", req.Username, ipAddress, userAgent, "", req.Proof.Nonce,
+				"Login with a legacy short salt; credential upgrade offered", "WARN")
+			response["saltUpgrade"] = gin.H{
+				"salt":      salt,
+				"expiresAt": expiresAt.Unix(),
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
-func (h *AuthHandler) verifyZKProof(proofReq proof.Request, user interface{}) bool {
+func (h *AuthHandler) verifyZKProof(ctx context.Context, proofReq proof.Request, user interface{}) (verifier.Result, error) {
 	// Type assertion to get the actual user
-	authUser, ok := user.(repository.User) // Use auth.User type instead of anonymous struct
+	authUser, ok := user.(repository.User)
 	if !ok {
+		return verifier.Result{}, fmt.Errorf("unexpected user record type %T", user)
+	}
+
+	credential := verifier.Credential{
+		Salt:       authUser.Salt,
+		Commitment: authUser.Commitment,
+	}
+	return h.deps.ZKPVerifier.Verify(ctx, verifier.NewInput(proofReq, credential))
+}
+
+// verifierUnavailable answers 503 with Retry-After when the verification pool
+// shed the request or ran out of time, which says nothing about the proof
+func (h *AuthHandler) verifierUnavailable(c *gin.Context, username, nonce string, result verifier.Result, err error) bool {
+	if !errors.Is(err, verifier.ErrOverloaded) && !errors.Is(err, verifier.ErrTimeout) {
 		return false
 	}
 
-	proofData := map[string]interface{}{
-		"proof":         proofReq.Proof,
-		"publicSignals": proofReq.PublicSignals,
-		"username":      authUser.Username,
-		"salt":          authUser.Salt,
-		"storedHash":    authUser.PasswordHash,
-		"nonce":         proofReq.Nonce,
-		"timestamp":     proofReq.Timestamp,
+	retryAfter := result.RetryAfter.Round(time.Second)
+	if retryAfter < time.Second {
+		retryAfter = time.Second
 	}
 
-	return h.deps.ZKPVerifier.VerifyProof(proofData)
+	h.deps.SecurityMonitor.LogEvent(c.Request.Context(), "VERIFIER_UNAVAILABLE", username, c.ClientIP(), c.Request.UserAgent(), "", nonce,
+		verificationDetails(result, err), "WARN")
+	c.Header("Retry-After", strconv.Itoa(int(retryAfter/time.Second)))
+	c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Verification service is busy, please retry later"})
+	return true
 }
 
-func (h *AuthHandler) generateJWT(username string) string {
+// verificationDetails formats a failed verification for the security log
+func verificationDetails(result verifier.Result, err error) string {
+	reason := "error"
+	if result.Reason != nil {
+		reason = result.Reason.Error()
+	}
+	circuit := "none"
+	if result.CircuitID != "" {
+		circuit = fmt.Sprintf("%s v%d (%s)", result.CircuitID, result.CircuitVersion, result.Protocol)
+	}
+	return fmt.Sprintf("reason=%q circuit=%s duration=%s: %v", reason, circuit, result.Duration, err)
+}
+
+func (h *AuthHandler) generateJWT(ctx context.Context, username string) (string, error) {
 	expirationTime := time.Now().Add(h.deps.Config.JWTExpiry)
 
-	claims := &jwt.RegisteredClaims{
-		Subject:   username,
-		ExpiresAt: jwt.NewNumericDate(expirationTime),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		NotBefore: jwt.NewNumericDate(time.Now()),
-		Issuer:    "zkp-auth",
-		ID:        h.generateRandomID(),
+	tokenID, err := h.generateRandomID()
+	if err != nil {
+		return "", fmt.Errorf("generate token ID: %w", err)
+	}
+
+	sessionVersion, err := h.deps.Sessions.Version(ctx, username)
+	if err != nil {
+		return "", fmt.Errorf("load session version: %w", err)
+	}
+
+	claims := &tokenauth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   username,
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    h.deps.Config.JWTIssuer,
+			Audience:  h.deps.Config.JWTAudience,
+			ID:        tokenID,
+		},
+		Scope:          strings.Join(h.tokenScopes(username), " "),
+		SessionVersion: sessionVersion,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(h.deps.Config.JWTSecret)
+	tokenString, err := h.deps.TokenKeys.Sign(claims)
 	if err != nil {
-		return ""
+		return "", fmt.Errorf("sign access token: %w", err)
 	}
-	return tokenString
+	return tokenString, nil
 }
 
-func (h *AuthHandler) generateRandomID() string {
-	return fmt.Sprintf("jti-%d-%d", time.Now().UnixNano(), rand.Intn(1000000))
+// tokenScopes are the scopes granted to every token, plus the admin scope
+// for the configured admin users
+func (h *AuthHandler) tokenScopes(username string) []string {
+	scopes := slices.Clone(h.deps.Config.TokenScopes)
+	if slices.Contains(h.deps.Config.AdminUsers, username) {
+		scopes = append(scopes, AdminScope)
+	}
+	return scopes
 }
 
+// generateRandomID returns a 128-bit token ID from crypto/rand
+func (h *AuthHandler) generateRandomID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate token ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Logout revokes the access token the request was made with and, if the body
+// names it, the refresh token family of the same login. A refresh token of
+// another user is refused, so a leaked one cannot be used to log its owner out
 func (h *AuthHandler) Logout(c *gin.Context) {
-	username, _ := c.Get("username")
+	var req struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	// The body is optional
+	if c.Request.ContentLength != 0 {
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	username := c.GetString("username")
+
+	// Ownership is checked before anything is revoked, so a refused request
+	// leaves the caller logged in
+	if req.RefreshToken != "" {
+		owner, err := h.deps.RefreshTokens.Owner(ctx, req.RefreshToken)
+		switch {
+		case errors.Is(err, session.ErrRefreshTokenInvalid):
+			// Unknown refresh tokens are ignored
+			req.RefreshToken = ""
+		case err != nil:
+			log.Printf("❌ Refresh token lookup failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not log out"})
+			return
+		case owner.Username != username:
+			h.deps.SecurityMonitor.LogEvent(ctx, "LOGOUT_FAILED", username, c.ClientIP(), c.Request.UserAgent(), owner.FamilyID, "",
+				"Logout with a refresh token of user "+owner.Username, "WARN")
+			c.JSON(http.StatusForbidden, gin.H{"error": "Refresh token belongs to another user"})
+			return
+		}
+	}
+
+	if err := h.deps.Revocations.Revoke(ctx, c.GetString("tokenID"), c.GetTime("tokenExpiresAt")); err != nil {
+		log.Printf("❌ Token revocation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not log out"})
+		return
+	}
+	if req.RefreshToken != "" {
+		if err := h.deps.RefreshTokens.RevokeFamily(ctx, req.RefreshToken); err != nil {
+			log.Printf("❌ Refresh token revocation failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not log out"})
+			return
+		}
+	}
 
 	// Security logging
-	h.deps.SecurityMonitor.LogEvent("LOGOUT", username.(string), c.ClientIP(), c.Request.UserAgent(), "", "",
+	h.deps.SecurityMonitor.LogEvent(ctx, "LOGOUT", username, c.ClientIP(), c.Request.UserAgent(), "", "",
 		"User logged out successfully", "INFO")
 
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
+// LogoutAll revokes every access and refresh token issued to the user so far,
+// on every device, including the one making the request
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	ctx := c.Request.Context()
+	username := c.GetString("username")
+
+	if _, err := h.deps.Sessions.RevokeAll(ctx, username); err != nil {
+		log.Printf("❌ Session revocation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not log out"})
+		return
+	}
+
+	h.deps.SecurityMonitor.LogEvent(ctx, "LOGOUT_ALL", username, c.ClientIP(), c.Request.UserAgent(), "", "",
+		"All sessions revoked", "INFO")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out on all devices"})
+}
+
 func (h *AuthHandler) Protected(c *gin.Context) {
 	username, exists := c.Get("username")
 	if !exists {
@@ -0,0 +1,79 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threehook/zkp-auth/backend/app"
+	"github.com/threehook/zkp-auth/backend/proof"
+	"github.com/threehook/zkp-auth/backend/verifier"
+)
+
+func TestPlaintextRegistrationIsOptIn(t *testing.T) {
+	body := gin.H{"username": "alice", "password": "correct horse"}
+
+	s := newTestServer(t, nil)
+	if status, response := s.do("POST", "/api/register", "", body); status != http.StatusBadRequest {
+		t.Fatalf("plaintext registration by default = %d %v, want 400", status, response)
+	}
+	if _, exists, _ := s.deps.UserRepo.GetUser(t.Context(), "alice"); exists {
+		t.Fatal("refused plaintext registration created the user")
+	}
+
+	s = newTestServer(t, func(cfg *app.Config) {
+		cfg.AllowPlaintextRegistration = true
+	})
+	s.mustDo("POST", "/api/register", "", body)
+	s.login("alice", "correct horse")
+}
+
+func TestLogoutRefusesAnotherUsersRefreshToken(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.register("alice", "alice password")
+	s.register("mallory", "mallory password")
+	alice := s.login("alice", "alice password")
+	mallory := s.login("mallory", "mallory password")
+
+	status, _ := s.do("POST", "/api/logout", mallory["token"].(string), gin.H{"refreshToken": alice["refreshToken"]})
+	if status != http.StatusForbidden {
+		t.Fatalf("logout with alice's refresh token = %d, want 403", status)
+	}
+	// Neither session was touched
+	s.mustDo("GET", "/api/protected", mallory["token"].(string), nil)
+	aliceRefreshed := s.mustDo("POST", "/api/token/refresh", "", gin.H{"refreshToken": alice["refreshToken"]})
+
+	s.mustDo("POST", "/api/logout", aliceRefreshed["token"].(string), gin.H{"refreshToken": aliceRefreshed["refreshToken"]})
+	if status, _ := s.do("POST", "/api/token/refresh", "", gin.H{"refreshToken": aliceRefreshed["refreshToken"]}); status != http.StatusUnauthorized {
+		t.Fatalf("refresh after logout = %d, want 401", status)
+	}
+}
+
+func TestRegistrationProofMustBeRegisterProof(t *testing.T) {
+	s := newTestServer(t, func(cfg *app.Config) {
+		cfg.RequireRegistrationProof = true
+	})
+
+	register := func(purpose proof.ProofType) (int, map[string]any) {
+		salt := s.mustDo("POST", "/api/register/salt", "", gin.H{"username": "alice"})["salt"].(string)
+		stored := verifier.Credential{Salt: salt, Commitment: s.commit("password", salt)}
+		return s.do("POST", "/api/register", "", gin.H{
+			"username":   "alice",
+			"salt":       salt,
+			"commitment": stored.Commitment,
+			"proof":      s.proveNonce("alice", "password", stored, salt, purpose),
+		})
+	}
+
+	for _, purpose := range []proof.ProofType{proof.ProofTypeLogin, proof.ProofTypeAuth, proof.ProofTypeRecovery} {
+		status, response := register(purpose)
+		details, _ := response["details"].(map[string]any)
+		if status != http.StatusBadRequest || details["proofType"] == nil {
+			t.Fatalf("registration with a %s proof = %d %v, want 400 for the proof type", purpose, status, response)
+		}
+	}
+	if status, response := register(proof.ProofTypeRegister); status != http.StatusOK {
+		t.Fatalf("registration with a register proof = %d %v, want 200", status, response)
+	}
+	s.login("alice", "password")
+}
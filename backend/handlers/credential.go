@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threehook/zkp-auth/backend/credential"
+	"github.com/threehook/zkp-auth/backend/proof"
+	"github.com/threehook/zkp-auth/backend/repository"
+	"github.com/threehook/zkp-auth/backend/validation"
+	"github.com/threehook/zkp-auth/backend/verifier"
+)
+
+// validateCommitment adds the checks shared by every endpoint that stores a
+// client-computed commitment for a salt the server issued. The optional proof
+// of knowledge of the commitment must be a register proof
+func (h *AuthHandler) validateCommitment(validator *validation.Validator, username, salt, commitment string, proofReq *proof.Request) {
+	validator.ValidateFieldElement("salt", salt)
+	validator.ValidateFieldElement("commitment", commitment)
+	if proofReq == nil && h.deps.Config.RequireRegistrationProof {
+		validator.AddError("proof", "proof of knowledge of the commitment is required")
+	}
+	if proofReq != nil {
+		validator.ValidateProofStructure(proofReq.Proof)
+		validator.ValidatePublicSignals(proofReq.PublicSignals)
+		validator.ValidateCircuit(proofReq.CircuitID, proofReq.CircuitVersion)
+		if proofReq.ProofType == "" {
+			proofReq.ProofType = proof.ProofTypeRegister
+		}
+		if proofReq.ProofType != proof.ProofTypeRegister {
+			validator.AddError("proofType", fmt.Sprintf("proof type must be %q", proof.ProofTypeRegister))
+		}
+		if proofReq.Username != username {
+			validator.AddError("username", "username does not match proof username")
+		}
+		// Binding the proof to the issued salt makes it specific to this request
+		if proofReq.Nonce != salt {
+			validator.AddError("proof", "proof nonce must be the issued salt")
+		}
+	}
+}
+
+// validateChallengeProof adds the checks for a proof made for a login
+// challenge that authorises something other than a login
+func validateChallengeProof(validator *validation.Validator, proofReq proof.Request, username string, proofType proof.ProofType) {
+	if proofReq.Proof == nil {
+		validator.AddError("proof", "proof object is required")
+	} else {
+		validator.ValidateProofStructure(proofReq.Proof)
+	}
+	validator.ValidatePublicSignals(proofReq.PublicSignals)
+	validator.ValidateCircuit(proofReq.CircuitID, proofReq.CircuitVersion)
+	if proofReq.ProofType != proofType {
+		validator.AddError("proofType", fmt.Sprintf("proof type must be %q", proofType))
+	}
+	if proofReq.Username != username {
+		validator.AddError("username", "username does not match proof username")
+	}
+	validator.ValidateNonce(proofReq.Nonce)
+	validator.ValidateTimestamp(proofReq.Timestamp)
+}
+
+// verifyCommitmentProof checks the optional proof of knowledge of the
+// commitment's preimage. On failure it logs failureEvent, answers the request
+// and returns false
+func (h *AuthHandler) verifyCommitmentProof(c *gin.Context, username, salt, commitment string, proofReq *proof.Request, failureEvent string) bool {
+	if proofReq == nil {
+		return true
+	}
+
+	credential := verifier.Credential{Salt: salt, Commitment: commitment}
+	result, err := h.deps.ZKPVerifier.Verify(c.Request.Context(), verifier.NewInput(*proofReq, credential))
+	if h.verifierUnavailable(c, username, proofReq.Nonce, result, err) {
+		return false
+	}
+	if err != nil {
+		h.deps.SecurityMonitor.LogEvent(c.Request.Context(), failureEvent, username, c.ClientIP(), c.Request.UserAgent(), "", proofReq.Nonce,
+			"Commitment proof verification failed: "+verificationDetails(result, err), "WARN")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Commitment proof verification failed"})
+		return false
+	}
+	return true
+}
+
+// UpgradeCredential re-keys a credential that still uses a legacy short salt.
+// Login hands such users a fresh field-sized salt; the client posts
+// Poseidon(password, salt) for it here, together with a fresh auth proof
+// against the old credential made for an auth challenge, and a proof of
+// knowledge of the new commitment when registration requires one. Like a
+// password change it revokes every existing session and answers with the
+// tokens for a new one. Credentials that already use a field-sized salt can
+// only be replaced through a password change
+func (h *AuthHandler) UpgradeCredential(c *gin.Context) {
+	h.replaceCredential(c, credentialReplacement{
+		failureEvent:    "CREDENTIAL_UPGRADE_FAILED",
+		disabledDetails: "Credential upgrade for a disabled account",
+		legacyOnly:      true,
+		successEvent:    "CREDENTIAL_UPGRADED",
+		successDetails:  "Legacy salt replaced with a field-sized salt and all sessions revoked",
+		done:            "Credential upgraded",
+	})
+}
+
+// respondSaltIssueError answers a failed SaltStore.Issue. A full store is
+// load rather than a server fault, so the client is asked to retry
+func respondSaltIssueError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrTooManySalts) {
+		log.Printf("⚠️ Salt store full, refusing salt for %s", c.ClientIP())
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Too many pending salts, please retry later"})
+		return
+	}
+	log.Printf("❌ Salt issue failed: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not issue salt"})
+}
+
+// PasswordSalt issues the salt the client must use for the new commitment in
+// a password change
+func (h *AuthHandler) PasswordSalt(c *gin.Context) {
+	salt, expiresAt, err := h.deps.SaltStore.Issue(c.Request.Context(), c.GetString("username"), c.ClientIP())
+	if err != nil {
+		respondSaltIssueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"salt":      salt,
+		"expiresAt": expiresAt.Unix(),
+	})
+}
+
+// ChangePassword replaces the caller's credential. A valid token alone is not
+// enough: the request carries a fresh auth proof against the old credential,
+// made for an auth challenge, and the new commitment for a salt from
+// PasswordSalt. The credential is swapped only if it is still the one the
+// proof was checked against, and every existing session is revoked; the
+// response carries the tokens for a new session
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	h.replaceCredential(c, credentialReplacement{
+		failureEvent:    "PASSWORD_CHANGE_FAILED",
+		disabledDetails: "Password change for a disabled account",
+		successEvent:    "PASSWORD_CHANGED",
+		successDetails:  "Credential rotated and all sessions revoked",
+		done:            "Password changed",
+	})
+}
+
+// credentialReplacement is what sets ChangePassword and UpgradeCredential
+// apart: their security events, their messages and which credentials qualify
+type credentialReplacement struct {
+	failureEvent    string
+	disabledDetails string
+	legacyOnly      bool // only credentials with a legacy short salt qualify
+	successEvent    string
+	successDetails  string
+	done            string // what happened, for the response messages
+}
+
+// replaceCredential is the flow behind ChangePassword and UpgradeCredential:
+// it checks a fresh auth proof against the old credential, spends the issued
+// salt, swaps in the new commitment, revokes every session and answers with
+// the tokens for a new one
+func (h *AuthHandler) replaceCredential(c *gin.Context, r credentialReplacement) {
+	var req struct {
+		Proof      proof.Request  `json:"proof"` // proof of the old credential
+		Salt       string         `json:"salt"`
+		Commitment string         `json:"commitment"`
+		NewProof   *proof.Request `json:"newProof,omitempty"` // proof of knowledge of the new commitment
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	username := c.GetString("username")
+	ctx := c.Request.Context()
+	ipAddress := c.ClientIP()
+	userAgent := c.Request.UserAgent()
+
+	if req.Proof.ProofType == "" {
+		req.Proof.ProofType = proof.ProofTypeAuth
+	}
+
+	validator := validation.New()
+	validateChallengeProof(validator, req.Proof, username, proof.ProofTypeAuth)
+	h.validateCommitment(validator, username, req.Salt, req.Commitment, req.NewProof)
+	if !validator.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": validator.Errors})
+		return
+	}
+
+	user, exists, err := h.deps.UserRepo.GetUser(ctx, username)
+	if err != nil {
+		log.Printf("❌ User lookup failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not load user"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.Disabled {
+		h.deps.SecurityMonitor.LogEvent(ctx, r.failureEvent, username, ipAddress, userAgent, "", req.Proof.Nonce,
+			r.disabledDetails, "WARN")
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account disabled"})
+		return
+	}
+	if r.legacyOnly && !credential.IsLegacySalt(user.Salt) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Credential already uses a field-sized salt"})
+		return
+	}
+
+	// The old credential must be proven now, for a fresh single-use challenge
+	if err := h.deps.ProofValidator.ValidateProofRequest(ctx, req.Proof, ipAddress, userAgent); err != nil {
+		h.deps.SecurityMonitor.LogEvent(ctx, r.failureEvent, username, ipAddress, userAgent, "", req.Proof.Nonce,
+			"Proof validation failed: "+err.Error(), "WARN")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Proof validation failed: " + err.Error()})
+		return
+	}
+
+	result, err := h.verifyZKProof(ctx, req.Proof, user)
+	if h.verifierUnavailable(c, username, req.Proof.Nonce, result, err) {
+		return
+	}
+	if err != nil {
+		h.deps.SecurityMonitor.LogEvent(ctx, r.failureEvent, username, ipAddress, userAgent, "", req.Proof.Nonce,
+			"Old credential proof verification failed: "+verificationDetails(result, err), "ERROR")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "ZKP proof verification failed"})
+		return
+	}
+
+	if err := h.deps.SaltStore.Consume(ctx, username, req.Salt); err != nil {
+		h.deps.SecurityMonitor.LogEvent(ctx, r.failureEvent, username, ipAddress, userAgent, "", req.Proof.Nonce,
+			err.Error(), "WARN")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown or expired salt"})
+		return
+	}
+
+	if !h.verifyCommitmentProof(c, username, req.Salt, req.Commitment, req.NewProof, r.failureEvent) {
+		return
+	}
+
+	if !h.rotateCredential(c, user, req.Salt, req.Commitment) {
+		return
+	}
+
+	if _, err := h.deps.Sessions.RevokeAll(ctx, username); err != nil {
+		// The credential has changed but old tokens still work; make that loud
+		h.deps.SecurityMonitor.LogEvent(ctx, "SESSION_REVOCATION_FAILED", username, ipAddress, userAgent, "", "",
+			err.Error(), "CRITICAL")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": r.done + " but sessions could not be revoked"})
+		return
+	}
+
+	h.deps.SecurityMonitor.LogEvent(ctx, r.successEvent, username, ipAddress, userAgent, "", req.Proof.Nonce,
+		r.successDetails, "INFO")
+
+	response := gin.H{
+		"message": r.done,
+		"salt":    req.Salt,
+	}
+	if err := h.issueTokens(ctx, username, response); err != nil {
+		log.Printf("❌ Token issue failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": r.done + " but no new session could be started"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// rotateCredential swaps in the new credential if the stored one is still
+// user's. It answers the request and returns false on failure
+func (h *AuthHandler) rotateCredential(c *gin.Context, user repository.User, salt, commitment string) bool {
+	_, err := h.deps.UserRepo.RotateCredential(c.Request.Context(), user.Username, user.Commitment, salt, commitment)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, repository.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, repository.ErrCredentialChanged):
+		c.JSON(http.StatusConflict, gin.H{"error": "Credential was changed by another request"})
+	default:
+		log.Printf("❌ Credential update failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update credential"})
+	}
+	return false
+}
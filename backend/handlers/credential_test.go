@@ -0,0 +1,125 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threehook/zkp-auth/backend/proof"
+	"github.com/threehook/zkp-auth/backend/verifier"
+)
+
+func TestChangePasswordRevokesSessions(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.register("alice", "old password")
+	old := s.login("alice", "old password")
+	oldToken, oldRefresh := old["token"].(string), old["refreshToken"].(string)
+	oldCredential := s.storedCredential("alice")
+
+	salt := s.mustDo("POST", "/api/password/salt", oldToken, nil)["salt"].(string)
+	change := gin.H{
+		"proof":      s.prove("alice", "old password", oldCredential, proof.ProofTypeAuth),
+		"salt":       salt,
+		"commitment": s.commit("new password", salt),
+	}
+	changed := s.mustDo("POST", "/api/password/change", oldToken, change)
+	if changed["salt"] != salt {
+		t.Fatalf("change salt = %v, want %s", changed["salt"], salt)
+	}
+
+	// Every token issued before the change is dead, on this device and others
+	if status, _ := s.do("GET", "/api/protected", oldToken, nil); status != http.StatusUnauthorized {
+		t.Errorf("old access token = %d, want 401", status)
+	}
+	if status, _ := s.do("POST", "/api/token/refresh", "", gin.H{"refreshToken": oldRefresh}); status != http.StatusUnauthorized {
+		t.Errorf("old refresh token = %d, want 401", status)
+	}
+
+	newToken := changed["token"].(string)
+	if status, response := s.do("GET", "/api/protected", newToken, nil); status != http.StatusOK {
+		t.Fatalf("new access token = %d %v, want 200", status, response)
+	}
+	s.mustDo("POST", "/api/token/refresh", "", gin.H{"refreshToken": changed["refreshToken"]})
+
+	// The auth challenge was single use
+	if status, _ := s.do("POST", "/api/password/change", newToken, change); status == http.StatusOK {
+		t.Error("replayed password change succeeded")
+	}
+
+	stale := s.prove("alice", "old password", oldCredential, proof.ProofTypeLogin)
+	if status, _ := s.do("POST", "/api/login", "", gin.H{"username": "alice", "proof": stale}); status != http.StatusUnauthorized {
+		t.Errorf("login with the old password = %d, want 401", status)
+	}
+	s.login("alice", "new password")
+}
+
+func TestUpgradeCredential(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	// alice registered before salts were field-sized
+	const legacySalt = "123456"
+	if _, err := s.deps.UserRepo.CreateUser(context.Background(), "alice", legacySalt, s.commit("alice password", legacySalt)); err != nil {
+		t.Fatal(err)
+	}
+	old := s.login("alice", "alice password")
+	oldToken, oldRefresh := old["token"].(string), old["refreshToken"].(string)
+	offer, ok := old["saltUpgrade"].(map[string]any)
+	if !ok {
+		t.Fatalf("login with a legacy salt offered no upgrade: %v", old)
+	}
+	salt := offer["salt"].(string)
+	oldCredential := s.storedCredential("alice")
+	upgrade := func(proofReq proof.Request, salt string) gin.H {
+		return gin.H{"proof": proofReq, "salt": salt, "commitment": s.commit("alice password", salt)}
+	}
+
+	// A valid proof, but of a credential alice does not hold
+	other := verifier.Credential{Salt: legacySalt, Commitment: s.commit("other password", legacySalt)}
+	wrong := s.prove("alice", "other password", other, proof.ProofTypeAuth)
+	if status, _ := s.do("POST", "/api/credential/upgrade", oldToken, upgrade(wrong, salt)); status != http.StatusUnauthorized {
+		t.Errorf("upgrade with a proof of another credential = %d, want 401", status)
+	}
+
+	// A proof whose challenge was already spent, here on a request for a salt
+	// that was never issued
+	stale := s.prove("alice", "alice password", oldCredential, proof.ProofTypeAuth)
+	if status, _ := s.do("POST", "/api/credential/upgrade", oldToken, upgrade(stale, "987654321987654321987654321")); status != http.StatusBadRequest {
+		t.Fatalf("upgrade with an unissued salt = %d, want 400", status)
+	}
+	if status, _ := s.do("POST", "/api/credential/upgrade", oldToken, upgrade(stale, salt)); status != http.StatusUnauthorized {
+		t.Errorf("upgrade with a stale proof = %d, want 401", status)
+	}
+	if s.storedCredential("alice") != oldCredential {
+		t.Fatal("a refused upgrade changed the credential")
+	}
+
+	fresh := s.prove("alice", "alice password", oldCredential, proof.ProofTypeAuth)
+	upgraded := s.mustDo("POST", "/api/credential/upgrade", oldToken, upgrade(fresh, salt))
+	if upgraded["salt"] != salt || s.storedCredential("alice").Salt != salt {
+		t.Fatalf("upgrade salt = %v, stored %s, want %s", upgraded["salt"], s.storedCredential("alice").Salt, salt)
+	}
+
+	// Every token issued before the upgrade is dead
+	if status, _ := s.do("GET", "/api/protected", oldToken, nil); status != http.StatusUnauthorized {
+		t.Errorf("old access token = %d, want 401", status)
+	}
+	if status, _ := s.do("POST", "/api/token/refresh", "", gin.H{"refreshToken": oldRefresh}); status != http.StatusUnauthorized {
+		t.Errorf("old refresh token = %d, want 401", status)
+	}
+	newToken := upgraded["token"].(string)
+	if status, response := s.do("GET", "/api/protected", newToken, nil); status != http.StatusOK {
+		t.Fatalf("new access token = %d %v, want 200", status, response)
+	}
+
+	// The credential now has a field-sized salt: no more offers or upgrades
+	again := s.login("alice", "alice password")
+	if _, offered := again["saltUpgrade"]; offered {
+		t.Error("login after the upgrade still offers one")
+	}
+	nextSalt := s.mustDo("POST", "/api/password/salt", newToken, nil)["salt"].(string)
+	current := s.prove("alice", "alice password", s.storedCredential("alice"), proof.ProofTypeAuth)
+	if status, _ := s.do("POST", "/api/credential/upgrade", newToken, upgrade(current, nextSalt)); status != http.StatusConflict {
+		t.Errorf("upgrade of a field-sized salt = %d, want 409", status)
+	}
+}
@@ -0,0 +1,242 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threehook/zkp-auth/backend/app"
+	"github.com/threehook/zkp-auth/backend/credential"
+	"github.com/threehook/zkp-auth/backend/handlers"
+	"github.com/threehook/zkp-auth/backend/proof"
+	"github.com/threehook/zkp-auth/backend/prover"
+	"github.com/threehook/zkp-auth/backend/repository"
+	"github.com/threehook/zkp-auth/backend/security"
+	"github.com/threehook/zkp-auth/backend/session"
+	"github.com/threehook/zkp-auth/backend/signing"
+	"github.com/threehook/zkp-auth/backend/tokenauth"
+	"github.com/threehook/zkp-auth/backend/verifier"
+)
+
+// testProver is set up once: every test server verifies its circuit
+var testProver = sync.OnceValues(func() (*prover.Prover, error) {
+	return prover.Setup(1)
+})
+
+type testServer struct {
+	t      *testing.T
+	deps   *app.Dependencies
+	router *gin.Engine
+	prover *prover.Prover
+}
+
+// newTestServer wires the handlers as main does, with in-memory stores and an
+// HS256 key. configure may adjust the config before anything is built
+func newTestServer(t *testing.T, configure func(cfg *app.Config)) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	passwordProver, err := testProver()
+	if err != nil {
+		t.Fatalf("prover setup: %v", err)
+	}
+
+	cfg := app.Config{
+		JWTSecret:          []byte("test-secret"),
+		ProofTTL:           5 * time.Minute,
+		JWTExpiry:          15 * time.Minute,
+		RefreshTokenTTL:    time.Hour,
+		JWTIssuer:          "zkp-auth",
+		TokenScopes:        []string{"user"},
+		ChallengeTTL:       2 * time.Minute,
+		SaltTTL:            10 * time.Minute,
+		RequireBoundProofs: true,
+	}
+	if configure != nil {
+		configure(&cfg)
+	}
+
+	users := repository.NewMemoryUserRepo()
+	proofValidator := proof.NewValidator(proof.NewStore(cfg.ProofTTL), proof.NewChallengeStore(cfg.ChallengeTTL), cfg.ProofTTL, 2*time.Minute)
+	registry := verifier.NewRegistry(prover.CircuitID)
+	if err := registry.Register(passwordProver.Circuit()); err != nil {
+		t.Fatal(err)
+	}
+	zkpVerifier := verifier.NewRegistryVerifier(registry)
+	zkpVerifier.RequireBinding = cfg.RequireBoundProofs
+
+	tokenKeys := signing.NewHMACKeyRing(cfg.JWTSecret)
+	sessions := session.NewStore(users)
+	revocations := session.NewRevocationStore(users)
+	tokenVerifier, err := tokenauth.NewVerifier(tokenauth.Config{
+		Keyfunc:     tokenKeys.Keyfunc,
+		Issuer:      cfg.JWTIssuer,
+		Revocations: session.NewTokenChecker(sessions, revocations),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps := &app.Dependencies{
+		Config:          cfg,
+		Prover:          passwordProver,
+		UserRepo:        users,
+		SaltStore:       repository.NewSaltStore(cfg.SaltTTL),
+		ProofValidator:  proofValidator,
+		ZKPVerifier:     zkpVerifier,
+		CircuitRegistry: registry,
+		SecurityMonitor: security.NewSecurityMonitor(1000),
+		Sessions:        sessions,
+		RefreshTokens:   session.NewRefreshStore(sessions, users, cfg.RefreshTokenTTL),
+		Revocations:     revocations,
+		TokenKeys:       tokenKeys,
+		TokenVerifier:   tokenVerifier,
+	}
+
+	authHandler := handlers.NewAuthHandler(deps)
+	adminHandler := handlers.NewAdminHandler(deps.SecurityMonitor, registry, nil, deps.UserRepo, deps.Sessions)
+
+	router := gin.New()
+	router.POST("/api/register/salt", authHandler.RegisterSalt)
+	router.POST("/api/register", authHandler.Register)
+	router.POST("/api/login/challenge", authHandler.LoginChallenge)
+	router.POST("/api/login", authHandler.Login)
+	router.POST("/api/recover/challenge", authHandler.RecoverChallenge)
+	router.POST("/api/recover", authHandler.Recover)
+	router.POST("/api/token/refresh", authHandler.RefreshToken)
+
+	protected := router.Group("/api")
+	protected.Use(handlers.AuthMiddleware(tokenVerifier))
+	protected.POST("/logout", authHandler.Logout)
+	protected.POST("/logout/all", authHandler.LogoutAll)
+	protected.POST("/credential/upgrade", authHandler.UpgradeCredential)
+	protected.POST("/password/salt", authHandler.PasswordSalt)
+	protected.POST("/password/change", authHandler.ChangePassword)
+	protected.GET("/protected", authHandler.Protected)
+	protected.GET("/admin/circuits", adminHandler.Circuits)
+	protected.POST("/admin/users/:username/disabled", adminHandler.SetUserDisabled)
+
+	return &testServer{t: t, deps: deps, router: router, prover: passwordProver}
+}
+
+// do sends body as JSON, with token as bearer token unless empty, and decodes
+// the JSON response
+func (s *testServer) do(method, path, token string, body any) (int, map[string]any) {
+	s.t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	var response map[string]any
+	if rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			s.t.Fatalf("%s %s: decode %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code, response
+}
+
+// mustDo is do for requests that must succeed with 200
+func (s *testServer) mustDo(method, path, token string, body any) map[string]any {
+	s.t.Helper()
+	status, response := s.do(method, path, token, body)
+	if status != http.StatusOK {
+		s.t.Fatalf("%s %s = %d %v, want 200", method, path, status, response)
+	}
+	return response
+}
+
+// createUser stores a user directly, bypassing registration
+func (s *testServer) createUser(username, password string) {
+	s.t.Helper()
+	salt, err := credential.GenerateSalt()
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if _, err := s.deps.UserRepo.CreateUser(context.Background(), username, salt, s.commit(password, salt)); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// register signs up through the salt and commitment endpoints
+func (s *testServer) register(username, password string, recoveryCodes ...string) map[string]any {
+	s.t.Helper()
+	salt := s.mustDo("POST", "/api/register/salt", "", gin.H{"username": username})["salt"].(string)
+	commitments := make([]string, len(recoveryCodes))
+	for i, code := range recoveryCodes {
+		commitments[i] = s.commit(code, salt)
+	}
+	return s.mustDo("POST", "/api/register", "", gin.H{
+		"username":      username,
+		"salt":          salt,
+		"commitment":    s.commit(password, salt),
+		"recoveryCodes": commitments,
+	})
+}
+
+// commit returns Poseidon(secret, salt) as the client computes it
+func (s *testServer) commit(secret, salt string) string {
+	s.t.Helper()
+	commitment, err := credential.Compute(secret, salt)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return commitment
+}
+
+// prove proves knowledge of secret behind stored for a login or auth challenge
+func (s *testServer) prove(username, secret string, stored verifier.Credential, purpose proof.ProofType) proof.Request {
+	s.t.Helper()
+	challenge := s.mustDo("POST", "/api/login/challenge", "", gin.H{"username": username, "purpose": purpose})["challenge"].(string)
+	return s.proveNonce(username, secret, stored, challenge, purpose)
+}
+
+// proveNonce proves knowledge of secret behind stored, bound to nonce
+func (s *testServer) proveNonce(username, secret string, stored verifier.Credential, nonce string, purpose proof.ProofType) proof.Request {
+	s.t.Helper()
+	request, err := s.prover.Prove(username, secret, stored, nonce, time.Now().Unix())
+	if err != nil {
+		s.t.Fatalf("prove: %v", err)
+	}
+	request.ProofType = purpose
+	return request
+}
+
+// storedCredential returns the credential the user repository holds
+func (s *testServer) storedCredential(username string) verifier.Credential {
+	s.t.Helper()
+	user, exists, err := s.deps.UserRepo.GetUser(context.Background(), username)
+	if err != nil || !exists {
+		s.t.Fatalf("GetUser(%s) = %v, %v", username, exists, err)
+	}
+	return verifier.Credential{Salt: user.Salt, Commitment: user.Commitment}
+}
+
+// login signs in with a proof of the password and returns the response
+func (s *testServer) login(username, password string) map[string]any {
+	s.t.Helper()
+	request := s.prove(username, password, s.storedCredential(username), proof.ProofTypeLogin)
+	return s.mustDo("POST", "/api/login", "", gin.H{"username": username, "proof": request})
+}
@@ -2,53 +2,29 @@ package handlers
 
 import (
 	"fmt"
-	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
-	"zkp-auth/app"
+	"github.com/threehook/zkp-auth/backend/app"
+	"github.com/threehook/zkp-auth/backend/tokenauth"
 )
 
-// AuthMiddleware (capitalized to export it)
-func AuthMiddleware(jwtSecret []byte) gin.HandlerFunc {
+// AuthMiddleware (capitalized to export it). It verifies tokens the way other
+// services do with tokenauth; the verifier's session.TokenChecker refuses
+// tokens revoked by logout or together with every other token of the user
+func AuthMiddleware(tokens *tokenauth.Verifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString := c.GetHeader("Authorization")
-		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing token"})
+		principal, err := tokens.VerifyRequest(c.Request)
+		if err != nil {
+			tokenauth.WriteError(c.Writer, err)
 			c.Abort()
 			return
 		}
 
-		if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
-			tokenString = tokenString[7:]
-		}
-
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method")
-			}
-			return jwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
-
-		// Extract username from Subject claim
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			if subject, exists := claims["sub"]; exists {
-				if username, ok := subject.(string); ok {
-					c.Set("username", username)
-					c.Next()
-					return
-				}
-			}
-		}
-
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-		c.Abort()
+		c.Set("username", principal.Subject)
+		c.Set("tokenID", principal.TokenID)
+		c.Set("tokenExpiresAt", principal.ExpiresAt)
+		c.Request = c.Request.WithContext(tokenauth.NewContext(c.Request.Context(), principal))
+		c.Next()
 	}
 }
 
@@ -63,8 +39,8 @@ func SecurityMiddleware(deps *app.Dependencies) gin.HandlerFunc {
 		// Log security events for certain status codes
 		status := c.Writer.Status()
 		if status >= 400 {
-			username, _ := c.Get("username")
-			deps.SecurityMonitor.LogEvent("HTTP_ERROR", username.(string), ipAddress, userAgent, "", "",
+			// Requests refused before authentication have no username
+			deps.SecurityMonitor.LogEvent(c.Request.Context(), "HTTP_ERROR", c.GetString("username"), ipAddress, userAgent, "", "",
 				fmt.Sprintf("path=%s status=%d", path, status), "WARN")
 		}
 	}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threehook/zkp-auth/backend/prover"
+)
+
+// ProverKey serves one of the key files of the password-gnark circuit this
+// deployment verifies, for the browser's wasm prover. They are public: a
+// proving key only proves statements its holder knows a witness for
+func (h *AuthHandler) ProverKey(c *gin.Context) {
+	file := c.Param("file")
+	switch file {
+	case "circuit.json", "verification_key.json", prover.ProvingKeyFile:
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown key file"})
+		return
+	}
+
+	dir := prover.VersionDir(h.deps.Config.CircuitKeyDir, h.deps.Prover.Circuit().Version)
+	// Revalidated on every load, so clients pick up a new version at once
+	c.Header("Cache-Control", "no-cache")
+	c.File(filepath.Join(dir, file))
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threehook/zkp-auth/backend/proof"
+	"github.com/threehook/zkp-auth/backend/repository"
+	"github.com/threehook/zkp-auth/backend/validation"
+	"github.com/threehook/zkp-auth/backend/verifier"
+)
+
+// recoverySaltKey keeps salts issued for a recovery apart from registration
+// and password change salts, so a salt from an unauthenticated recovery start
+// can only be spent on a recovery. Usernames cannot contain ':'
+func recoverySaltKey(username string) string {
+	return "recover:" + username
+}
+
+// RecoverChallenge starts an account recovery. It hands out a challenge for
+// the recovery proof and the salt for the new credential. Like
+// LoginChallenge it answers for unknown usernames too
+func (h *AuthHandler) RecoverChallenge(c *gin.Context) {
+	var req struct {
+		Username string `json:"username"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	validator := validation.New()
+	validator.ValidateUsername(req.Username)
+	if !validator.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": validator.Errors})
+		return
+	}
+
+	ctx := c.Request.Context()
+	ipAddress := c.ClientIP()
+	challenge, err := h.deps.ProofValidator.GetChallengeStore().Issue(ctx, req.Username, ipAddress, proof.ProofTypeRecovery)
+	if err != nil {
+		log.Printf("❌ Challenge generation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not issue challenge"})
+		return
+	}
+	salt, _, err := h.deps.SaltStore.Issue(ctx, recoverySaltKey(req.Username), ipAddress)
+	if err != nil {
+		respondSaltIssueError(c, err)
+		return
+	}
+
+	// Anyone can ask for a challenge, so this is only worth a warning; the
+	// attempt itself is logged as CRITICAL by Recover
+	h.deps.SecurityMonitor.LogEvent(ctx, "RECOVERY_STARTED", req.Username, ipAddress, c.Request.UserAgent(), "", challenge.Value,
+		"Account recovery challenge issued", "WARN")
+
+	c.JSON(http.StatusOK, gin.H{
+		"challenge": challenge.Value,
+		"salt":      salt,
+		"expiresAt": challenge.ExpiresAt.Unix(),
+	})
+}
+
+// Recover sets a new credential for a user who lost their password. The user
+// proves knowledge of one unused recovery code, whose commitment the request
+// names, with a recovery proof for the challenge from RecoverChallenge, and
+// posts the new commitment for the salt issued with it. The code is burned
+// together with the credential change and every existing session is revoked
+func (h *AuthHandler) Recover(c *gin.Context) {
+	var req struct {
+		Username     string         `json:"username"`
+		RecoveryCode string         `json:"recoveryCode"` // commitment of the code the proof is for
+		Proof        proof.Request  `json:"proof"`
+		Salt         string         `json:"salt"`
+		Commitment   string         `json:"commitment"`
+		NewProof     *proof.Request `json:"newProof,omitempty"` // proof of knowledge of the new commitment
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	ipAddress := c.ClientIP()
+	userAgent := c.Request.UserAgent()
+
+	if req.Proof.ProofType == "" {
+		req.Proof.ProofType = proof.ProofTypeRecovery
+	}
+
+	validator := validation.New()
+	validator.ValidateUsername(req.Username)
+	validator.ValidateFieldElement("recoveryCode", req.RecoveryCode)
+	validateChallengeProof(validator, req.Proof, req.Username, proof.ProofTypeRecovery)
+	h.validateCommitment(validator, req.Username, req.Salt, req.Commitment, req.NewProof)
+	if !validator.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": validator.Errors})
+		return
+	}
+
+	// recoveryFailed records a refused recovery. The client only learns that
+	// recovery failed, not whether the user or the code exists
+	recoveryFailed := func(status int, details string) {
+		h.deps.SecurityMonitor.LogEvent(ctx, "RECOVERY_FAILED", req.Username, ipAddress, userAgent, "", req.Proof.Nonce,
+			details, "CRITICAL")
+		c.JSON(status, gin.H{"error": "Recovery failed"})
+	}
+
+	user, exists, err := h.deps.UserRepo.GetUser(ctx, req.Username)
+	if err != nil {
+		log.Printf("❌ User lookup failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not load user"})
+		return
+	}
+	if !exists {
+		recoveryFailed(http.StatusUnauthorized, "Recovery for an unknown user")
+		return
+	}
+	if user.Disabled {
+		recoveryFailed(http.StatusUnauthorized, "Recovery for a disabled account")
+		return
+	}
+
+	if err := h.deps.ProofValidator.ValidateProofRequest(ctx, req.Proof, ipAddress, userAgent); err != nil {
+		recoveryFailed(http.StatusUnauthorized, "Proof validation failed: "+err.Error())
+		return
+	}
+
+	codes, err := h.deps.UserRepo.RecoveryCodes(ctx, req.Username)
+	if err != nil {
+		log.Printf("❌ Recovery code lookup failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not load recovery codes"})
+		return
+	}
+	i := slices.IndexFunc(codes, func(code repository.RecoveryCode) bool {
+		return code.Commitment == req.RecoveryCode && !code.Used()
+	})
+	if i < 0 {
+		recoveryFailed(http.StatusUnauthorized, "Unknown or already used recovery code")
+		return
+	}
+
+	credential := verifier.Credential{Salt: codes[i].Salt, Commitment: codes[i].Commitment}
+	result, err := h.deps.ZKPVerifier.Verify(ctx, verifier.NewInput(req.Proof, credential))
+	if h.verifierUnavailable(c, req.Username, req.Proof.Nonce, result, err) {
+		return
+	}
+	if err != nil {
+		recoveryFailed(http.StatusUnauthorized, "Recovery code proof verification failed: "+verificationDetails(result, err))
+		return
+	}
+
+	if err := h.deps.SaltStore.Consume(ctx, recoverySaltKey(req.Username), req.Salt); err != nil {
+		recoveryFailed(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !h.verifyCommitmentProof(c, req.Username, req.Salt, req.Commitment, req.NewProof, "RECOVERY_FAILED") {
+		return
+	}
+
+	_, err = h.deps.UserRepo.RecoverCredential(ctx, req.Username, req.RecoveryCode, req.Salt, req.Commitment)
+	switch {
+	case errors.Is(err, repository.ErrRecoveryCodeInvalid):
+		recoveryFailed(http.StatusConflict, "Recovery code was burned by a concurrent request")
+		return
+	case errors.Is(err, repository.ErrUserNotFound):
+		recoveryFailed(http.StatusUnauthorized, "User was deleted during recovery")
+		return
+	case err != nil:
+		log.Printf("❌ Credential recovery failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update credential"})
+		return
+	}
+
+	if _, err := h.deps.Sessions.RevokeAll(ctx, req.Username); err != nil {
+		h.deps.SecurityMonitor.LogEvent(ctx, "SESSION_REVOCATION_FAILED", req.Username, ipAddress, userAgent, "", "",
+			err.Error(), "CRITICAL")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Account recovered but sessions could not be revoked"})
+		return
+	}
+
+	remaining := repository.UnusedRecoveryCodes(codes) - 1
+	h.deps.SecurityMonitor.LogEvent(ctx, "ACCOUNT_RECOVERED", req.Username, ipAddress, userAgent, "", req.Proof.Nonce,
+		fmt.Sprintf("Credential reset with a recovery code; %d codes left, all sessions revoked", remaining), "CRITICAL")
+
+	response := gin.H{
+		"message":                "Account recovered",
+		"salt":                   req.Salt,
+		"remainingRecoveryCodes": remaining,
+	}
+	if err := h.issueTokens(ctx, req.Username, response); err != nil {
+		log.Printf("❌ Token issue failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Account recovered but no new session could be started"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
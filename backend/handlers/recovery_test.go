@@ -0,0 +1,83 @@
+package handlers_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threehook/zkp-auth/backend/proof"
+	"github.com/threehook/zkp-auth/backend/verifier"
+)
+
+// recover runs a recovery for username with the recovery code, setting
+// newPassword, and returns the status and response
+func (s *testServer) recover(username, code string, codeCredential verifier.Credential, newPassword string) (int, map[string]any) {
+	s.t.Helper()
+	started := s.mustDo("POST", "/api/recover/challenge", "", gin.H{"username": username})
+	salt := started["salt"].(string)
+	return s.do("POST", "/api/recover", "", gin.H{
+		"username":     username,
+		"recoveryCode": codeCredential.Commitment,
+		"proof":        s.proveNonce(username, code, codeCredential, started["challenge"].(string), proof.ProofTypeRecovery),
+		"salt":         salt,
+		"commitment":   s.commit(newPassword, salt),
+	})
+}
+
+func TestRecoverBurnsTheCode(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.register("alice", "lost password", "code one", "code two")
+	oldToken := s.login("alice", "lost password")["token"].(string)
+
+	// Recovery codes are committed with the registration salt
+	registered := s.storedCredential("alice")
+	codeOne := verifier.Credential{Salt: registered.Salt, Commitment: s.commit("code one", registered.Salt)}
+	codeTwo := verifier.Credential{Salt: registered.Salt, Commitment: s.commit("code two", registered.Salt)}
+
+	status, response := s.recover("alice", "code one", codeOne, "new password")
+	if status != http.StatusOK {
+		t.Fatalf("recover = %d %v, want 200", status, response)
+	}
+	if response["remainingRecoveryCodes"] != 1.0 {
+		t.Errorf("remainingRecoveryCodes = %v, want 1", response["remainingRecoveryCodes"])
+	}
+	if status, _ := s.do("GET", "/api/protected", oldToken, nil); status != http.StatusUnauthorized {
+		t.Errorf("token from before the recovery = %d, want 401", status)
+	}
+	s.login("alice", "new password")
+
+	// A burned code is refused with the same answer as a wrong one
+	status, burned := s.recover("alice", "code one", codeOne, "other password")
+	if status != http.StatusUnauthorized {
+		t.Fatalf("recover with a burned code = %d %v, want 401", status, burned)
+	}
+	status, wrong := s.recover("alice", "code three", verifier.Credential{
+		Salt: registered.Salt, Commitment: s.commit("code three", registered.Salt),
+	}, "other password")
+	if status != http.StatusUnauthorized || !reflect.DeepEqual(wrong, burned) {
+		t.Fatalf("recover with an unknown code = %d %v, want 401 %v", status, wrong, burned)
+	}
+	s.login("alice", "new password")
+
+	if status, response := s.recover("alice", "code two", codeTwo, "newer password"); status != http.StatusOK {
+		t.Fatalf("recover with the second code = %d %v, want 200", status, response)
+	}
+	s.login("alice", "newer password")
+}
+
+func TestRecoverFailsAlikeForDisabledAndUnknownUsers(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.register("alice", "lost password", "code one")
+	registered := s.storedCredential("alice")
+	codeOne := verifier.Credential{Salt: registered.Salt, Commitment: s.commit("code one", registered.Salt)}
+	if err := s.deps.UserRepo.SetDisabled(t.Context(), "alice", true); err != nil {
+		t.Fatal(err)
+	}
+
+	disabledStatus, disabled := s.recover("alice", "code one", codeOne, "new password")
+	unknownStatus, unknown := s.recover("nobody", "code one", codeOne, "new password")
+	if disabledStatus != http.StatusUnauthorized || disabledStatus != unknownStatus || !reflect.DeepEqual(disabled, unknown) {
+		t.Fatalf("disabled user = %d %v, unknown user = %d %v, want the same 401", disabledStatus, disabled, unknownStatus, unknown)
+	}
+}
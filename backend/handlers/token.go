@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threehook/zkp-auth/backend/session"
+	"github.com/threehook/zkp-auth/backend/tokenauth"
+)
+
+// issueTokens adds an access token and the first refresh token of a new
+// family to response. Every proof-authenticated step that signs the user in
+// starts its own family. The access token is signed first so a failure leaves
+// no unused family behind
+func (h *AuthHandler) issueTokens(ctx context.Context, username string, response gin.H) error {
+	accessToken, err := h.generateJWT(ctx, username)
+	if err != nil {
+		return err
+	}
+	refresh, err := h.deps.RefreshTokens.Issue(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	response["token"] = accessToken
+	response["expiresIn"] = int(h.deps.Config.JWTExpiry / time.Second)
+	response["refreshToken"] = refresh.Token
+	response["refreshExpiresAt"] = refresh.ExpiresAt.Unix()
+	return nil
+}
+
+// RefreshToken exchanges a refresh token for a new access token and a new
+// refresh token. The presented token cannot be used again; if it is, the
+// whole family is revoked and the reuse is reported as CRITICAL
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+		return
+	}
+	if req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": gin.H{"refreshToken": "refresh token is required"}})
+		return
+	}
+
+	ctx := c.Request.Context()
+	ipAddress := c.ClientIP()
+	userAgent := c.Request.UserAgent()
+
+	// The access token is signed before the refresh token is rotated: a
+	// rotated token cannot be presented again without revoking its family, so
+	// failing after the rotation would log the client out
+	owner, err := h.deps.RefreshTokens.Owner(ctx, req.RefreshToken)
+	var accessToken string
+	if err == nil {
+		accessToken, err = h.generateJWT(ctx, owner.Username)
+		if err != nil {
+			log.Printf("❌ Access token signing failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not refresh token"})
+			return
+		}
+	}
+	var refresh session.RefreshToken
+	if err == nil {
+		refresh, err = h.deps.RefreshTokens.Rotate(ctx, req.RefreshToken)
+	}
+	switch {
+	case errors.Is(err, session.ErrRefreshTokenReused):
+		h.deps.SecurityMonitor.LogEvent(ctx, "REFRESH_TOKEN_REUSE", refresh.Username, ipAddress, userAgent, refresh.FamilyID, "",
+			"Rotated refresh token presented again; token family revoked", "CRITICAL")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	case errors.Is(err, session.ErrRefreshTokenInvalid):
+		h.deps.SecurityMonitor.LogEvent(ctx, "REFRESH_FAILED", "", ipAddress, userAgent, "", "",
+			err.Error(), "WARN")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	case err != nil:
+		log.Printf("❌ Refresh token rotation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not refresh token"})
+		return
+	}
+
+	// Accounts disabled or deleted since the login get no new tokens
+	user, exists, err := h.deps.UserRepo.GetUser(ctx, refresh.Username)
+	if err != nil {
+		log.Printf("❌ User lookup failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not load user"})
+		return
+	}
+	if !exists || user.Disabled {
+		if err := h.deps.RefreshTokens.RevokeFamily(ctx, refresh.Token); err != nil {
+			log.Printf("❌ Revoking refresh token family failed: %v", err)
+		}
+		h.deps.SecurityMonitor.LogEvent(ctx, "REFRESH_FAILED", refresh.Username, ipAddress, userAgent, refresh.FamilyID, "",
+			"Refresh for a disabled or deleted account; token family revoked", "WARN")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	h.deps.SecurityMonitor.LogEvent(ctx, "TOKEN_REFRESHED", refresh.Username, ipAddress, userAgent, refresh.FamilyID, "",
+		"Refresh token rotated", "INFO")
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":            accessToken,
+		"expiresIn":        int(h.deps.Config.JWTExpiry / time.Second),
+		"refreshToken":     refresh.Token,
+		"refreshExpiresAt": refresh.ExpiresAt.Unix(),
+	})
+}
+
+// IntrospectToken tells services whether an access token is still active
+// (RFC 7662), so they see logouts and revoked sessions. Callers authenticate
+// with INTROSPECTION_SECRET as a bearer token. The answer only repeats claims
+// the token carries; anything refused, revoked or not, is just inactive
+func (h *AuthHandler) IntrospectToken(c *gin.Context) {
+	secret := h.deps.Config.IntrospectionSecret
+	presented, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if len(secret) == 0 || subtle.ConstantTimeCompare([]byte(presented), secret) != 1 {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid introspection credentials"})
+		return
+	}
+
+	token := c.PostForm("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": gin.H{"token": "token is required"}})
+		return
+	}
+
+	principal, err := h.deps.TokenVerifier.Verify(c.Request.Context(), token)
+	if errors.Is(err, tokenauth.ErrUnavailable) {
+		log.Printf("❌ Token introspection failed: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Could not check token"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	if err != nil {
+		c.JSON(http.StatusOK, tokenauth.Introspection{Active: false})
+		return
+	}
+	c.JSON(http.StatusOK, tokenauth.NewIntrospection(principal))
+}
+
+// JWKS publishes the public keys access tokens are verified with. Verifiers
+// may cache the set for a few minutes; a new key is published long before it
+// signs. An HS256 deployment publishes no keys
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.deps.TokenKeys.JWKS())
+}
@@ -0,0 +1,33 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.register("alice", "password")
+	first := s.login("alice", "password")["refreshToken"].(string)
+	otherDevice := s.login("alice", "password")["refreshToken"].(string)
+
+	rotated := s.mustDo("POST", "/api/token/refresh", "", gin.H{"refreshToken": first})
+	if status, response := s.do("GET", "/api/protected", rotated["token"].(string), nil); status != http.StatusOK {
+		t.Fatalf("refreshed access token = %d %v, want 200", status, response)
+	}
+	second := rotated["refreshToken"].(string)
+	if second == first {
+		t.Fatal("refresh returned the presented token")
+	}
+
+	if status, _ := s.do("POST", "/api/token/refresh", "", gin.H{"refreshToken": first}); status != http.StatusUnauthorized {
+		t.Fatalf("reused refresh token = %d, want 401", status)
+	}
+	// The reuse ends the whole family, including the token issued in its place
+	if status, _ := s.do("POST", "/api/token/refresh", "", gin.H{"refreshToken": second}); status != http.StatusUnauthorized {
+		t.Fatalf("successor of a reused token = %d, want 401", status)
+	}
+	s.mustDo("POST", "/api/token/refresh", "", gin.H{"refreshToken": otherDevice})
+}
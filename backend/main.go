@@ -1,19 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/gin-gonic/gin"
+	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/joho/godotenv"
-	"zkp-auth/app"
-	"zkp-auth/handlers"
-	"zkp-auth/middleware"
-	"zkp-auth/proof"
-	"zkp-auth/repository"
-	"zkp-auth/security"
-	"zkp-auth/verifier"
+	"github.com/threehook/zkp-auth/backend/app"
+	"github.com/threehook/zkp-auth/backend/handlers"
+	"github.com/threehook/zkp-auth/backend/middleware"
+	"github.com/threehook/zkp-auth/backend/proof"
+	"github.com/threehook/zkp-auth/backend/prover"
+	"github.com/threehook/zkp-auth/backend/repository"
+	"github.com/threehook/zkp-auth/backend/security"
+	"github.com/threehook/zkp-auth/backend/session"
+	"github.com/threehook/zkp-auth/backend/signing"
+	"github.com/threehook/zkp-auth/backend/tokenauth"
+	"github.com/threehook/zkp-auth/backend/verifier"
+	_ "modernc.org/sqlite"
 )
 
 func main() {
@@ -33,26 +46,141 @@ func main() {
 
 func initDependencies() *app.Dependencies {
 	cfg := app.Config{
-		JWTSecret:  getJWTSecret(),
+		JWTSecret:  []byte(os.Getenv("JWT_SECRET")),
 		ServerPort: getEnv("SERVER_PORT", "8080"),
 		CorsOrigin: getEnv("CORS_ORIGIN", "http://localhost:5173"),
 		ProofTTL:   5 * time.Minute,
-		JWTExpiry:  24 * time.Hour,
+		JWTExpiry:  getDurationEnv("ACCESS_TOKEN_TTL", 15*time.Minute),
+
+		RefreshTokenTTL: getDurationEnv("REFRESH_TOKEN_TTL", 7*24*time.Hour),
+
+		JWTSigningAlg:  getEnv("JWT_SIGNING_ALG", signing.AlgEdDSA),
+		JWTKeyDir:      getEnv("JWT_KEY_DIR", "data/jwt-keys"),
+		JWTKeyRotation: getDurationEnv("JWT_KEY_ROTATION", 30*24*time.Hour),
+		JWTKeyOverlap:  getDurationEnv("JWT_KEY_OVERLAP", 24*time.Hour),
+
+		JWTIssuer:   getEnv("JWT_ISSUER", "zkp-auth"),
+		JWTAudience: strings.FieldsFunc(os.Getenv("JWT_AUDIENCE"), isListSeparator),
+		TokenScopes: strings.FieldsFunc(getEnv("ACCESS_TOKEN_SCOPES", "user"), isListSeparator),
+		AdminUsers:  strings.FieldsFunc(os.Getenv("ADMIN_USERS"), isListSeparator),
+
+		IntrospectionSecret: []byte(os.Getenv("INTROSPECTION_SECRET")),
+
+		ChallengeTTL: 2 * time.Minute,
+		SaltTTL:      10 * time.Minute,
+
+		UserStore:    getEnv("USER_STORE", "memory"),
+		UserDBPath:   getEnv("USER_DB_PATH", "data/users.db"),
+		UserDBDriver: getEnv("USER_DB_DRIVER", "sqlite"),
+		UserDBDSN:    getEnv("USER_DB_DSN", "file:data/users.sqlite?_pragma=foreign_keys(1)"),
+
+		AllowPlaintextRegistration: getEnv("ALLOW_PLAINTEXT_REGISTRATION", "false") == "true",
+		RequireRegistrationProof:   getEnv("REQUIRE_REGISTRATION_PROOF", "false") == "true",
+
+		CircuitKeyDir:      getEnv("CIRCUIT_KEY_DIR", "data/circuit-keys"),
+		CircuitKeyDevSetup: getEnv("CIRCUIT_KEY_DEV_SETUP", "false") == "true",
+
+		VerificationKeyDir: getEnv("VERIFICATION_KEY_DIR", ""),
+		KeyPollInterval:    getDurationEnv("VERIFICATION_KEY_POLL_INTERVAL", 30*time.Second),
+
+		VerifyBatchWindow: getDurationEnv("VERIFY_BATCH_WINDOW", 0),
+		VerifyBatchSize:   getIntEnv("VERIFY_BATCH_SIZE", 32),
+
+		VerifyWorkers:   getIntEnv("VERIFY_WORKERS", 0),
+		VerifyQueueSize: getIntEnv("VERIFY_QUEUE_SIZE", 0),
+		VerifyTimeout:   getDurationEnv("VERIFY_TIMEOUT", 5*time.Second),
+
+		RequireBoundProofs: getEnv("REQUIRE_BOUND_PROOFS", "true") == "true",
+	}
+
+	if os.Getenv("JWT_KEY_ROTATION") == "0" {
+		cfg.JWTKeyRotation = 0
+	}
+	if cfg.AllowPlaintextRegistration {
+		log.Printf("⚠️ ALLOW_PLAINTEXT_REGISTRATION is deprecated and will be removed: clients send the password itself to the server. Register with a commitment instead")
+	}
+	// The admin scope is only ever granted to ADMIN_USERS
+	if slices.Contains(cfg.TokenScopes, handlers.AdminScope) {
+		log.Fatalf("ACCESS_TOKEN_SCOPES must not contain %q; list admins in ADMIN_USERS", handlers.AdminScope)
 	}
 
 	// Initialize dependencies
-	userRepository := repository.NewMemoryUserRepo()
-	proofStore := proof.NewStore(cfg.ProofTTL)
-	proofValidator := proof.NewValidator(proofStore, cfg.ProofTTL, 2*time.Minute)
-	zkpVerifier := verifier.NewGroth16Verifier()
 	securityMonitor := security.GlobalMonitor
+	tokenKeys, err := openTokenKeys(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open token signing keys: %v", err)
+	}
+	userRepository, err := openUserRepo(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open user store: %v", err)
+	}
+	warnMissingAdmins(userRepository, cfg.AdminUsers)
+	saltStore := repository.NewSaltStore(cfg.SaltTTL)
+	proofStore := proof.NewStore(cfg.ProofTTL)
+	challengeStore := proof.NewChallengeStore(cfg.ChallengeTTL)
+	proofValidator := proof.NewValidator(proofStore, challengeStore, cfg.ProofTTL, 2*time.Minute)
+	// Requests that name no circuit are checked against the one browsers use
+	circuitRegistry := verifier.NewRegistry(prover.CircuitID)
+	// Browsers prove with this deployment's own keys
+	passwordProver, err := prover.Open(cfg.CircuitKeyDir, cfg.CircuitKeyDevSetup)
+	if errors.Is(err, prover.ErrNoKeys) {
+		log.Fatalf("Failed to open circuit keys: %v; install keys from a ceremony, or set CIRCUIT_KEY_DEV_SETUP=true to generate development keys", err)
+	}
+	if err != nil {
+		log.Fatalf("Failed to open circuit keys in %s: %v", cfg.CircuitKeyDir, err)
+	}
+	builtinCircuits := []verifier.Circuit{passwordProver.Circuit()}
+	if cfg.VerificationKeyDir != "" {
+		keyWatcher := verifier.NewKeyWatcher(cfg.VerificationKeyDir, cfg.KeyPollInterval,
+			circuitRegistry, builtinCircuits, securityMonitor)
+		if err := keyWatcher.Reload(context.Background()); err != nil {
+			log.Fatalf("Failed to load verification keys from %s: %v", cfg.VerificationKeyDir, err)
+		}
+		go keyWatcher.Run(context.Background())
+	} else if err := circuitRegistry.RegisterAll(builtinCircuits); err != nil {
+		log.Fatalf("Failed to register circuits: %v", err)
+	}
+	zkpVerifier := verifier.NewRegistryVerifier(circuitRegistry)
+	zkpVerifier.RequireBinding = cfg.RequireBoundProofs
+	verifyWorkers := cfg.VerifyWorkers
+	if cfg.VerifyBatchWindow > 0 {
+		zkpVerifier.Batcher = verifier.NewBatcher(cfg.VerifyBatchWindow, cfg.VerifyBatchSize)
+		// Each worker blocks on its batch, so a batch can only fill up if there
+		// are at least as many workers as proofs in it
+		verifyWorkers = max(verifyWorkers, cfg.VerifyBatchSize)
+	}
+	// Pairing checks run on a bounded pool instead of the request goroutines
+	verifierPool := verifier.NewPool(zkpVerifier, verifyWorkers, cfg.VerifyQueueSize, cfg.VerifyTimeout)
+	// Session state lives with the users, so it is as durable and as shared
+	sessions := session.NewStore(userRepository)
+	revocations := session.NewRevocationStore(userRepository)
+	go session.PurgeExpired(context.Background(), userRepository, time.Minute)
+	// The API checks its own tokens as the services consuming them do, with
+	// the session and revocation state they ask for through introspection
+	tokenVerifier, err := tokenauth.NewVerifier(tokenauth.Config{
+		Keyfunc:     tokenKeys.Keyfunc,
+		Issuer:      cfg.JWTIssuer,
+		Revocations: session.NewTokenChecker(sessions, revocations),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create token verifier: %v", err)
+	}
 
 	return &app.Dependencies{
 		Config:          cfg,
+		Prover:          passwordProver,
 		UserRepo:        userRepository,
+		SaltStore:       saltStore,
 		ProofValidator:  proofValidator,
-		ZKPVerifier:     zkpVerifier,
+		ZKPVerifier:     verifierPool,
+		VerifierPool:    verifierPool,
+		CircuitRegistry: circuitRegistry,
 		SecurityMonitor: securityMonitor,
+		Sessions:        sessions,
+		RefreshTokens:   session.NewRefreshStore(sessions, userRepository, cfg.RefreshTokenTTL),
+		Revocations:     revocations,
+		TokenKeys:       tokenKeys,
+		TokenVerifier:   tokenVerifier,
 	}
 }
 
@@ -63,36 +191,120 @@ func setupRouter(deps *app.Dependencies) *gin.Engine {
 	router.Use(middleware.CORS(deps.Config.CorsOrigin))
 	router.Use(middleware.SecurityHeaders())
 	router.Use(middleware.RequestSizeLimit(100 * 1024))
-	router.Use(middleware.RateLimit())
+	router.Use(middleware.RateLimit("/api/token/introspect", "/api/prover/:file"))
 	router.Use(handlers.SecurityMiddleware(deps))
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(deps)
-	adminHandler := handlers.NewAdminHandler(deps.SecurityMonitor)
+	adminHandler := handlers.NewAdminHandler(deps.SecurityMonitor, deps.CircuitRegistry, deps.VerifierPool, deps.UserRepo, deps.Sessions)
 
 	// Routes
 	router.GET("/health", handlers.HealthCheck)
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+	router.GET("/api/prover/:file", authHandler.ProverKey)
+	router.POST("/api/register/salt", authHandler.RegisterSalt)
 	router.POST("/api/register", authHandler.Register)
+	router.POST("/api/login/challenge", authHandler.LoginChallenge)
 	router.POST("/api/login", authHandler.Login)
+	router.POST("/api/recover/challenge", authHandler.RecoverChallenge)
+	router.POST("/api/recover", authHandler.Recover)
+	router.POST("/api/token/refresh", authHandler.RefreshToken)
+	router.POST("/api/token/introspect", authHandler.IntrospectToken)
 
 	// Protected routes
 	protected := router.Group("/api")
-	protected.Use(handlers.AuthMiddleware(deps.Config.JWTSecret))
+	protected.Use(handlers.AuthMiddleware(deps.TokenVerifier))
 	{
 		protected.POST("/logout", authHandler.Logout)
+		protected.POST("/logout/all", authHandler.LogoutAll)
+		protected.POST("/credential/upgrade", authHandler.UpgradeCredential)
+		protected.POST("/password/salt", authHandler.PasswordSalt)
+		protected.POST("/password/change", authHandler.ChangePassword)
 		protected.GET("/protected", authHandler.Protected)
 		protected.GET("/admin/security-events", adminHandler.SecurityEvents)
+		protected.GET("/admin/circuits", adminHandler.Circuits)
+		protected.POST("/admin/circuits/:id/versions/:version/status", adminHandler.SetCircuitStatus)
+		protected.GET("/admin/verifier/stats", adminHandler.VerifierStats)
+		protected.POST("/admin/users/:username/disabled", adminHandler.SetUserDisabled)
 	}
 
 	return router
 }
 
-func getJWTSecret() []byte {
-	jwtSecretStr := os.Getenv("JWT_SECRET")
-	if jwtSecretStr == "" {
-		log.Fatal("JWT_SECRET environment variable must be set in production!")
+func openUserRepo(cfg app.Config) (repository.Store, error) {
+	switch cfg.UserStore {
+	case "memory":
+		return repository.NewMemoryUserRepo(), nil
+	case "bolt":
+		return repository.OpenBoltUserRepo(cfg.UserDBPath)
+	case "sql":
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		return repository.OpenSQLUserRepo(ctx, cfg.UserDBDriver, cfg.UserDBDSN)
+	default:
+		return nil, fmt.Errorf("unknown USER_STORE %q (want memory, bolt or sql)", cfg.UserStore)
+	}
+}
+
+// warnMissingAdmins reports ADMIN_USERS entries without an account. Nobody
+// can register them while they are listed
+func warnMissingAdmins(users repository.UserRepo, admins []string) {
+	for _, admin := range admins {
+		exists, err := users.UserExists(context.Background(), admin)
+		if err != nil {
+			log.Printf("⚠️ Could not look up admin user %s: %v", admin, err)
+		} else if !exists {
+			log.Printf("⚠️ Admin user %s does not exist; register it before listing it in ADMIN_USERS", admin)
+		}
+	}
+}
+
+// openTokenKeys opens the access token signing keys and keeps rotating them
+func openTokenKeys(cfg app.Config) (*signing.KeyRing, error) {
+	if cfg.JWTSigningAlg == signing.AlgHS256 {
+		if len(cfg.JWTSecret) == 0 {
+			return nil, fmt.Errorf("JWT_SECRET must be set for HS256")
+		}
+		return signing.NewHMACKeyRing(cfg.JWTSecret), nil
+	}
+	// A key retired before the last token it signed expires would log its
+	// holder out early
+	if cfg.JWTKeyOverlap < cfg.JWTExpiry {
+		return nil, fmt.Errorf("JWT_KEY_OVERLAP %s must be at least ACCESS_TOKEN_TTL %s", cfg.JWTKeyOverlap, cfg.JWTExpiry)
+	}
+
+	keys, err := signing.OpenKeyRing(signing.Config{
+		Algorithm: cfg.JWTSigningAlg,
+		Dir:       cfg.JWTKeyDir,
+		Rotation:  cfg.JWTKeyRotation,
+		Overlap:   cfg.JWTKeyOverlap,
+	})
+	if err != nil {
+		return nil, err
 	}
-	return []byte(jwtSecretStr)
+	go keys.Run(context.Background(), time.Minute)
+	return keys, nil
+}
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// isListSeparator splits list settings on commas and whitespace
+func isListSeparator(r rune) bool {
+	return r == ',' || unicode.IsSpace(r)
 }
 
 func getEnv(key, defaultValue string) string {
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"slices"
 	"sync"
 	"time"
 
@@ -49,11 +50,20 @@ func (i *IPRateLimiter) GetLimiter(ip string) *rate.Limiter {
 }
 
 // RateLimit middleware
-func RateLimit() gin.HandlerFunc {
+// RateLimit limits each client IP, except on the exempt routes, given as
+// registered (e.g. "/api/prover/:file"): those called by services rather than
+// browsers, which authenticate before doing any work, and static files a page
+// load fetches before the user has done anything
+func RateLimit(exempt ...string) gin.HandlerFunc {
 	// Allow 10 requests per minute, burst of 5
 	limiter := NewIPRateLimiter(rate.Every(time.Minute), 10)
 
 	return func(c *gin.Context) {
+		if slices.Contains(exempt, c.FullPath()) {
+			c.Next()
+			return
+		}
+
 		// Get client IP
 		ip := c.ClientIP()
 		if ip == "" {
@@ -0,0 +1,40 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/threehook/zkp-auth/backend/middleware"
+)
+
+func TestRateLimitExemptsRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RateLimit("/api/prover/:file"))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/api/prover/:file", ok)
+	router.POST("/api/login", ok)
+
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+	for i := 0; i < 50; i++ {
+		if status := get("/api/prover/proving_key.bin"); status != http.StatusOK {
+			t.Fatalf("prover download %d = %d, want 200", i, status)
+		}
+	}
+
+	limited := false
+	for i := 0; i < 50 && !limited; i++ {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/login", nil))
+		limited = rec.Code == http.StatusTooManyRequests
+	}
+	if !limited {
+		t.Fatal("login was never rate limited")
+	}
+}
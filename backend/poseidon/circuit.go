@@ -0,0 +1,92 @@
+package poseidon
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/frontend"
+)
+
+// HashCircuit is Hash expressed as gnark constraints. It follows the same
+// round structure step by step, so a gnark circuit using it agrees with
+// circomlib's Poseidon and with the native Hash on every input
+func HashCircuit(api frontend.API, inputs ...frontend.Variable) (frontend.Variable, error) {
+	t := len(inputs) + 1
+	rc, ok := constants[t]
+	if !ok {
+		return nil, fmt.Errorf("poseidon: unsupported input count %d, expected 1 to %d", len(inputs), MaxInputs)
+	}
+	nRoundsP := partialRounds[t]
+
+	state := make([]frontend.Variable, t)
+	state[0] = 0
+	copy(state[1:], inputs)
+
+	arkCircuit(api, state, rc.c, 0)
+	for i := 0; i < fullRounds/2-1; i++ {
+		sboxAllCircuit(api, state)
+		arkCircuit(api, state, rc.c, (i+1)*t)
+		state = mixCircuit(api, state, rc.m)
+	}
+	sboxAllCircuit(api, state)
+	arkCircuit(api, state, rc.c, (fullRounds/2)*t)
+	state = mixCircuit(api, state, rc.p)
+
+	for i := 0; i < nRoundsP; i++ {
+		state[0] = api.Add(sboxCircuit(api, state[0]), constant(&rc.c[(fullRounds/2+1)*t+i]))
+
+		newState0 := frontend.Variable(0)
+		for j := range state {
+			newState0 = api.Add(newState0, api.Mul(constant(&rc.s[(t*2-1)*i+j]), state[j]))
+		}
+		for k := 1; k < t; k++ {
+			state[k] = api.Add(state[k], api.Mul(state[0], constant(&rc.s[(t*2-1)*i+t+k-1])))
+		}
+		state[0] = newState0
+	}
+
+	for i := 0; i < fullRounds/2-1; i++ {
+		sboxAllCircuit(api, state)
+		arkCircuit(api, state, rc.c, (fullRounds/2+1)*t+nRoundsP+i*t)
+		state = mixCircuit(api, state, rc.m)
+	}
+	sboxAllCircuit(api, state)
+	state = mixCircuit(api, state, rc.m)
+
+	return state[0], nil
+}
+
+func sboxCircuit(api frontend.API, x frontend.Variable) frontend.Variable {
+	x2 := api.Mul(x, x)
+	x4 := api.Mul(x2, x2)
+	return api.Mul(x, x4)
+}
+
+func sboxAllCircuit(api frontend.API, state []frontend.Variable) {
+	for i := range state {
+		state[i] = sboxCircuit(api, state[i])
+	}
+}
+
+func arkCircuit(api frontend.API, state []frontend.Variable, c []fr.Element, offset int) {
+	for i := range state {
+		state[i] = api.Add(state[i], constant(&c[offset+i]))
+	}
+}
+
+func mixCircuit(api frontend.API, state []frontend.Variable, m [][]fr.Element) []frontend.Variable {
+	newState := make([]frontend.Variable, len(state))
+	for i := range state {
+		newState[i] = frontend.Variable(0)
+		for j := range state {
+			newState[i] = api.Add(newState[i], api.Mul(constant(&m[j][i]), state[j]))
+		}
+	}
+	return newState
+}
+
+// constant converts a round constant into a value the gnark frontend accepts
+func constant(e *fr.Element) *big.Int {
+	return e.BigInt(new(big.Int))
+}
@@ -0,0 +1,683 @@
+package poseidon
+
+// Round constants of circomlib's Poseidon over the BN254 scalar field, in the
+// optimized form (C, S, M, P) used by circomlibjs and poseidon-lite, keyed by
+// state width t = inputs + 1.
+
+var constantStrings = map[int]struct {
+	c, s []string
+	m, p [][]string
+}{
+	2: {
+		c: []string{
+			"4417881134626180770308697923359573201005643519861877412381846989312604493735",
+			"5433650512959517612316327474713065966758808864213826738576266661723522780033",
+			"16762637755406472493812601151014278118641635553391913542964880219707263322052",
+			"17301668213014761646596552653015591039760461391617952799153519234591864034463",
+			"19776162473771124504636460540005465934623588388516917789964545078054814040751",
+			"11316768622745143833150712249439306411966930091146927416794626237074092972974",
+			"16445483823163065295596987123801926703922762982696126295152762759513382273866",
+			"4514474156763384993737907502541146539969481658202448264361041161669007485071",
+			"15962945726901666037200703277090943625692830090147479512076829448913210994056",
+			"887718591790650017281197227986729839639624303500401204697133087008458682956",
+			"12274216425815286338344348482336276117995066724093487299512262174649563976186",
+			"1050758930252644049914605206403427631313247657935800041327915318465466974783",
+			"10607585076226348745183629788245008577438579576330359487986117688517235408881",
+			"20509009694313778489858884111978287368685508463213168251197030962719682409205",
+			"4563680725198793251172562411623420668869330885202539013185566348548255071635",
+			"10132793020925051358967312895903080509340240497490871556356344077873835967889",
+			"2647212931513679432767054030363504863222540192567519779190979594289737202550",
+			"4359886051856780224292971980741406425492649906224296730054335077893394909530",
+			"3054847578866604975257033821021111653343035155943751913629683293137065347688",
+			"1596959724864208462318973909411993812742702283705404297188991879075781032285",
+			"9261998448432672939016143689737142959950360294646800435636112732010430895748",
+			"21811458719499960186771214587366397959723618323848154217660010569954685810715",
+			"3239076967784329489572444293576130919094218653342995300018245457598795548917",
+			"20462520400712402627709344205131902699657125916001693532154815488581362749475",
+			"15540512546148021086829191867945740637058588949955294856096961322685397103557",
+			"13865340336144010740428645313739899438060242120535852433410522037321843803156",
+			"4030925228209360989389332579897661669475428247758360576989046703985980534507",
+			"2580022244547148022781785697172503311467595214847728559144402326933969076319",
+			"337091914144357888262743136529662076143869414926179844835581507288841653842",
+			"21128818615001540137263856211467089199630793116539340697922423416110745118506",
+			"7314289664170998780822250984795805793842874757314808790503348444042779760074",
+			"17774049587694351616089815110956846453850106707275621110789201687277957820281",
+			"11019693257420674975398059669532837767980992812803956883547482631461450651960",
+			"8945986251101329707360666524341408357502651307257925367450947820355368214535",
+			"16600645183477638722007904287733521074791737381945794958827103590279914462576",
+			"11176512602209691417636272193861235132071391793840565562361651389294061042059",
+			"14889617408341048364135563212181036206522304313837983213620424531022449761460",
+			"13725846858893036951158965143583678272726150787955443316044240114688865768260",
+			"7031966701933394996335226463904734684231938370740242159297465282149711323302",
+			"1598623817079294552053879422210947472173483306271647853752616124799192256195",
+			"19774466718056564032734488707833648551011540998602523750214654491716642104404",
+			"10833489778171075947445720806211760058004501131759886332251918804308190690081",
+			"1643230281589922929913461313269721677840560726699527243824368999070606462076",
+			"5529383825244679302765929967407905660423153508826597450732592666784449667225",
+			"3182718166498008812418586185198779390426386793456672324496083987133297273028",
+			"14599400764263231688213618032482449522109778447311452342990557992771279312515",
+			"19971122460007657859508211404523175040055308424338161607196356611508685672711",
+			"12801369279155577131760037436686619341421686678830792595575775840669776442563",
+			"2874800145470695258580041840911551878272283565060591475417515522159069870291",
+			"15558578381003392888309173936039806021949114680855373870727168432126175091041",
+			"9811599964264530187304305794911397412166832842489170888364387877850235210426",
+			"11797479380255190086030457967941350172480879235135372778366002137304980472711",
+			"15812276861397201227532067085271584728094218111982315967331671382133625425702",
+			"14555033526911765831054005951477598685743695466168667850533171876658643729676",
+			"8453370176619636688730899865196838058367647719211769227158095525122045566926",
+			"13746050345785052659407845574049356403121452981555493239274450074369130425982",
+			"14759221361221742392877550517283338785302973165056606116918148040408389214809",
+			"12918384943133505870596332126482017188382093626741625148832869110825500058603",
+			"6090669736471884927589246515939581624927624112611999595504716515722644260809",
+			"5818256914990297609452278687275570020517086210519703828397962481130049333349",
+			"21665668991006174860786913595488512200171275130423190085616080866607498513357",
+			"2268297495968854614780848291068505574765456615293615512346180903730018964697",
+			"13973211016421000871032597822807506910708863418864348758128380360422656258892",
+			"11877184595954796005081407550764117823731241224470762866371883626576306508793",
+			"2986065710695845701959971763474802354333410127136499986005712979427956719303",
+			"12649794127562509279197585900369868076945168057811626745015439261348449322516",
+			"12275810531539430738053742281045622394839331044128025660443842052759513336728",
+			"21143091624051898429049779202777783090616631078773674742631481136382890245166",
+			"10171452642243387955781526332645193123346266850900972162093561209595357586423",
+			"3855895713134056811998624598511873826580243324673003091901619068671473231435",
+			"13528768565745931233460851026527843568952293200662922221190432010518079703355",
+			"6443318563434187156482979037576690662213493094314678699384407936675395356263",
+		},
+		s: []string{
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"14392831394556883151981305211846637368219175236435029476710156182825234647903",
+			"3972157705848560553225735225624846054214663941061073198460328492610322308859",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"16440390658055422679423689139559182411435556269094721507939827735857031845941",
+			"11198648714251142428058726882013539847504262008973766589079741687056423490297",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"11040813526441169518500734422521060447982883219244845048102593255893561156996",
+			"20497865727088597598080943572498902593882858842109587509968463528339660458642",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"1500089846899596715974320910031668931285734517895867873260500036847322991504",
+			"4358644212889321953854825150676666638302618158801791795222421069155850412400",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"8984867899727903806270502850610949791728028091344606436356794482660239432128",
+			"13196988957644680853657010454825816766255261641090969872415940079324324517200",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"15426814260470757299305914584666551083338300404662495749902213200367864215525",
+			"10274907822029773752599364682846594220333819264221790819729461989526841644772",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"19026669700330333266441996665221118772970072473312832352798344963191816505645",
+			"20679714503042931132684279446055536449154645644493018261941282871993624898540",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"12964654075685058093719499158609612785968163923770489034904472803205989694304",
+			"18200677960239373637649539108876024241588634435991197442062713614540220543190",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"1485956159282120184145706275511156919936626022277424284457409303862685325664",
+			"12189269810846168525570955704880797987828224028357330527749390553118187515842",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"2586207584926778203417237662225218511791525092035011763237709611248045934201",
+			"14387303199695390816203090482727337652038500693872210859670388378998101201473",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"21344633194789444146436100273283079508710852342953476239695095631792372868968",
+			"11910468224409800239435584195555706876832023133453009084113016769318014668423",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"11658422394501172961021840088870641583506527880323141046958377567012468098078",
+			"11990725169474361073247471880995563289709946893926378225865319042037239805982",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"6926794121522657775135934726935958968808821452641449725179055387173135922053",
+			"12969461585708436520061060093527234630745148571054629461542821869611310096259",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"16557390904729648969688218936369896015291829116857797017319655543878543769936",
+			"8964420836568218664066858204835584634020973193582095787662357894138760847281",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"5231573215829413600046548137812118551885869704577525718147399841503199194611",
+			"1071071200992230681894953246456234077369899941216486618211597725800928546617",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"17272722596134695758553935805972290550265223542727587658143866403271745235106",
+			"20613690274524853975134092387124765882540065887226065151171374641692288412375",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"21123398086181123350404325066328947451198325999856475547763737130781214074497",
+			"13697891473983636529397725280805987427717683070952955028418464031927847367904",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"6540157478504651098599446818261488990274945658555720784107007035234949010986",
+			"13692854806651392920659362021445678559054720498825135113051920510471694493019",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"18447136363438645728208320728801902193236554945410027784124179735770398965427",
+			"16896643171942414108408247185225396501926477869502244248519140071367230697494",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"13430180537774007604987769461853224912338934750348255841707029554728095007998",
+			"5819273568013735755493463088783314163212784642531952901940984326646179149006",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"6468967241082043547855791346352823938882485950928439365930846015655744046793",
+			"15042336475589748801915271899536103771699602522329597483005804495133816339190",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"4060171086557274058343612891340790233148839011372025052284736924822074085735",
+			"3023820908854989750384256836857521819025897297312606568795781474223039623734",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"3936953456204187982773175206918708282706186102950905916950678236153422510545",
+			"8086679776961569665514914577770208433948463364260438801133495005504637834663",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"20735612809138096619077879032867017136084697780858662353126718233909458374054",
+			"7458741940459798223346846417548139368376690304967620067487874719179712159144",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"7540230737941909534931899907184979267065804077618755592631128043406851183715",
+			"21712477849654469842998332219679981332842222819768421669737377611110184803505",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"10574602811609933386922394329670035542165408167800267536609409027399220883133",
+			"19449109868018009024035308550535496206885978497472365074471465516237852004024",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"20473559466453541542743757731380228020042572837694623717511539612054623907337",
+			"5119965038090516455331824871869551046717310200821925697177541075595498699449",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"9231625164484624725202909636382137114605489328335506092061160621063299478087",
+			"18695404165336135862226806077152390729208311221843925968341263967481688988565",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"6147750791551333396338403098136527542493494959699182427908230628698995571450",
+			"14944058336757781671702739469686868591118420822954803379604679687848897327901",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"13084937281458400529670212324062190870439298170684666100848564079874253303169",
+			"15579388996035822512031957992925353820792555476125104021115212813037017427459",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"4920329416493485228222917333368311272906190983568384331696240309492443515874",
+			"10610585188871001058193874472688147873822746474774927087630695922296578311975",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"14066990365446240171264590286131189706913594228678489222182718356359357753920",
+			"13412997897307085374937251875519157299458442519459404138362903359030994968369",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"1275452933719514459755406278026260224234718080171423650799047950880018995112",
+			"5525666237417176285332109866632866482159739651769161010768589313396251467026",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"331320929213473674659683340489936265012073924885618517684099808377593924217",
+			"13345883030642517348526158689265360922100673835778077405969678435148649382298",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"9903473932310023389092593730447398748148168563821574505790961875190484229474",
+			"19285108207368178349486322131055123429097649700952049458826662800394937187028",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"7547844205956657353719318592545601778971414066433205769160345846396205216448",
+			"13159650965092821821049509972996470974724780282232110173097382450494203266442",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"1329940786763930840855165653045416639726820372831875538983652587303188188982",
+			"6224771944627459423481012804661332648378458233679029267154280919006640730107",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"911315530688044672029285781278328521936277726206277487008961836349380769061",
+			"19660660677661728905348337922741649556947664821947183537064012928627004917791",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"20145614140115000256982152403889411099557574950296569878820902392860244563410",
+			"21706842883572015081161394799827658661167090535515156295190515961870085048184",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"1400552940479299851014102879927394966857183445405527605909684166458398230596",
+			"5944732631177226208223021176951748275976534914098761357845821362866379425484",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"866154631101658107973176167493889697868303584431687787081874014019085575768",
+			"9902957556516789922255690107255362320309463820719161270922092794349102363391",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"21233124412580416243653143329453254964637397955655229998228862809897939414173",
+			"9864062428577829522020090391213542871894670504187224830118337159335722047746",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"6217676708689576975478917254093777588701125555591671650672932859785402217626",
+			"18210811974801653477473985577275752568328719661099508199696102451244085578411",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"12043702663840910199304591311786238821317874731758240168746697238705956499244",
+			"13728696687121317515586146614428357288980785883404986775474796827079135616306",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"4889270879114883369967301481542766407046173838599698916340454418283925918383",
+			"12515079354287291818450297171016809161352276701839755200273103149224182856489",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"741047074752645107215150300270199736808887851112472136717263242753407972124",
+			"8307815031735000326149520446964706184178576595356305076128069843390394587346",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"3780889029497540191387166920897950350640448777834552049496614608101825301459",
+			"17792710441034557220497866783158329786230479002470247459199885494563866613731",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"7008545839415003673417409600248354185791431456081927514934439919684830792303",
+			"15421721873912692366594902352749997776192587254503958550154395108662429176988",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"12589595435283157053084718937999123329697329486902786827800155253058107270050",
+			"9239044082798401984635454346302146468280143565576310941219363126988451890929",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"961487511272532695918565876171806928181070584332539011526449222050650574138",
+			"793142828451887046412221404377949946590270616440808685184599974957471437857",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"19558163290469234857367464115070060181059156807877210421879995941268362392032",
+			"11019953962774865014231719338582570778635390362563255121211455473100668888078",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"12313613766205176725088436557810879752761132299197518891952366825529253688897",
+			"9812736158335477055588158142520482323040292599247513676460746438010789324263",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"5332751193642382397028255617900470134079425867172910540275968625998341913528",
+			"2334047396459446283638491037365540318602523833738275731623984819151526291146",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"1352777438493320028219014760983211439847918655021091285358084287849511645024",
+			"14439295634941823716304713039076043803610042078708444589757658192409135010255",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"20593426838609769428650678230140825565556910531961836087447959096735625556411",
+			"5946284205099759175618134422286671175817995358660274650755229990352236707609",
+			"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+			"4323548108738063145890883850430354883892087996233033890710612394881641262820",
+			"5776684794125549462448597414050232243778680302179439492664047328281728356345",
+		},
+		m: [][]string{
+			{
+				"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+				"5776684794125549462448597414050232243778680302179439492664047328281728356345",
+			},
+			{
+				"19727366863391167538122140361473584127147630672623100827934084310230022599144",
+				"8348174920934122550483593999453880006756108121341067172388445916328941978568",
+			},
+		},
+		p: [][]string{
+			{
+				"2910766817845651019878574839501801340070030115151021261302834310722729507541",
+				"14876694094903316616163091687595355836267453073383265044550370713659048938454",
+			},
+			{
+				"19727366863391167538122140361473584127147630672623100827934084310230022599144",
+				"7527312705817953459920138003796377030820958175883853967715612380516078993222",
+			},
+		},
+	},
+	3: {
+		c: []string{
+			"6745197990210204598374042828761989596302876299545964402857411729872131034734",
+			"426281677759936592021316809065178817848084678679510574715894138690250139748",
+			"4014188762916583598888942667424965430287497824629657219807941460227372577781",
+			"3755116341545840759015036961635468144365099804379460727348866960676715430295",
+			"20392683181271908962657137166167696619865229065446607574667232999928814731550",
+			"6703994282500560979989445930081874901355102371090652156329919603050069367661",
+			"17189230569231604821073310501737896533088589624978650476197226450738944009738",
+			"18531998296162357308313149608963848512728570123579345240911571045895174353605",
+			"4433884058681415052165697534405705901078937172224017064607454469338590163489",
+			"8020484089444009184801117822789130075555480739986478064377452360454228170229",
+			"20560640391555251236826668015235029471365697963893708697460632109250285318704",
+			"17735423966452908760211059923359580380884879536808777323265778948947638259763",
+			"6791331612302297428695549285132291741490338679013661880702099967749867646461",
+			"10419627351290227145210525084258167372914788967175798542355001482631316994244",
+			"6206851612052541638976352943215840028030801164970177880767418169520708772536",
+			"16375603635162350436232250364669249324451378530661474785953680978023373794530",
+			"15688345709279674878722778274755546879655509895442959219801847456408443245585",
+			"9491195295080912096808640399994744159859678118343162847585525711429214413024",
+			"9797453712978351739894993124526343599910864939600507506817907398049628087845",
+			"21481156634888978845506145026281060650315619389631972720682147891193932034748",
+			"1544695019100535789562080715491958130358622823716581449438533301216924752935",
+			"15153967549418678242792255556974876142451438236452833905885476522771426565724",
+			"4591255420184723367998678386069903388982581566230137478170120814157251999972",
+			"13993317492298544887941044850630591562583461951060762639175439957405637125554",
+			"18050986222741620548156772647408352996300510941831685700744011415483819773010",
+			"582246807524529302909723370549441534244069879807711548626660000973375204921",
+			"17980568461424306839096120761698253698461014969574413132599910426852670637994",
+			"14228661217337404173590037181281556515313880823067200751208433351015082633231",
+			"17176587110943721909591525594639263627408109053511250375171964599662347949654",
+			"7286056960291791961279922035116305681626907328744157355775762073644197019846",
+			"11801365285243706250823971466535819473941637258351304973449723129085888576630",
+			"6789889064944432682687629097717611651009674254338563170567306510098910540667",
+			"9550619200100511068539661405398488623937521959417695171688138140248257936329",
+			"16927894918204554097233146055322393983512297393314402761978026471334045088468",
+			"2296319279680349420807150717514761554038762184731526596983718190376193064033",
+			"13381111760207441008426119944140900703001726391920993676751870388659584018005",
+			"11282457978268307664923525713815776526107144144595041430117539563509678852564",
+			"17377518636062549822834113219764678554103258757534291706153558084302477704360",
+			"20529239671116714650308624442796341176059426819897849304552671207130860806391",
+			"19313513922305909359661088066839481510878680142785006144992893032981513750163",
+			"12181397983537742191390434344829585062040306747989867043080195299198026532297",
+			"11112906716400273414317383189828104351449782172976766156576450389221891985945",
+			"16412541736785056759381201344213663399564662372426071178293124552177642678859",
+			"659264346779336196861046149708262978772865549957418762539334998250261177999",
+			"4845513029979932068519665574875148103907087162327411884857282514189560116135",
+			"5002732758219210120345003630968063328669992882526477928389701063084122341769",
+			"10252016712022906174591128558929263661248150132143972390462416316600730571625",
+			"21429601688543276478479631702989513062244319445797869558505239085486171344224",
+			"11227063021005188138910539120180069062417117307677326631195927999578666832402",
+			"2254910728581601099491456127797625022511731921877856968562861178616799012230",
+			"5924174077205168234689774914167707651618793087685768535543746729243682127746",
+			"329090408153092313434075726893539446277285458579468693042578376323593473572",
+			"3484834587887234802733103827332793869706642074000786703905145704379481896136",
+			"12759747455419586364957557614124565024455324273775792120780800828643067189145",
+			"13150191605185674559081945246113753211459390086746711042772368219406961549392",
+			"6143756015450030363279441218617635078858673495963778498235578799829663351430",
+			"18969449300908196125647274430671901552593706566744295860846386166630317453793",
+			"1852637158976378935795799109534699742700007284464701345503208109137291661250",
+			"9326761420703801200266867558954051317841905707190944714132337564904087549583",
+			"6279482686602249364815416065639446422429357296367124306817890060402815786728",
+			"8520294966848398129322322020893248716223461240734329732456748763332989445897",
+			"15681345134148763222663156294793340025833734930392220652982726544070262099820",
+			"17329667728585195296928718012738338154006158317991934918090698864750378948204",
+			"13283998627857168043664255754669222819501427102611857382896531955237893912656",
+			"6734950835262505445568244961310758511728644659360842525493721393514729768139",
+			"12640921348554222969118773328433453835370715908163239963534972271298897423616",
+			"3473754313923508472440372769623619753166905053830046385167341619128450077793",
+			"15149348017909893881037206267370389784518482186719845804410708430161111942280",
+			"15095929898353593452741657787428497312742822726453112001822847009791172948206",
+			"13779749201323782722498931190091600155866019828880573899249510809182581025824",
+			"21432322857364472753097486153424499274800937939449547067783750545210710387999",
+			"16479367804307361551951437245808989924478832646635984335550324334063271392915",
+			"148255380784797435050988367748108707226071678329729231552544164474530475505",
+			"12455016963320286149943199170327213031856517334199847717911791239594264576635",
+			"4938484771207094241571416021225789188526145811651959458066207028490239487168",
+			"10246318579378663345685131761175422014521877772325576451685137097369004581518",
+			"2049050629479134839952087472704012659976710958814656030641046436125418443803",
+			"13777389069170762688650820825296135648364766834707603999268593030539102422931",
+			"2293465760578772130353203454994751988060752014172004238858851708494457550991",
+			"6173354726105518526365269037588149920975300908099965898051063758804317864818",
+			"20864884888700633737572601890135683935475037549132028663329735513632822631102",
+		},
+		s: []string{
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"1781874611967874592137274483616240894881315449294815307306613366069350853425",
+			"9676220459425127104563807626505378474104527268335041816433595157913150665495",
+			"8364259238812534287689210722577399963878179320345509803468849104367466297989",
+			"2889496767351495797946386949910896668575115361724249874917471657626490587069",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"15203863717131037243487133177680233750660694097162830026522190480319019526887",
+			"1645017323598148583308153743253948043010266295265950623794066679542803673813",
+			"14985926134451618201070782922146535777997354606230522118685156055564432923596",
+			"11497455747123870842609033487886196057746577750687517341166074505317007288078",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"18109765756899962487111075951493451762273621105151506450773344342109668201999",
+			"8034324828084400593020431506480243533881627849088152439427470035355284392177",
+			"16846229027008741913165717881259554980809057413299912150488284683744940628261",
+			"21835563963581578576271778192505404662763222948742168673583931448375408835935",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"21536618802882283440947141155118738832596020335348742727957480541943406874436",
+			"13397320511797493654805969878195367010267669507871486661614614086160548021432",
+			"8274817596976627060721446579061034932059250181790318658419016654356916553793",
+			"11559576119047297261718762577915230877068346446232753309523408281532457130418",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"21110548928163625108646189707151361569577559205105116148655680158775559847460",
+			"13965463506707211992011711863952040570118432896827711820318513847839923700006",
+			"2754464625251737051452042869297896380028509218065510607416300542624867449301",
+			"10907469474459001232698351613440362499830316226097001251678076978108377020171",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"20501774224204372540136096556482919283387738959798723353983096093423267639300",
+			"9836931077600326261954341466265192955109945505714894685102395567763076425240",
+			"19217533572284768010875577797906138766391845135377424890965521440233301772052",
+			"7005258728852995460900263537370745968630166959734206159957799221191925945602",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"6345451795676342424205730938660185178325967413255712040877211691532798689536",
+			"2780978923276769603084110452947415993768824535337654671457442495556365161036",
+			"219671864641846575934756268958949205252482364792826985138865722150409651877",
+			"2443931363154274626039717967689506791351357117257173081384847784325709078475",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"13124186496213605736903678544398349776579723065394336602175410821613905218508",
+			"5432513339728268829134323309369787365379820462455443204721589629977134312631",
+			"10745936869168790696368181125446125013764092826641393505115044228223535523023",
+			"2700209967286437008389190340075174766403488226669328017790667859130312864557",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"15772893083972477184537403920426585293594439809285129872672815610040350722871",
+			"21294428622740779056903376466216234290427165681731300802847694130469993394218",
+			"15894266239135468928185960163477926922877264274860345967753038330869627204155",
+			"1096368123578790517530711897777194394731212499866120053001617840145178088046",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"1394159664042366811003813388790050758063269308116252272062876498627195056527",
+			"11261056337190313066266746243632478642455050257003187980730240798531224877809",
+			"17305755215616267997146077497692988596800400998462752069352600363708883007839",
+			"15371909256746742985463109622300958997197963549518997301051533693886710333747",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"20448403594130444648089851873755778887290146036948090191937739293689284059473",
+			"4729734530435653548119746580911521748567799572047317151447278252902717458440",
+			"9055786267907928908044744667038735571363428775572377654006433176678216544138",
+			"9245235689750537947580373772395968915903822328347419898008094165262061513168",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"3259295965548895132416347844457131035605305127351914029013784648223586893840",
+			"8133110647024433575836378618144076616087915311423771001766168251715944436436",
+			"18008110744560769834041791617986172641037836309092881379393935691644464895108",
+			"9013781624325778780635119850834699693214454594410089381646984478492152387681",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"8639475724251693453868768913531642954729623102539857464903122082472741556796",
+			"20830477318165650288464577487190659978049487402162708436273498600859419634",
+			"13349403513519757309593948043861292012890478614413714204682445685718878345535",
+			"12328718012639542828603926948594616778151940577607872267472093244388211484665",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"2915193368065516044845133384670589952110028644251918175654110563684523822623",
+			"734569780368547903851295084790632331276116174575476972380730437666080976462",
+			"671279589493917786728461606950395733859229090661420264134519841071301262611",
+			"14678633946393860532975080521069035476080119750719889071999652281987539169763",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"1691723231954090840146258931861867912252544708433831341842516308673817885610",
+			"15574291717899911745152218359999334153551671302357403351163198662554477508279",
+			"5981433277656201872845331017220505919530200539512006725994262794217018602010",
+			"18156370456324591238469578107588309514554581437801913401654775491244030795770",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"1556309133439204006654419798348540449388501185001051750586019510457868307958",
+			"4356046460272772399467859547886701446225520814019018000924715176417367561817",
+			"15450880045468650144156961948500828099983553409239937576968037166948001455511",
+			"3569335951432407776495772012753227552443207946081123669782387270240663238980",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"20299619590358223273964702925591899099197268683684968495953258757381055203999",
+			"1737269388672443415630244155940415723987255613151927271717623952056489022942",
+			"7676370330863607260797103988986524817754264672351485136731920308227511577030",
+			"10764843120898224557535111936383223186451299651941198232539050093196747543756",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"2819356662200804458856836085264643083461835827345828419663815020125966978385",
+			"14230399494919677144321487695512822636538939956639271484923914516686249040244",
+			"6229792639229852919549182508857380693477833417363232050296992412866445633778",
+			"3106676750956526417925705057501789384016262285679193764776023640126964109042",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"19031174113953815401575291273416077779134839378929564662214633569481371994627",
+			"4938890649131231154991766222525002264167203279761035096310595945387423228795",
+			"9092947503088322001901942345058983345234772453274860663410155583684545688529",
+			"4443468689502285528589936084153593105296452987872236962264792108454557959607",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"13722785522864435678176292501919399406320755026890489431768679408994572946910",
+			"13256667663287458052646690425465025507007074499017697722372788741483765988169",
+			"3342109259843261627877766497639597960616083706719254912542704334341413113811",
+			"8377411907540655144604614191841171970491144397410270165752490408438880282950",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"21175860851919058796901112169110721691550903636481812384865553578742784165824",
+			"1758219250556332515525607381478749746944627538834804425466160661798760928660",
+			"8100116405804673915839318005809562313337323503890310411989391068380938049891",
+			"10950382949046383428868423373874360297216755027265677947152651089682316462002",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"2960277668778712586277871117504309767461547310299729646458954502866505810933",
+			"12436779988817213442780718350478562778741169493686625046971163883056781227217",
+			"18433130870381757859416696830699316172155927980655832716601174117670334361663",
+			"8929014056758944506773121953984691621375460981653721583817790162968859020827",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"21021117587745109604358066010067802867362858152931661595258839458778309017921",
+			"3687110520160985940053416129106142708996683054120258602350677914558228149704",
+			"80825880291398182792276850849647837369189970581427465051543823269639712237",
+			"15602858448994554323587941766253362391857349901811304586895693153675332257479",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"13135494086574956175617288396849614521078575779781791595261561845703124468256",
+			"15393949948260444958980146663126583924466023603235882001681196779684410878420",
+			"18384989275581989698635194175130733158283698892545299942532908080907204625644",
+			"485819771042979048690736635548322492095227593209398128669906407316732600888",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"3969961112111760614492622183501881958866859761703927612714294408063065400072",
+			"8752648669145926648227277846713521231276713532721674183702641053051161352313",
+			"7585110218885204638023993650637083463989720045086789711575843350789273631911",
+			"2494379627738416372577673662163694139249446937999082811387265339768290503797",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"20616688053782525026898984172292202648073622844719283906076705056594026518452",
+			"9900087106206622398227913281602779201149185950522515728836722160259149448172",
+			"11017903209339322884500424701067037363510354251034908831176623007763979729891",
+			"11242911200839364801115949018449987647748348820992122514426624004928045344694",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"19232429724858702744754565081221224741960943688294029401593672990665719107878",
+			"16765052252594983393669755070044308615954848363525024643880249721059862220578",
+			"6842036836789558363749002265840843768314388887366152991347087598440783984114",
+			"21393710061740643339940504965509850732741799591113979313939113730695101694096",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"9622969983019916007969470405619112229949366797764113862835459776222718281535",
+			"13767247240219074238794646743011288498093412255264931357766139021509967203039",
+			"20328692478494464365122435286989408673672104431805610695614028351842993934534",
+			"9073999256592381826494042793078479866030288210942587220949345879429845129344",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"8385133441250571023649882990135092851061706452670332562366981695578823064040",
+			"6908037916791839012443104181201551324508228729079993473762605932494330190638",
+			"7944824570503701879156726471230631291347547538049727334541219865644837323988",
+			"18800482911329847069658844436812670171974070641520523903011375486406401133846",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"2730366093593546914821994695117890569154816790844740397371897554795276235383",
+			"5675297339307536929988306800229752810880677519055155910685928984270724939639",
+			"8840975546939648540488041522549892926507078571712382410740665008159904893712",
+			"20979353866970550917873042661559159890255433653612953419331011151144149783744",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"516844421659953336774353304123555882256525184827876947252825317542649719056",
+			"551311298954341872590849377639279261005593012684858706728599073331951775432",
+			"21048129191517485874758270018130757373572343861561541709103852181146637709285",
+			"883108184400682278340850461255904007212979661827816162352333281411119132932",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"14420640332119892506393437524000256966511511660102357305862673030163266588863",
+			"6769807849276165954616728496863793269428109021002779834929547188571900768755",
+			"11299306373336024504558247995641644825418404376401286822173736758483745500585",
+			"3383499335919177296989189306855753260005794820125735943026533024070779082856",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"3433708777679466194488047633816494102612852206949168870493217054333441112985",
+			"13364335699281038824576139080495276061523646519119171104214550514343584904357",
+			"19088517692777810072139780055414076811493668977474813912864370395663606472109",
+			"17046893265171064448293585872818107620988569612784541924208567811178685573298",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"3339406933518442876411910401896457020433273656520834348101852668427397002466",
+			"6394754036751016627974453048774687667103663469778455952578525678514140357908",
+			"13348080011937103566625637585590574831645542599062267708945074519374215924576",
+			"2035451312942883968544771537469165070918629861375811750777728864744610711929",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"7534846726693802303568319129617958732413064154452139317544115737563440922906",
+			"5142893372197042264809108797404775402895973963341426202916561252529309911953",
+			"7387703761213293203195518374872886870044236674278580805224056813041998830918",
+			"9834981306855341246423988959170352646074821767371321543902587618825629388790",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"10591940164582290683765523873302053954617746134288371151158550854319230671848",
+			"19645940765685168416476108842047364297815786496263306942428428501384703436530",
+			"806317401532332279371557871696268272788644426105491726521005970610425656401",
+			"14873156151354922251283278949136754794279449340904101629102561195129848597881",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"14877529356535812861712404300630166048169645526789734524489710998713041156616",
+			"21101727915049995883360583090020188667871655700326983236468917802238514631527",
+			"8784561081435496519936150848470355611125213198581563342192869536231698468724",
+			"12951011119123862602637073643625306517125538175126787345374445023875682668190",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"4754486070458897643044014762078146540057558083321156154490263991438824591559",
+			"6698229600376653940889127765081219516223590790118662195996060465168245635029",
+			"3488212148323687832952214845303080200128370770801913448081307315149532795755",
+			"13395974002200754692425063613054297713599822621888055825281485401829047673168",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"21306313216752316778610596575521334059455780410245249300161336400126377013198",
+			"14440430794889894255165366081371645366323676828730327401596635433732808761635",
+			"11301736477249846070880364749238210747019850007649734004911360387721732439176",
+			"18529371950411247463536323927264771481897887775743653755596309214956011300885",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"2024094455599253391879172765188241728909648958146830531168621392830348748452",
+			"12380443335956575796199242302050308002170284713778975658193413541837749582704",
+			"17800128209140157388583882622714179816536883599865901438503119252725091065454",
+			"21045861938698937974912479796474383908520405721888783097215705657386912086696",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"4141409637360999331951189783363878171311106492172769273638619574221156829121",
+			"14259414300388792410641104009760954363156850399537170069218165074426770063617",
+			"4451799750330945793479450341858976120375530940735690476632525521874862862324",
+			"18172943363350781888342804719974357493732050248863214305201835660468795448831",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"14803601458117323257887833141099311008736410980719735518416107862729259860503",
+			"8012097819445489095043609535945175643371775681362129577114806789033825080174",
+			"20987299682170427723890380587526212844337242486458048148468388739903558239166",
+			"10548394851179037704178101661877192514367125574136880556232929084397088507285",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"20436799052987452454072495255981676264927711874374541657901611880206848218041",
+			"11989711640394693472854276906656379594783073287861131885588974887589308529140",
+			"18091352772795342278278111004131463236456400626592100937570367790871324385847",
+			"12711678752325475197741198013733874816358621859214685652221956581940736498324",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"1190440422304761108055570691102969032887211603334032397741971602684610500183",
+			"20742281673328504122132555473443044322771333000072182383854251396175500629988",
+			"6330789123996977458876730494567876598951832573056269268585355576434452265824",
+			"7613427805763613770396578102318646348515686256763144477876781927753355511242",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"2767787737080836074588827866493428969025899581972950836068099283611716162872",
+			"12368938928679702085904015193412499809238916971742093835750222401100611164036",
+			"2120299666226961199589805206721729429805450574305859164922602701608405684727",
+			"16101730347660865451514214922930122989814420468390642556358093789599914392935",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"14613859797855964370156853496634409122022020442980743716687965083719225519778",
+			"3779283189030991331381776355121793593816122884996482647339823869532343988764",
+			"16538148594031353209577287616352326794499928553504745660554665295855556894824",
+			"3123079822626887350655514696649580980677141915307255141970749507463896361323",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"12982425935199817815259066755446031161131158570221278702242861239646270552470",
+			"5102498747304120681063234869297561678666553390318425372362768137182642230556",
+			"5650907760235911671502574958247698947488602341810330231889326036197969521231",
+			"15311713639934636809857700294816883015313069642974788089784484331866842863071",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"4378917750778986566195783994933317136780665487997343184053349232575020190805",
+			"17269370569234016318347144117809553750186193189061649546246584002692850765629",
+			"15965151781956286974774343502657082669197845298829367751669865649959140668605",
+			"21450812444968239732217119395020350433942366034590850012483985750698873548994",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"15683936267873086453313398000666330885268595221356044868315623959998545803993",
+			"3671832753185336498356295312340707707414043518732009721061564751475499397884",
+			"8481986539959965597443698434877359782057734265717731981500359220829881743669",
+			"7660359655796884328413537474185961598411595576826789377114759090571468288601",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"15099124105714544055181852556690181850324058320144202151709072305108445970672",
+			"20318193804808062899310835542933059696106644785975739849404243508909313676170",
+			"19507005947491991053222274938143459936049667535869659344107661714058651936303",
+			"9680025363676779851027254588433018356491149034845693284454451321234537209837",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"7977470924284966780400839042253052128867651372085267651005651852743199555955",
+			"6289851497425782381089985916585292730162942529496823947960740692893599485508",
+			"1278198251448605653669861163912985025434795035476225580040678106599898395055",
+			"778822024062014472867802453882888474232798997852884487172408961114550237272",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"17813998309135288259967425155412879887627227853886754905994951577284709256891",
+			"13046754442426756722325203449473048800017855579216820439904651005250574252301",
+			"2675026038592592996108363640079209157158679725371291640028590665609721944662",
+			"4508630743012318612584732934628562592521561330245083297020204983532991482453",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"11205586019601053374384489950424904802845225981790097591516963184783396704786",
+			"3269337097979539661372044451055530562428122764943331896964292158786499210701",
+			"21019215961028087428383457025829718359262809032898137235613214997150896209535",
+			"3466829339166757648673145858981890214467602134411898125584568038757537007697",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"5157412242877806836300066366873354964107079264741076245467526756146318011096",
+			"21581392381591215300367149151779503009022070613614304076664343782920390616547",
+			"18549000796552159819327648418939689514195739516390499357595136551758253444650",
+			"9515161205290672029912318778766314272223114844295330905826919799686753566536",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"6709763924604181304099526756361626798321199970667226939575017525120090147429",
+			"3564812180471312318342772028868158337379185681492234710321340015348576731268",
+			"2715256219839290031990931607545071222786464220056110728638073108255144059506",
+			"2526648118676632885942026268297123310722360774374297527748460434510013028101",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"14946395762997152888563288005029334540378039755814859784393666974164235199684",
+			"8924616408420875343266627737208318913120073601143028545020037129947462534137",
+			"14553445721437460754651496265942888390087731770131124952756252097400616930608",
+			"6484523689837038546406369281981798795409487950329098695251686883211239498930",
+			"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+			"6279378546762757460220383767956301075209286500691039336178850629635359180183",
+			"3249524281869446882651222652032498789242625585725252350645660151130325444989",
+			"18732019378264290557468133440468564866454307626475683536618613112504878618481",
+			"9131299761947733513298312097611845208338517739621853568979632113419485819303",
+		},
+		m: [][]string{
+			{
+				"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+				"18732019378264290557468133440468564866454307626475683536618613112504878618481",
+				"9131299761947733513298312097611845208338517739621853568979632113419485819303",
+			},
+			{
+				"10370080108974718697676803824769673834027675643658433702224577712625900127200",
+				"20870176810702568768751421378473869562658540583882454726129544628203806653987",
+				"10595341252162738537912664445405114076324478519622938027420701542910180337937",
+			},
+			{
+				"19705173408229649878903981084052839426532978878058043055305024233888854471533",
+				"7266061498423634438633389053804536045105766754026813321943009179476902321146",
+				"11597556804922396090267472882856054602429588299176362916247939723151043581408",
+			},
+		},
+		p: [][]string{
+			{
+				"7511745149465107256748700652201246547602992235352608707588321460060273774987",
+				"13765730681189380936346492971955185320534160954304757809496083602133165929757",
+				"12595446607664744934103076352963528000966896978346099459720409268422440395879",
+			},
+			{
+				"10370080108974718697676803824769673834027675643658433702224577712625900127200",
+				"20498480049173041451757161739353136932402063966867101132544382489060457121690",
+				"12226297560593729389190789373669758216633073552812492133170543943243249907657",
+			},
+			{
+				"19705173408229649878903981084052839426532978878058043055305024233888854471533",
+				"8087150636429993556473620686397944819119746067671291185379890893406156055968",
+				"15428267695360211473228142908425586842453705255249103144570280918777118090173",
+			},
+		},
+	},
+}
@@ -0,0 +1,154 @@
+// Package poseidon is a native implementation of the Poseidon hash exactly as
+// circomlib's poseidon.circom computes it, so the backend can derive the same
+// credentials the browser and the circuits do.
+package poseidon
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+const fullRounds = 8
+
+// partialRounds by state width t
+var partialRounds = map[int]int{2: 56, 3: 57}
+
+type roundConstants struct {
+	c, s []fr.Element
+	m, p [][]fr.Element
+}
+
+var constants = make(map[int]*roundConstants)
+
+func init() {
+	for t, strs := range constantStrings {
+		constants[t] = &roundConstants{
+			c: parseVector(strs.c),
+			s: parseVector(strs.s),
+			m: parseMatrix(strs.m),
+			p: parseMatrix(strs.p),
+		}
+	}
+}
+
+// MaxInputs is the largest number of inputs Hash accepts
+const MaxInputs = 2
+
+// Hash returns Poseidon(inputs...) for one or two field elements
+func Hash(inputs ...fr.Element) (fr.Element, error) {
+	t := len(inputs) + 1
+	rc, ok := constants[t]
+	if !ok {
+		return fr.Element{}, fmt.Errorf("poseidon: unsupported input count %d, expected 1 to %d", len(inputs), MaxInputs)
+	}
+	nRoundsP := partialRounds[t]
+
+	state := make([]fr.Element, t)
+	copy(state[1:], inputs)
+
+	ark(state, rc.c, 0)
+	for i := 0; i < fullRounds/2-1; i++ {
+		sboxAll(state)
+		ark(state, rc.c, (i+1)*t)
+		state = mix(state, rc.m)
+	}
+	sboxAll(state)
+	ark(state, rc.c, (fullRounds/2)*t)
+	state = mix(state, rc.p)
+
+	// Partial rounds only apply the S-box to the first element; the sparse
+	// matrices in S replace the full MDS multiplication
+	for i := 0; i < nRoundsP; i++ {
+		sbox(&state[0])
+		state[0].Add(&state[0], &rc.c[(fullRounds/2+1)*t+i])
+
+		var newState0, term fr.Element
+		for j := range state {
+			term.Mul(&rc.s[(t*2-1)*i+j], &state[j])
+			newState0.Add(&newState0, &term)
+		}
+		for k := 1; k < t; k++ {
+			term.Mul(&state[0], &rc.s[(t*2-1)*i+t+k-1])
+			state[k].Add(&state[k], &term)
+		}
+		state[0] = newState0
+	}
+
+	for i := 0; i < fullRounds/2-1; i++ {
+		sboxAll(state)
+		ark(state, rc.c, (fullRounds/2+1)*t+nRoundsP+i*t)
+		state = mix(state, rc.m)
+	}
+	sboxAll(state)
+	state = mix(state, rc.m)
+
+	return state[0], nil
+}
+
+// HashStrings hashes decimal field elements, the encoding snarkjs inputs use,
+// and returns the result in the same encoding
+func HashStrings(inputs ...string) (string, error) {
+	elements := make([]fr.Element, len(inputs))
+	for i, input := range inputs {
+		if _, err := elements[i].SetString(input); err != nil {
+			return "", fmt.Errorf("poseidon: input %d: %w", i, err)
+		}
+	}
+
+	h, err := Hash(elements...)
+	if err != nil {
+		return "", err
+	}
+	return h.String(), nil
+}
+
+func sbox(x *fr.Element) {
+	var x2, x4 fr.Element
+	x2.Square(x)
+	x4.Square(&x2)
+	x.Mul(x, &x4)
+}
+
+func sboxAll(state []fr.Element) {
+	for i := range state {
+		sbox(&state[i])
+	}
+}
+
+func ark(state, c []fr.Element, offset int) {
+	for i := range state {
+		state[i].Add(&state[i], &c[offset+i])
+	}
+}
+
+// mix returns the state multiplied by the matrix, newState[i] = Σ m[j][i]·state[j]
+func mix(state []fr.Element, m [][]fr.Element) []fr.Element {
+	newState := make([]fr.Element, len(state))
+	var term fr.Element
+	for i := range state {
+		for j := range state {
+			term.Mul(&m[j][i], &state[j])
+			newState[i].Add(&newState[i], &term)
+		}
+	}
+	return newState
+}
+
+func parseVector(strs []string) []fr.Element {
+	v := make([]fr.Element, len(strs))
+	for i, s := range strs {
+		if _, err := v[i].SetString(s); err != nil {
+			panic(fmt.Sprintf("poseidon: bad constant %q: %v", s, err))
+		}
+	}
+	return v
+}
+
+func parseMatrix(strs [][]string) [][]fr.Element {
+	m := make([][]fr.Element, len(strs))
+	for i, row := range strs {
+		m[i] = parseVector(row)
+	}
+	return m
+}
@@ -0,0 +1,94 @@
+package poseidon_test
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/threehook/zkp-auth/backend/poseidon"
+)
+
+// circomlibVectors are outputs of circomlib's Poseidon (and circomlibjs)
+var circomlibVectors = []struct {
+	inputs []string
+	want   string
+}{
+	{[]string{"1"}, "18586133768512220936620570745912940619677854269274689475585506675881198879027"},
+	{[]string{"1", "2"}, "7853200120776062878684798364095072458815029376092732009249414926327459813530"},
+}
+
+func TestHashMatchesCircomlib(t *testing.T) {
+	for _, v := range circomlibVectors {
+		got, err := poseidon.HashStrings(v.inputs...)
+		if err != nil {
+			t.Fatalf("HashStrings(%v): %v", v.inputs, err)
+		}
+		if got != v.want {
+			t.Errorf("Poseidon(%v) = %s, want %s", v.inputs, got, v.want)
+		}
+	}
+}
+
+func TestHashRejectsUnsupportedInputCounts(t *testing.T) {
+	if _, err := poseidon.Hash(); err == nil {
+		t.Error("Hash() succeeded, want an error")
+	}
+	if _, err := poseidon.Hash(make([]fr.Element, poseidon.MaxInputs+1)...); err == nil {
+		t.Errorf("Hash of %d inputs succeeded, want an error", poseidon.MaxInputs+1)
+	}
+	if _, err := poseidon.HashStrings("not a number"); err == nil {
+		t.Error("HashStrings of a non-number succeeded, want an error")
+	}
+}
+
+// hashCircuit asserts that HashCircuit of In is Out
+type hashCircuit struct {
+	In  []frontend.Variable
+	Out frontend.Variable `gnark:",public"`
+}
+
+func (c *hashCircuit) Define(api frontend.API) error {
+	h, err := poseidon.HashCircuit(api, c.In...)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(h, c.Out)
+	return nil
+}
+
+func TestHashCircuitAgreesWithHash(t *testing.T) {
+	inputs := [][]string{
+		{"1"},
+		{"1", "2"},
+		{"0", "0"},
+		{"12345678901234567890", "21888242871839275222246405745257275088548364400416034343698204186575808495616"},
+	}
+	for _, strs := range inputs {
+		elements := make([]fr.Element, len(strs))
+		assigned := make([]frontend.Variable, len(strs))
+		for i, s := range strs {
+			if _, err := elements[i].SetString(s); err != nil {
+				t.Fatal(err)
+			}
+			assigned[i] = s
+		}
+		want, err := poseidon.Hash(elements...)
+		if err != nil {
+			t.Fatalf("Hash(%v): %v", strs, err)
+		}
+
+		placeholder := &hashCircuit{In: make([]frontend.Variable, len(strs))}
+		if err := test.IsSolved(placeholder, &hashCircuit{In: assigned, Out: want.String()}, ecc.BN254.ScalarField()); err != nil {
+			t.Errorf("circuit rejects the native Poseidon(%v): %v", strs, err)
+		}
+
+		var wrong fr.Element
+		wrong.SetOne()
+		wrong.Add(&wrong, &want)
+		if err := test.IsSolved(placeholder, &hashCircuit{In: assigned, Out: wrong.String()}, ecc.BN254.ScalarField()); err == nil {
+			t.Errorf("circuit accepts a wrong Poseidon(%v)", strs)
+		}
+	}
+}
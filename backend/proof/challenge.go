@@ -0,0 +1,171 @@
+package proof
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxChallengesPerClient bounds outstanding challenges per username and client
+// IP; issuing more evicts the oldest one. Keying by IP as well keeps a client
+// elsewhere from evicting the challenges of a user who is logging in
+const maxChallengesPerClient = 5
+
+var (
+	ErrChallengeNotFound = errors.New("unknown or already used challenge")
+	ErrChallengeExpired  = errors.New("challenge has expired")
+	ErrChallengeMismatch = errors.New("challenge was issued to a different user or client")
+	ErrChallengePurpose  = errors.New("challenge was issued for a different kind of proof")
+)
+
+// Challenge is a server-random, single-use nonce for one kind of proof
+type Challenge struct {
+	Value     string
+	Username  string
+	IPAddress string
+	Purpose   ProofType
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// challengeClient identifies the client a challenge was issued to
+type challengeClient struct {
+	username  string
+	ipAddress string
+}
+
+type ChallengeStore struct {
+	mu         sync.Mutex
+	challenges map[string]Challenge
+	byClient   map[challengeClient][]string // outstanding values, oldest first
+	issued     []string                     // every issued value, oldest first
+	ttl        time.Duration
+}
+
+func NewChallengeStore(ttl time.Duration) *ChallengeStore {
+	return &ChallengeStore{
+		challenges: make(map[string]Challenge),
+		byClient:   make(map[challengeClient][]string),
+		ttl:        ttl,
+	}
+}
+
+// Issue creates a challenge for a proof of the given purpose, tied to the
+// username and client IP
+func (cs *ChallengeStore) Issue(ctx context.Context, username, ipAddress string, purpose ProofType) (Challenge, error) {
+	if err := ctx.Err(); err != nil {
+		return Challenge{}, err
+	}
+
+	value, err := randomChallenge()
+	if err != nil {
+		return Challenge{}, err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.cleanExpired()
+
+	client := challengeClient{username: username, ipAddress: ipAddress}
+	if outstanding := cs.byClient[client]; len(outstanding) >= maxChallengesPerClient {
+		cs.remove(outstanding[0])
+	}
+
+	now := time.Now()
+	challenge := Challenge{
+		Value:     value,
+		Username:  username,
+		IPAddress: ipAddress,
+		Purpose:   purpose,
+		CreatedAt: now,
+		ExpiresAt: now.Add(cs.ttl),
+	}
+	cs.challenges[value] = challenge
+	cs.byClient[client] = append(cs.byClient[client], value)
+	cs.issued = append(cs.issued, value)
+	return challenge, nil
+}
+
+// Consume atomically removes the challenge and checks that it is still valid
+// for the username, client IP and kind of proof. A challenge can be presented
+// only once, whether or not the check succeeds
+func (cs *ChallengeStore) Consume(ctx context.Context, value, username, ipAddress string, purpose ProofType) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	cs.mu.Lock()
+	challenge, exists := cs.challenges[value]
+	cs.remove(value)
+	cs.mu.Unlock()
+
+	if !exists {
+		return ErrChallengeNotFound
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		return ErrChallengeExpired
+	}
+	if challenge.Username != username || challenge.IPAddress != ipAddress {
+		return ErrChallengeMismatch
+	}
+	if challenge.Purpose != purpose {
+		return ErrChallengePurpose
+	}
+	return nil
+}
+
+// Cleanup explicitly removes expired challenges
+func (cs *ChallengeStore) Cleanup() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.cleanExpired()
+}
+
+// cleanExpired drops challenges from the front of the issue order until it
+// reaches one that is still valid. Every challenge lives for the same TTL, so
+// the rest expire later
+func (cs *ChallengeStore) cleanExpired() {
+	now := time.Now()
+	expired := 0
+	for _, value := range cs.issued {
+		challenge, exists := cs.challenges[value]
+		if exists && !now.After(challenge.ExpiresAt) {
+			break
+		}
+		cs.remove(value)
+		expired++
+	}
+	cs.issued = cs.issued[expired:]
+}
+
+// remove forgets an outstanding challenge. Its entry in issued is dropped
+// by cleanExpired
+func (cs *ChallengeStore) remove(value string) {
+	challenge, exists := cs.challenges[value]
+	if !exists {
+		return
+	}
+	delete(cs.challenges, value)
+
+	client := challengeClient{username: challenge.Username, ipAddress: challenge.IPAddress}
+	outstanding := slices.DeleteFunc(cs.byClient[client], func(v string) bool { return v == value })
+	if len(outstanding) == 0 {
+		delete(cs.byClient, client)
+	} else {
+		cs.byClient[client] = outstanding
+	}
+}
+
+func randomChallenge() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate challenge: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,108 @@
+package proof
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChallengeCapIsPerClient(t *testing.T) {
+	ctx := context.Background()
+	cs := NewChallengeStore(time.Minute)
+
+	victim, err := cs.Issue(ctx, "alice", "10.0.0.1", ProofTypeLogin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Flooding alice's username from elsewhere must not evict her challenge
+	for range 2 * maxChallengesPerClient {
+		if _, err := cs.Issue(ctx, "alice", "10.0.0.2", ProofTypeLogin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(cs.byClient[challengeClient{"alice", "10.0.0.2"}]); got != maxChallengesPerClient {
+		t.Fatalf("attacker holds %d challenges, want %d", got, maxChallengesPerClient)
+	}
+	if err := cs.Consume(ctx, victim.Value, "alice", "10.0.0.1", ProofTypeLogin); err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+}
+
+func TestChallengeEvictsOldestForClient(t *testing.T) {
+	ctx := context.Background()
+	cs := NewChallengeStore(time.Minute)
+
+	first, err := cs.Issue(ctx, "alice", "10.0.0.1", ProofTypeLogin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range maxChallengesPerClient {
+		if _, err := cs.Issue(ctx, "alice", "10.0.0.1", ProofTypeLogin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cs.Consume(ctx, first.Value, "alice", "10.0.0.1", ProofTypeLogin); !errors.Is(err, ErrChallengeNotFound) {
+		t.Fatalf("err = %v, want ErrChallengeNotFound", err)
+	}
+}
+
+func TestChallengeConsume(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		username  string
+		ipAddress string
+		purpose   ProofType
+		want      error
+	}{
+		{"valid", "alice", "10.0.0.1", ProofTypeAuth, nil},
+		{"other user", "bob", "10.0.0.1", ProofTypeAuth, ErrChallengeMismatch},
+		{"other client", "alice", "10.0.0.2", ProofTypeAuth, ErrChallengeMismatch},
+		{"login proof", "alice", "10.0.0.1", ProofTypeLogin, ErrChallengePurpose},
+		{"recovery proof", "alice", "10.0.0.1", ProofTypeRecovery, ErrChallengePurpose},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := NewChallengeStore(time.Minute)
+			challenge, err := cs.Issue(ctx, "alice", "10.0.0.1", ProofTypeAuth)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := cs.Consume(ctx, challenge.Value, tt.username, tt.ipAddress, tt.purpose); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			// Presented once, gone either way
+			if err := cs.Consume(ctx, challenge.Value, "alice", "10.0.0.1", ProofTypeAuth); !errors.Is(err, ErrChallengeNotFound) {
+				t.Fatalf("second Consume: err = %v, want ErrChallengeNotFound", err)
+			}
+		})
+	}
+}
+
+func TestChallengeExpiry(t *testing.T) {
+	ctx := context.Background()
+	cs := NewChallengeStore(10 * time.Millisecond)
+
+	expired, err := cs.Issue(ctx, "alice", "10.0.0.1", ProofTypeLogin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := cs.Issue(ctx, "bob", "10.0.0.1", ProofTypeLogin); err != nil {
+		t.Fatal(err)
+	}
+
+	// Issuing dropped the expired challenge and its index entry
+	if _, exists := cs.challenges[expired.Value]; exists {
+		t.Fatal("expired challenge still stored")
+	}
+	if _, exists := cs.byClient[challengeClient{"alice", "10.0.0.1"}]; exists {
+		t.Fatal("expired challenge still indexed")
+	}
+	if len(cs.issued) != 1 {
+		t.Fatalf("issue order holds %d challenges, want 1", len(cs.issued))
+	}
+}
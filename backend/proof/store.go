@@ -1,6 +1,7 @@
 package proof
 
 import (
+	"context"
 	"sync"
 	"time"
 )
@@ -29,7 +30,11 @@ func NewStore(ttl time.Duration) *Store {
 }
 
 // AddProof adds a proof with enhanced metadata and returns true if it was new
-func (ps *Store) AddProof(nonce string, username string, proofType ProofType, ipAddress string, userAgent string) bool {
+func (ps *Store) AddProof(ctx context.Context, nonce string, username string, proofType ProofType, ipAddress string, userAgent string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
 
@@ -38,7 +43,7 @@ func (ps *Store) AddProof(nonce string, username string, proofType ProofType, ip
 
 	// Check if proof already exists
 	if _, exists := ps.usedProofs[nonce]; exists {
-		return false
+		return false, nil
 	}
 
 	// Add new proof with enhanced metadata
@@ -51,29 +56,34 @@ func (ps *Store) AddProof(nonce string, username string, proofType ProofType, ip
 		UserAgent: userAgent,
 		CreatedAt: time.Now(),
 	}
-	return true
+	return true, nil
 }
 
 // HasProof checks if a proof has been used
-func (ps *Store) HasProof(proofID string) bool {
-	ps.mu.RLock()
-	defer ps.mu.RUnlock()
-
-	_, exists := ps.usedProofs[proofID]
-	return exists
+func (ps *Store) HasProof(ctx context.Context, proofID string) (bool, error) {
+	_, exists, err := ps.GetProofMetadata(ctx, proofID)
+	return exists, err
 }
 
 // GetProofMetadata retrieves proof metadata for auditing
-func (ps *Store) GetProofMetadata(proofID string) (ProofRecord, bool) {
+func (ps *Store) GetProofMetadata(ctx context.Context, proofID string) (ProofRecord, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return ProofRecord{}, false, err
+	}
+
 	ps.mu.RLock()
 	defer ps.mu.RUnlock()
 
 	record, exists := ps.usedProofs[proofID]
-	return record, exists
+	return record, exists, nil
 }
 
 // GetProofsByUsername for security monitoring
-func (ps *Store) GetProofsByUsername(username string) []ProofRecord {
+func (ps *Store) GetProofsByUsername(ctx context.Context, username string) ([]ProofRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	ps.mu.RLock()
 	defer ps.mu.RUnlock()
 
@@ -83,7 +93,7 @@ func (ps *Store) GetProofsByUsername(username string) []ProofRecord {
 			records = append(records, record)
 		}
 	}
-	return records
+	return records, nil
 }
 
 // Cleanup explicitly cleans expired proofs
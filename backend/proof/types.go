@@ -5,18 +5,22 @@ import "time"
 type ProofType string
 
 const (
-	ProofTypeLogin ProofType = "login"
-	ProofTypeAuth  ProofType = "auth"
+	ProofTypeLogin    ProofType = "login"
+	ProofTypeAuth     ProofType = "auth"
+	ProofTypeRegister ProofType = "register"
+	ProofTypeRecovery ProofType = "recovery"
 )
 
 type Request struct {
-	Username      string                 `json:"username"`
-	Proof         map[string]interface{} `json:"proof"`
-	PublicSignals []interface{}          `json:"publicSignals"`
-	Nonce         string                 `json:"nonce"`
-	Timestamp     int64                  `json:"timestamp"`
-	SessionID     string                 `json:"sessionId,omitempty"` // NEW: Session binding
-	ProofType     ProofType              `json:"proofType"`           // NEW: Proof categorization
+	Username       string                 `json:"username"`
+	Proof          map[string]interface{} `json:"proof"`
+	PublicSignals  []interface{}          `json:"publicSignals"`
+	Nonce          string                 `json:"nonce"`
+	Timestamp      int64                  `json:"timestamp"`
+	SessionID      string                 `json:"sessionId,omitempty"` // NEW: Session binding
+	ProofType      ProofType              `json:"proofType"`           // NEW: Proof categorization
+	CircuitID      string                 `json:"circuitId,omitempty"`
+	CircuitVersion int                    `json:"circuitVersion,omitempty"` // 0 = any non-revoked version
 }
 
 type ProofMetadata struct {
@@ -1,6 +1,7 @@
 package proof
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -8,13 +9,15 @@ import (
 
 type Validator struct {
 	store           *Store
+	challenges      *ChallengeStore
 	maxAge          time.Duration
 	futureAllowance time.Duration
 }
 
-func NewValidator(store *Store, maxAge, futureAllowance time.Duration) *Validator {
+func NewValidator(store *Store, challenges *ChallengeStore, maxAge, futureAllowance time.Duration) *Validator {
 	return &Validator{
 		store:           store,
+		challenges:      challenges,
 		maxAge:          maxAge,
 		futureAllowance: futureAllowance,
 	}
@@ -24,8 +27,12 @@ func (v *Validator) GetStore() *Store {
 	return v.store
 }
 
+func (v *Validator) GetChallengeStore() *ChallengeStore {
+	return v.challenges
+}
+
 // ValidateProofRequest with security context
-func (v *Validator) ValidateProofRequest(proofReq Request, ipAddress string, userAgent string) error {
+func (v *Validator) ValidateProofRequest(ctx context.Context, proofReq Request, ipAddress string, userAgent string) error {
 	// Basic validation
 	if err := v.validateBasicFields(proofReq); err != nil {
 		return err
@@ -41,14 +48,24 @@ func (v *Validator) ValidateProofRequest(proofReq Request, ipAddress string, use
 		return err
 	}
 
+	// The nonce must be an outstanding server-issued challenge
+	if err := v.challenges.Consume(ctx, proofReq.Nonce, proofReq.Username, ipAddress, proofReq.ProofType); err != nil {
+		return fmt.Errorf("challenge rejected: %w", err)
+	}
+
 	// Check for replay attack with security context
-	if !v.store.AddProof(
+	added, err := v.store.AddProof(
+		ctx,
 		proofReq.Nonce,
 		proofReq.Username,
 		proofReq.ProofType,
 		ipAddress,
 		userAgent,
-	) {
+	)
+	if err != nil {
+		return err
+	}
+	if !added {
 		return fmt.Errorf("proof replay detected - nonce already used")
 	}
 
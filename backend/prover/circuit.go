@@ -0,0 +1,54 @@
+// Package prover is a native gnark implementation of the password relation in
+// circuits/password.circom, so Go services, integration tests and machine
+// clients can produce login proofs without Node or snarkjs.
+package prover
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/threehook/zkp-auth/backend/poseidon"
+	"github.com/threehook/zkp-auth/backend/verifier"
+)
+
+// CircuitID is the registry ID of the gnark circuit. It has its own keys, so
+// circom keys of the same relation are registered under another ID rather
+// than as versions of it
+const CircuitID = "password-gnark"
+
+// PublicSignals is the layout of the public witness, in the order of the
+// public fields of PasswordCircuit
+var PublicSignals = []string{
+	verifier.SignalSalt,
+	verifier.SignalStoredHash,
+	verifier.SignalNonce,
+	verifier.SignalTimestamp,
+	verifier.SignalUsernameHash,
+}
+
+// PasswordCircuit proves knowledge of the password behind a stored credential
+// storedHash = Poseidon(password, salt), bound to the login request, exactly
+// like the circom SecurePassword template
+type PasswordCircuit struct {
+	Password frontend.Variable `gnark:",secret"` // UTF-8 bytes of the password, big-endian
+
+	Salt         frontend.Variable `gnark:",public"`
+	StoredHash   frontend.Variable `gnark:",public"`
+	Nonce        frontend.Variable `gnark:",public"` // HashToField(nonce)
+	Timestamp    frontend.Variable `gnark:",public"` // unix seconds
+	UsernameHash frontend.Variable `gnark:",public"` // HashToField(username)
+}
+
+// Define declares the circuit constraints
+func (c *PasswordCircuit) Define(api frontend.API) error {
+	hash, err := poseidon.HashCircuit(api, c.Password, c.Salt)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(hash, c.StoredHash)
+
+	// Same reason as in the circom circuit: a public input that appears in no
+	// constraint is not bound by the proof
+	api.Mul(c.Nonce, c.Nonce)
+	api.Mul(c.Timestamp, c.Timestamp)
+	api.Mul(c.UsernameHash, c.UsernameHash)
+	return nil
+}
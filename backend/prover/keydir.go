@@ -0,0 +1,99 @@
+package prover
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// VersionDir is where Save output for a version lives under root, in the
+// <id>/v<version>/ layout of VERIFICATION_KEY_DIR
+func VersionDir(root string, version int) string {
+	return filepath.Join(root, CircuitID, fmt.Sprintf("v%d", version))
+}
+
+// ErrNoKeys is returned by Open when root holds no keys and setting them up
+// was not allowed
+var ErrNoKeys = errors.New("no circuit keys installed")
+
+// Open loads the newest version of the circuit kept under root. When there is
+// none and devSetup is set, it runs Setup for version 1 and saves it there
+// first. Like every Setup that is a single-party setup: the server that ran it
+// knows the toxic waste and can forge a proof for any user, so devSetup is
+// only for development. Otherwise a missing key fails with ErrNoKeys, and
+// production keys, from a multi-party ceremony, are installed under root
+// beforehand. Instances sharing root share the keys: when several set up at
+// once, the first to save wins and the others load its keys
+func Open(root string, devSetup bool) (*Prover, error) {
+	version, err := newestVersion(filepath.Join(root, CircuitID))
+	if err != nil {
+		return nil, err
+	}
+	if version > 0 {
+		return Load(VersionDir(root, version))
+	}
+	if !devSetup {
+		return nil, fmt.Errorf("%w under %s", ErrNoKeys, filepath.Join(root, CircuitID))
+	}
+
+	p, err := Setup(1)
+	if err != nil {
+		return nil, err
+	}
+
+	// Save into a scratch directory and rename it into place, so no instance
+	// ever loads half-written keys
+	if err := os.MkdirAll(filepath.Join(root, CircuitID), 0o755); err != nil {
+		return nil, err
+	}
+	scratch, err := os.MkdirTemp(filepath.Join(root, CircuitID), ".setup-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(scratch)
+	if err := os.Chmod(scratch, 0o755); err != nil {
+		return nil, err
+	}
+	if err := p.Save(scratch); err != nil {
+		return nil, err
+	}
+
+	dir := VersionDir(root, 1)
+	if err := os.Rename(scratch, dir); err != nil {
+		if _, statErr := os.Stat(filepath.Join(dir, ProvingKeyFile)); statErr == nil {
+			return Load(dir)
+		}
+		return nil, err
+	}
+	log.Printf("⚠️ Set up %s version 1 development keys in %s; this server can forge proofs for them", CircuitID, dir)
+	return p, nil
+}
+
+// newestVersion returns the highest v<N> directory under dir that holds a
+// proving key, or 0 if there is none
+func newestVersion(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	newest := 0
+	for _, entry := range entries {
+		version, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), "v"))
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "v") || err != nil || version <= newest {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, entry.Name(), ProvingKeyFile)); err == nil {
+			newest = version
+		}
+	}
+	return newest, nil
+}
@@ -0,0 +1,151 @@
+package prover_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/threehook/zkp-auth/backend/credential"
+	"github.com/threehook/zkp-auth/backend/prover"
+	"github.com/threehook/zkp-auth/backend/verifier"
+)
+
+// sameKeys reports whether two provers come from the same setup, by the
+// points every setup draws afresh
+func sameKeys(a, b *prover.Prover) bool {
+	ka, kb := a.Circuit().VerifyingKey, b.Circuit().VerifyingKey
+	return ka.G1.Alpha.Equal(&kb.G1.Alpha) && ka.G2.Delta.Equal(&kb.G2.Delta) && ka.G2.Gamma.Equal(&kb.G2.Gamma)
+}
+
+func TestOpenSetsUpOnFirstBootAndReloads(t *testing.T) {
+	root := t.TempDir()
+
+	first, err := prover.Open(root, true)
+	if err != nil {
+		t.Fatalf("first Open: %v", err)
+	}
+	if version := first.Circuit().Version; version != 1 {
+		t.Fatalf("version = %d, want 1", version)
+	}
+	if _, err := os.Stat(filepath.Join(prover.VersionDir(root, 1), prover.ProvingKeyFile)); err != nil {
+		t.Fatalf("proving key not saved: %v", err)
+	}
+
+	second, err := prover.Open(root, true)
+	if err != nil {
+		t.Fatalf("second Open: %v", err)
+	}
+	if !sameKeys(first, second) {
+		t.Fatal("second Open ran a new setup instead of loading the saved keys")
+	}
+
+	// A proof from the reloaded keys verifies against the first boot's key
+	registry := verifier.NewRegistry(prover.CircuitID)
+	if err := registry.Register(first.Circuit()); err != nil {
+		t.Fatal(err)
+	}
+	salt, err := credential.GenerateSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitment, err := credential.Compute("correct horse", salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := verifier.Credential{Salt: salt, Commitment: commitment}
+	request, err := second.Prove("alice", "correct horse", stored, "nonce-0123456789abcdef", time.Now().Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.NewRegistryVerifier(registry).Verify(context.Background(), verifier.NewInput(request, stored)); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
+
+func TestOpenRefusesSetupWithoutDevFlag(t *testing.T) {
+	root := t.TempDir()
+
+	if _, err := prover.Open(root, false); !errors.Is(err, prover.ErrNoKeys) {
+		t.Fatalf("Open without keys = %v, want ErrNoKeys", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, prover.CircuitID)); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Open without the dev flag wrote to %s", root)
+	}
+
+	// Installed keys load without it
+	p, err := prover.Setup(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Save(prover.VersionDir(root, 1)); err != nil {
+		t.Fatal(err)
+	}
+	opened, err := prover.Open(root, false)
+	if err != nil {
+		t.Fatalf("Open with installed keys: %v", err)
+	}
+	if !sameKeys(opened, p) {
+		t.Fatal("Open did not load the installed keys")
+	}
+}
+
+func TestOpenPicksNewestVersion(t *testing.T) {
+	root := t.TempDir()
+	p, err := prover.Setup(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// v3 holds keys; v4 has none and must be skipped
+	if err := p.Save(prover.VersionDir(root, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(prover.VersionDir(root, 4), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	opened, err := prover.Open(root, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameKeys(opened, p) {
+		t.Fatal("Open did not load the saved v3 keys")
+	}
+}
+
+func TestOpenConcurrentFirstBootsAgree(t *testing.T) {
+	root := t.TempDir()
+
+	provers := make([]*prover.Prover, 3)
+	var wg sync.WaitGroup
+	for i := range provers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := prover.Open(root, true)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			provers[i] = p
+		}()
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	saved, err := prover.Load(prover.VersionDir(root, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range provers {
+		if !sameKeys(p, saved) {
+			t.Errorf("instance %d uses keys that were not saved", i)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package prover
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/threehook/zkp-auth/backend/credential"
+	"github.com/threehook/zkp-auth/backend/proof"
+	"github.com/threehook/zkp-auth/backend/verifier"
+)
+
+// ProvingKeyFile is the name of the proving key next to the circuit.json and
+// verification_key.json written by Save
+const ProvingKeyFile = "proving_key.bin"
+
+// Prover generates Groth16 proofs for PasswordCircuit in the snarkjs format the
+// backend verifies
+type Prover struct {
+	ccs     constraint.ConstraintSystem
+	pk      groth16.ProvingKey
+	circuit verifier.Circuit
+}
+
+// Compile builds the constraint system of PasswordCircuit
+func Compile() (constraint.ConstraintSystem, error) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &PasswordCircuit{})
+	if err != nil {
+		return nil, fmt.Errorf("compile password circuit: %w", err)
+	}
+	return ccs, nil
+}
+
+// Setup compiles the circuit and runs a fresh Groth16 setup for the given
+// circuit version. This is a single-party setup: whoever runs it knows the
+// toxic waste and can forge proofs, so it is only suitable for development and
+// tests. Production keys come from a multi-party ceremony
+func Setup(version int) (*Prover, error) {
+	ccs, err := Compile()
+	if err != nil {
+		return nil, err
+	}
+
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		return nil, fmt.Errorf("groth16 setup: %w", err)
+	}
+
+	verifyingKey, err := convertVerifyingKey(vk)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Prover{
+		ccs: ccs,
+		pk:  pk,
+		circuit: verifier.Circuit{
+			ID:            CircuitID,
+			Version:       version,
+			PublicSignals: PublicSignals,
+			VerifyingKey:  verifyingKey,
+		},
+	}, nil
+}
+
+// Load reads a prover written by Save from dir
+func Load(dir string) (*Prover, error) {
+	p, err := LoadFS(os.DirFS(dir))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	return p, nil
+}
+
+// LoadFS reads a prover from the root of fsys, laid out as Save writes it
+func LoadFS(fsys fs.FS) (*Prover, error) {
+	circuits, err := verifier.LoadCircuits(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if len(circuits) != 1 || circuits[0].ID != CircuitID {
+		return nil, fmt.Errorf("not a single %s circuit", CircuitID)
+	}
+
+	ccs, err := Compile()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := fsys.Open(ProvingKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pk := groth16.NewProvingKey(ecc.BN254)
+	if _, err := pk.ReadFrom(f); err != nil {
+		return nil, fmt.Errorf("read proving key: %w", err)
+	}
+
+	return &Prover{ccs: ccs, pk: pk, circuit: circuits[0]}, nil
+}
+
+// Save writes the proving key, the snarkjs verification key and the circuit
+// manifest to dir. The directory can then be deployed under
+// VERIFICATION_KEY_DIR like any circom circuit version
+func (p *Prover) Save(dir string) error {
+	if err := verifier.WriteCircuit(dir, p.circuit); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(dir, ProvingKeyFile))
+	if err != nil {
+		return err
+	}
+	if _, err := p.pk.WriteTo(f); err != nil {
+		f.Close()
+		return fmt.Errorf("write proving key: %w", err)
+	}
+	return f.Close()
+}
+
+// Circuit returns the verifier's view of the circuit this prover generates
+// proofs for
+func (p *Prover) Circuit() verifier.Circuit {
+	return p.circuit
+}
+
+// Prove generates a login proof for the user's stored credential, bound to the
+// nonce and timestamp, and returns it as the proof part of a login request
+func (p *Prover) Prove(username, password string, stored verifier.Credential, nonce string, timestamp int64) (proof.Request, error) {
+	passwordField, err := credential.PasswordToField(password)
+	if err != nil {
+		return proof.Request{}, err
+	}
+	salt, err := credential.ParseFieldElement(stored.Salt)
+	if err != nil {
+		return proof.Request{}, fmt.Errorf("invalid salt: %w", err)
+	}
+	storedHash, err := credential.ParseFieldElement(stored.Commitment)
+	if err != nil {
+		return proof.Request{}, fmt.Errorf("invalid commitment: %w", err)
+	}
+	if timestamp < 0 {
+		return proof.Request{}, fmt.Errorf("timestamp must not be negative")
+	}
+
+	assignment := &PasswordCircuit{
+		Password:     passwordField,
+		Salt:         salt,
+		StoredHash:   storedHash,
+		Nonce:        verifier.HashToField(nonce),
+		Timestamp:    uint64(timestamp),
+		UsernameHash: verifier.HashToField(username),
+	}
+
+	witness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		return proof.Request{}, fmt.Errorf("build witness: %w", err)
+	}
+	publicWitness, err := witness.Public()
+	if err != nil {
+		return proof.Request{}, err
+	}
+	publicSignals, ok := publicWitness.Vector().(fr.Vector)
+	if !ok {
+		return proof.Request{}, fmt.Errorf("unexpected public witness type %T", publicWitness.Vector())
+	}
+
+	// Solving fails here when the password does not match the credential
+	gnarkProof, err := groth16.Prove(p.ccs, p.pk, witness)
+	if err != nil {
+		return proof.Request{}, fmt.Errorf("prove: %w", err)
+	}
+	bn254Proof, ok := gnarkProof.(*groth16bn254.Proof)
+	if !ok {
+		return proof.Request{}, fmt.Errorf("unexpected proof type %T", gnarkProof)
+	}
+
+	return proof.Request{
+		Username: username,
+		Proof: verifier.FormatSnarkJSProof(&verifier.Proof{
+			Ar:  bn254Proof.Ar,
+			Krs: bn254Proof.Krs,
+			Bs:  bn254Proof.Bs,
+		}),
+		PublicSignals:  verifier.FormatPublicSignals(publicSignals),
+		Nonce:          nonce,
+		Timestamp:      timestamp,
+		ProofType:      proof.ProofTypeLogin,
+		CircuitID:      p.circuit.ID,
+		CircuitVersion: p.circuit.Version,
+	}, nil
+}
+
+func convertVerifyingKey(vk groth16.VerifyingKey) (*verifier.VerifyingKey, error) {
+	bn254Key, ok := vk.(*groth16bn254.VerifyingKey)
+	if !ok {
+		return nil, fmt.Errorf("unexpected verifying key type %T", vk)
+	}
+	if len(bn254Key.CommitmentKeys) > 0 {
+		return nil, fmt.Errorf("circuit uses commitments, which snarkjs keys cannot express")
+	}
+
+	key := verifier.NewVerifyingKey()
+	key.G1.Alpha = bn254Key.G1.Alpha
+	key.G1.Beta = bn254Key.G1.Beta
+	key.G1.Delta = bn254Key.G1.Delta
+	key.G1.K = bn254Key.G1.K
+	key.G2.Beta = bn254Key.G2.Beta
+	key.G2.Gamma = bn254Key.G2.Gamma
+	key.G2.Delta = bn254Key.G2.Delta
+	if err := key.Precompute(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	sessionVersionsBucket = []byte("session_versions") // username -> big-endian uint64
+	revokedTokensBucket   = []byte("revoked_tokens")   // token ID -> JSON expiry
+	refreshFamiliesBucket = []byte("refresh_families") // family ID -> JSON RefreshFamily
+	refreshTokensBucket   = []byte("refresh_tokens")   // token hash -> JSON RefreshTokenRecord
+)
+
+func (r *BoltUserRepo) SessionVersion(ctx context.Context, username string) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var version uint64
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		version, err = getSessionVersion(tx, username)
+		return err
+	})
+	return version, err
+}
+
+func (r *BoltUserRepo) BumpSessionVersion(ctx context.Context, username string) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var version uint64
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		current, err := getSessionVersion(tx, username)
+		if err != nil {
+			return err
+		}
+		version = current + 1
+
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], version)
+		return tx.Bucket(sessionVersionsBucket).Put([]byte(username), buf[:])
+	})
+	return version, err
+}
+
+func (r *BoltUserRepo) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !time.Now().Before(expiresAt) {
+		return nil
+	}
+
+	data, err := json.Marshal(expiresAt.UTC())
+	if err != nil {
+		return err
+	}
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(revokedTokensBucket).Put([]byte(tokenID), data)
+	})
+}
+
+func (r *BoltUserRepo) TokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	var revoked bool
+	err := r.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(revokedTokensBucket).Get([]byte(tokenID))
+		if data == nil {
+			return nil
+		}
+		var expiresAt time.Time
+		if err := json.Unmarshal(data, &expiresAt); err != nil {
+			return fmt.Errorf("decode revocation of %s: %w", tokenID, err)
+		}
+		revoked = time.Now().Before(expiresAt)
+		return nil
+	})
+	return revoked, err
+}
+
+func (r *BoltUserRepo) CreateRefreshFamily(ctx context.Context, family RefreshFamily, token RefreshTokenRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		if err := putJSON(tx.Bucket(refreshFamiliesBucket), family.ID, family); err != nil {
+			return err
+		}
+		return putJSON(tx.Bucket(refreshTokensBucket), token.Hash, token)
+	})
+}
+
+func (r *BoltUserRepo) AddRefreshToken(ctx context.Context, token RefreshTokenRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		var family RefreshFamily
+		found, err := getJSON(tx.Bucket(refreshFamiliesBucket), token.FamilyID, &family)
+		if err != nil {
+			return err
+		}
+		if !found || family.Revoked {
+			return ErrRefreshTokenInvalid
+		}
+
+		family.ExpiresAt = token.ExpiresAt
+		if err := putJSON(tx.Bucket(refreshFamiliesBucket), family.ID, family); err != nil {
+			return err
+		}
+		return putJSON(tx.Bucket(refreshTokensBucket), token.Hash, token)
+	})
+}
+
+// UseRefreshToken checks and marks the token in one write transaction; bolt
+// runs one at a time, so concurrent calls cannot both see it unrotated
+func (r *BoltUserRepo) UseRefreshToken(ctx context.Context, tokenHash string) (RefreshFamily, error) {
+	if err := ctx.Err(); err != nil {
+		return RefreshFamily{}, err
+	}
+
+	var family RefreshFamily
+	var reused bool
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		var token RefreshTokenRecord
+		found, err := getJSON(tx.Bucket(refreshTokensBucket), tokenHash, &token)
+		if err != nil {
+			return err
+		}
+		if !found || !time.Now().Before(token.ExpiresAt) {
+			return ErrRefreshTokenInvalid
+		}
+		found, err = getJSON(tx.Bucket(refreshFamiliesBucket), token.FamilyID, &family)
+		if err != nil {
+			return err
+		}
+		if !found || family.Revoked {
+			return ErrRefreshTokenInvalid
+		}
+
+		if token.Rotated {
+			reused = true
+			family.Revoked = true
+			return putJSON(tx.Bucket(refreshFamiliesBucket), family.ID, family)
+		}
+		token.Rotated = true
+		return putJSON(tx.Bucket(refreshTokensBucket), tokenHash, token)
+	})
+	if err != nil {
+		return RefreshFamily{}, err
+	}
+	if reused {
+		return family, ErrRefreshTokenReused
+	}
+	return family, nil
+}
+
+func (r *BoltUserRepo) RevokeRefreshFamily(ctx context.Context, familyID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		var family RefreshFamily
+		found, err := getJSON(tx.Bucket(refreshFamiliesBucket), familyID, &family)
+		if err != nil || !found {
+			return err
+		}
+		family.Revoked = true
+		return putJSON(tx.Bucket(refreshFamiliesBucket), familyID, family)
+	})
+}
+
+func (r *BoltUserRepo) RefreshFamilyOf(ctx context.Context, tokenHash string) (RefreshFamily, error) {
+	if err := ctx.Err(); err != nil {
+		return RefreshFamily{}, err
+	}
+
+	var family RefreshFamily
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var token RefreshTokenRecord
+		found, err := getJSON(tx.Bucket(refreshTokensBucket), tokenHash, &token)
+		if err != nil || !found {
+			return err
+		}
+		_, err = getJSON(tx.Bucket(refreshFamiliesBucket), token.FamilyID, &family)
+		return err
+	})
+	if err != nil {
+		return RefreshFamily{}, err
+	}
+	return family, nil
+}
+
+// PurgeExpired walks the revocation and refresh buckets; bolt has no index on
+// expiry. Keys are collected first because a bucket must not change under
+// ForEach
+func (r *BoltUserRepo) PurgeExpired(ctx context.Context, now time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		buckets := []struct {
+			name    []byte
+			expires func(v []byte) (time.Time, error)
+		}{
+			{revokedTokensBucket, func(v []byte) (time.Time, error) {
+				var expiresAt time.Time
+				err := json.Unmarshal(v, &expiresAt)
+				return expiresAt, err
+			}},
+			{refreshTokensBucket, func(v []byte) (time.Time, error) {
+				var token RefreshTokenRecord
+				err := json.Unmarshal(v, &token)
+				return token.ExpiresAt, err
+			}},
+			{refreshFamiliesBucket, func(v []byte) (time.Time, error) {
+				var family RefreshFamily
+				err := json.Unmarshal(v, &family)
+				return family.ExpiresAt, err
+			}},
+		}
+
+		for _, b := range buckets {
+			bucket := tx.Bucket(b.name)
+			var expired [][]byte
+			err := bucket.ForEach(func(k, v []byte) error {
+				expiresAt, err := b.expires(v)
+				if err != nil {
+					return fmt.Errorf("decode %s %s: %w", b.name, k, err)
+				}
+				if !now.Before(expiresAt) {
+					expired = append(expired, k)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, k := range expired {
+				if err := bucket.Delete(k); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func getSessionVersion(tx *bolt.Tx, username string) (uint64, error) {
+	data := tx.Bucket(sessionVersionsBucket).Get([]byte(username))
+	if data == nil {
+		return 0, nil
+	}
+	if len(data) != 8 {
+		return 0, fmt.Errorf("corrupt session version of %s", username)
+	}
+	return binary.BigEndian.Uint64(data), nil
+}
+
+func getJSON(bucket *bolt.Bucket, key string, v any) (bool, error) {
+	data := bucket.Get([]byte(key))
+	if data == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+func putJSON(bucket *bolt.Bucket, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(key), data)
+}
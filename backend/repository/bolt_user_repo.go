@@ -0,0 +1,357 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	usersBucket         = []byte("users")
+	recoveryCodesBucket = []byte("recovery_codes") // username -> JSON []RecoveryCode
+)
+
+// BoltUserRepo is a durable UserRepo in a single bbolt file. Users are stored
+// as JSON under their username, session state in buckets of its own. bbolt
+// locks the file, so only one process can use it. bbolt transactions cannot be
+// cancelled, so methods check the context before opening one
+type BoltUserRepo struct {
+	db *bolt.DB
+}
+
+// OpenBoltUserRepo opens or creates the database at path and brings its
+// schema up to date. Only one process can hold the file open
+func OpenBoltUserRepo(path string) (*BoltUserRepo, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("create database directory: %w", err)
+		}
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open user database %s: %w", path, err)
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltUserRepo{db: db}, nil
+}
+
+// Close releases the database file
+func (r *BoltUserRepo) Close() error {
+	return r.db.Close()
+}
+
+func (r *BoltUserRepo) CreateUser(ctx context.Context, username, salt, commitment string) (User, error) {
+	if err := ctx.Err(); err != nil {
+		return User{}, err
+	}
+	if err := validateCredential(salt, commitment); err != nil {
+		return User{}, err
+	}
+
+	now := timestamp()
+	user := User{
+		Username:   username,
+		Salt:       salt,
+		Commitment: commitment,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		users := tx.Bucket(usersBucket)
+		if users.Get([]byte(username)) != nil {
+			return ErrUserExists
+		}
+
+		data, err := json.Marshal(user)
+		if err != nil {
+			return err
+		}
+		return users.Put([]byte(username), data)
+	})
+	if err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+func (r *BoltUserRepo) GetUser(ctx context.Context, username string) (User, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return User{}, false, err
+	}
+
+	var user User
+	var found bool
+
+	err := r.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(usersBucket).Get([]byte(username))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &user)
+	})
+	if err != nil {
+		return User{}, false, fmt.Errorf("read user %s: %w", username, err)
+	}
+	return user, found, nil
+}
+
+func (r *BoltUserRepo) UserExists(ctx context.Context, username string) (bool, error) {
+	_, exists, err := r.GetUser(ctx, username)
+	return exists, err
+}
+
+func (r *BoltUserRepo) UpdateCredential(ctx context.Context, username, salt, commitment string) (User, error) {
+	if err := validateCredential(salt, commitment); err != nil {
+		return User{}, err
+	}
+
+	var user User
+	err := r.update(ctx, username, func(u *User) error {
+		u.Salt = salt
+		u.Commitment = commitment
+		u.UpdatedAt = timestamp()
+		user = *u
+		return nil
+	})
+	return user, err
+}
+
+func (r *BoltUserRepo) RotateCredential(ctx context.Context, username, oldCommitment, salt, commitment string) (User, error) {
+	if err := validateCredential(salt, commitment); err != nil {
+		return User{}, err
+	}
+
+	var user User
+	err := r.update(ctx, username, func(u *User) error {
+		if u.Commitment != oldCommitment {
+			return ErrCredentialChanged
+		}
+		u.Salt = salt
+		u.Commitment = commitment
+		u.UpdatedAt = timestamp()
+		user = *u
+		return nil
+	})
+	return user, err
+}
+
+func (r *BoltUserRepo) DeleteUser(ctx context.Context, username string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		users := tx.Bucket(usersBucket)
+		if users.Get([]byte(username)) == nil {
+			return ErrUserNotFound
+		}
+		if err := tx.Bucket(recoveryCodesBucket).Delete([]byte(username)); err != nil {
+			return err
+		}
+		return users.Delete([]byte(username))
+	})
+}
+
+// ListUsers walks the bucket in key order, which is username order
+func (r *BoltUserRepo) ListUsers(ctx context.Context, opts ListOptions) (UserPage, error) {
+	if err := ctx.Err(); err != nil {
+		return UserPage{}, err
+	}
+
+	limit := opts.limit()
+	var page UserPage
+
+	err := r.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(usersBucket).Cursor()
+		prefix := []byte(opts.Prefix)
+
+		start := prefix
+		if opts.After > opts.Prefix {
+			start = []byte(opts.After)
+		}
+		for k, v := cursor.Seek(start); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+			if string(k) <= opts.After {
+				continue
+			}
+			if len(page.Users) == limit {
+				page.NextCursor = page.Users[limit-1].Username
+				return nil
+			}
+
+			var user User
+			if err := json.Unmarshal(v, &user); err != nil {
+				return fmt.Errorf("decode user %s: %w", k, err)
+			}
+			page.Users = append(page.Users, user)
+		}
+		return nil
+	})
+	if err != nil {
+		return UserPage{}, err
+	}
+	return page, nil
+}
+
+func (r *BoltUserRepo) SetDisabled(ctx context.Context, username string, disabled bool) error {
+	return r.update(ctx, username, func(u *User) error {
+		u.Disabled = disabled
+		u.UpdatedAt = timestamp()
+		return nil
+	})
+}
+
+func (r *BoltUserRepo) RecordLogin(ctx context.Context, username string, at time.Time) error {
+	return r.update(ctx, username, func(u *User) error {
+		u.LastLoginAt = at.UTC()
+		return nil
+	})
+}
+
+func (r *BoltUserRepo) SetRecoveryCodes(ctx context.Context, username string, codes []RecoveryCode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := validateRecoveryCodes(codes); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(usersBucket).Get([]byte(username)) == nil {
+			return ErrUserNotFound
+		}
+		return putRecoveryCodes(tx, username, codes)
+	})
+}
+
+func (r *BoltUserRepo) RecoveryCodes(ctx context.Context, username string) ([]RecoveryCode, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var codes []RecoveryCode
+	err := r.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(usersBucket).Get([]byte(username)) == nil {
+			return ErrUserNotFound
+		}
+		var err error
+		codes, err = getRecoveryCodes(tx, username)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RecoverCredential burns the code and updates the user in one transaction
+func (r *BoltUserRepo) RecoverCredential(ctx context.Context, username, codeCommitment, salt, commitment string) (User, error) {
+	if err := ctx.Err(); err != nil {
+		return User{}, err
+	}
+	if err := validateCredential(salt, commitment); err != nil {
+		return User{}, err
+	}
+
+	var user User
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		return updateUser(tx, username, func(u *User) error {
+			codes, err := getRecoveryCodes(tx, username)
+			if err != nil {
+				return err
+			}
+			i := slices.IndexFunc(codes, func(code RecoveryCode) bool {
+				return code.Commitment == codeCommitment && !code.Used()
+			})
+			if i < 0 {
+				return ErrRecoveryCodeInvalid
+			}
+
+			now := timestamp()
+			codes[i].UsedAt = now
+			if err := putRecoveryCodes(tx, username, codes); err != nil {
+				return err
+			}
+
+			u.Salt = salt
+			u.Commitment = commitment
+			u.UpdatedAt = now
+			user = *u
+			return nil
+		})
+	})
+	return user, err
+}
+
+// update applies fn to a stored user inside one write transaction, which
+// rolls back if fn fails
+func (r *BoltUserRepo) update(ctx context.Context, username string, fn func(u *User) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		return updateUser(tx, username, fn)
+	})
+}
+
+func updateUser(tx *bolt.Tx, username string, fn func(u *User) error) error {
+	users := tx.Bucket(usersBucket)
+	data := users.Get([]byte(username))
+	if data == nil {
+		return ErrUserNotFound
+	}
+
+	var user User
+	if err := json.Unmarshal(data, &user); err != nil {
+		return err
+	}
+	if err := fn(&user); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	return users.Put([]byte(username), data)
+}
+
+func getRecoveryCodes(tx *bolt.Tx, username string) ([]RecoveryCode, error) {
+	data := tx.Bucket(recoveryCodesBucket).Get([]byte(username))
+	if data == nil {
+		return nil, nil
+	}
+
+	var codes []RecoveryCode
+	if err := json.Unmarshal(data, &codes); err != nil {
+		return nil, fmt.Errorf("decode recovery codes of %s: %w", username, err)
+	}
+	return codes, nil
+}
+
+func putRecoveryCodes(tx *bolt.Tx, username string, codes []RecoveryCode) error {
+	bucket := tx.Bucket(recoveryCodesBucket)
+	if len(codes) == 0 {
+		return bucket.Delete([]byte(username))
+	}
+
+	data, err := json.Marshal(codes)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(username), data)
+}
@@ -0,0 +1,26 @@
+package repository_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/threehook/zkp-auth/backend/repository"
+	"github.com/threehook/zkp-auth/backend/repository/repotest"
+)
+
+func TestBoltUserRepo(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.UserRepo { return openBoltUserRepo(t) })
+}
+
+func TestBoltSessionRepo(t *testing.T) {
+	repotest.RunSessionRepo(t, func(t *testing.T) repository.SessionRepo { return openBoltUserRepo(t) })
+}
+
+func openBoltUserRepo(t *testing.T) *repository.BoltUserRepo {
+	repo, err := repository.OpenBoltUserRepo(filepath.Join(t.TempDir(), "users.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+func (us *MemoryUserRepo) SessionVersion(ctx context.Context, username string) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+	return us.sessionVersions[username], nil
+}
+
+func (us *MemoryUserRepo) BumpSessionVersion(ctx context.Context, username string) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	us.sessionVersions[username]++
+	return us.sessionVersions[username], nil
+}
+
+func (us *MemoryUserRepo) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	if time.Now().Before(expiresAt) {
+		us.revokedTokens[tokenID] = expiresAt
+	}
+	return nil
+}
+
+func (us *MemoryUserRepo) TokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+	expiresAt, exists := us.revokedTokens[tokenID]
+	return exists && time.Now().Before(expiresAt), nil
+}
+
+func (us *MemoryUserRepo) CreateRefreshFamily(ctx context.Context, family RefreshFamily, token RefreshTokenRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	us.refreshFamilies[family.ID] = family
+	us.refreshTokens[token.Hash] = token
+	return nil
+}
+
+func (us *MemoryUserRepo) AddRefreshToken(ctx context.Context, token RefreshTokenRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	family, exists := us.refreshFamilies[token.FamilyID]
+	if !exists || family.Revoked {
+		return ErrRefreshTokenInvalid
+	}
+	family.ExpiresAt = token.ExpiresAt
+	us.refreshFamilies[family.ID] = family
+	us.refreshTokens[token.Hash] = token
+	return nil
+}
+
+func (us *MemoryUserRepo) UseRefreshToken(ctx context.Context, tokenHash string) (RefreshFamily, error) {
+	if err := ctx.Err(); err != nil {
+		return RefreshFamily{}, err
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	token, exists := us.refreshTokens[tokenHash]
+	if !exists || !time.Now().Before(token.ExpiresAt) {
+		return RefreshFamily{}, ErrRefreshTokenInvalid
+	}
+	family, exists := us.refreshFamilies[token.FamilyID]
+	if !exists || family.Revoked {
+		return RefreshFamily{}, ErrRefreshTokenInvalid
+	}
+
+	if token.Rotated {
+		family.Revoked = true
+		us.refreshFamilies[family.ID] = family
+		return family, ErrRefreshTokenReused
+	}
+	token.Rotated = true
+	us.refreshTokens[tokenHash] = token
+	return family, nil
+}
+
+func (us *MemoryUserRepo) RevokeRefreshFamily(ctx context.Context, familyID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	if family, exists := us.refreshFamilies[familyID]; exists {
+		family.Revoked = true
+		us.refreshFamilies[familyID] = family
+	}
+	return nil
+}
+
+func (us *MemoryUserRepo) RefreshFamilyOf(ctx context.Context, tokenHash string) (RefreshFamily, error) {
+	if err := ctx.Err(); err != nil {
+		return RefreshFamily{}, err
+	}
+
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+	token, exists := us.refreshTokens[tokenHash]
+	if !exists {
+		return RefreshFamily{}, nil
+	}
+	return us.refreshFamilies[token.FamilyID], nil
+}
+
+func (us *MemoryUserRepo) PurgeExpired(ctx context.Context, now time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	for tokenID, expiresAt := range us.revokedTokens {
+		if !now.Before(expiresAt) {
+			delete(us.revokedTokens, tokenID)
+		}
+	}
+	for hash, token := range us.refreshTokens {
+		if !now.Before(token.ExpiresAt) {
+			delete(us.refreshTokens, hash)
+		}
+	}
+	for id, family := range us.refreshFamilies {
+		if !now.Before(family.ExpiresAt) {
+			delete(us.refreshFamilies, id)
+		}
+	}
+	return nil
+}
@@ -1,23 +1,48 @@
 package repository
 
 import (
-	"math/rand"
-	"strconv"
+	"context"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/threehook/zkp-auth/backend/credential"
 )
 
+// MemoryUserRepo keeps users, and the session state of SessionRepo, in
+// memory. Everything is lost on restart, so it only suits a single instance
 type MemoryUserRepo struct {
-	mu    sync.RWMutex
-	users map[string]User
+	mu            sync.RWMutex
+	users         map[string]User
+	recoveryCodes map[string][]RecoveryCode
+
+	sessionVersions map[string]uint64
+	revokedTokens   map[string]time.Time // token ID -> token expiry
+	refreshFamilies map[string]RefreshFamily
+	refreshTokens   map[string]RefreshTokenRecord // keyed by token hash
 }
 
-func NewMemoryUserRepo() UserRepo {
+func NewMemoryUserRepo() *MemoryUserRepo {
 	return &MemoryUserRepo{
-		users: make(map[string]User),
+		users:           make(map[string]User),
+		recoveryCodes:   make(map[string][]RecoveryCode),
+		sessionVersions: make(map[string]uint64),
+		revokedTokens:   make(map[string]time.Time),
+		refreshFamilies: make(map[string]RefreshFamily),
+		refreshTokens:   make(map[string]RefreshTokenRecord),
 	}
 }
 
-func (us *MemoryUserRepo) CreateUser(username, password string) (User, error) {
+func (us *MemoryUserRepo) CreateUser(ctx context.Context, username, salt, commitment string) (User, error) {
+	if err := ctx.Err(); err != nil {
+		return User{}, err
+	}
+	if err := validateCredential(salt, commitment); err != nil {
+		return User{}, err
+	}
+
 	us.mu.Lock()
 	defer us.mu.Unlock()
 
@@ -25,51 +50,228 @@ func (us *MemoryUserRepo) CreateUser(username, password string) (User, error) {
 		return User{}, ErrUserExists
 	}
 
-	salt := generateRandomSalt()
-	passwordHash := simpleHash(password)
-
+	now := timestamp()
 	user := User{
-		Username:     username,
-		Salt:         salt,
-		PasswordHash: strconv.Itoa(passwordHash),
+		Username:   username,
+		Salt:       salt,
+		Commitment: commitment,
+		CreatedAt:  now,
+		UpdatedAt:  now,
 	}
 
 	us.users[username] = user
 	return user, nil
 }
 
-func (us *MemoryUserRepo) GetUser(username string) (User, bool) {
+func (us *MemoryUserRepo) GetUser(ctx context.Context, username string) (User, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return User{}, false, err
+	}
+
 	us.mu.RLock()
 	defer us.mu.RUnlock()
 
 	user, exists := us.users[username]
-	return user, exists
+	return user, exists, nil
+}
+
+func (us *MemoryUserRepo) UserExists(ctx context.Context, username string) (bool, error) {
+	_, exists, err := us.GetUser(ctx, username)
+	return exists, err
+}
+
+func (us *MemoryUserRepo) UpdateCredential(ctx context.Context, username, salt, commitment string) (User, error) {
+	if err := validateCredential(salt, commitment); err != nil {
+		return User{}, err
+	}
+
+	var user User
+	err := us.update(ctx, username, func(u *User) error {
+		u.Salt = salt
+		u.Commitment = commitment
+		u.UpdatedAt = timestamp()
+		user = *u
+		return nil
+	})
+	return user, err
+}
+
+func (us *MemoryUserRepo) RotateCredential(ctx context.Context, username, oldCommitment, salt, commitment string) (User, error) {
+	if err := validateCredential(salt, commitment); err != nil {
+		return User{}, err
+	}
+
+	var user User
+	err := us.update(ctx, username, func(u *User) error {
+		if u.Commitment != oldCommitment {
+			return ErrCredentialChanged
+		}
+		u.Salt = salt
+		u.Commitment = commitment
+		u.UpdatedAt = timestamp()
+		user = *u
+		return nil
+	})
+	return user, err
+}
+
+func (us *MemoryUserRepo) DeleteUser(ctx context.Context, username string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	if _, exists := us.users[username]; !exists {
+		return ErrUserNotFound
+	}
+	delete(us.users, username)
+	delete(us.recoveryCodes, username)
+	return nil
+}
+
+func (us *MemoryUserRepo) ListUsers(ctx context.Context, opts ListOptions) (UserPage, error) {
+	if err := ctx.Err(); err != nil {
+		return UserPage{}, err
+	}
+
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+
+	usernames := make([]string, 0, len(us.users))
+	for username := range us.users {
+		if strings.HasPrefix(username, opts.Prefix) && username > opts.After {
+			usernames = append(usernames, username)
+		}
+	}
+	sort.Strings(usernames)
+
+	limit := opts.limit()
+	var page UserPage
+	for _, username := range usernames {
+		if len(page.Users) == limit {
+			page.NextCursor = page.Users[limit-1].Username
+			break
+		}
+		page.Users = append(page.Users, us.users[username])
+	}
+	return page, nil
+}
+
+func (us *MemoryUserRepo) SetDisabled(ctx context.Context, username string, disabled bool) error {
+	return us.update(ctx, username, func(u *User) error {
+		u.Disabled = disabled
+		u.UpdatedAt = timestamp()
+		return nil
+	})
 }
 
-func (us *MemoryUserRepo) UserExists(username string) bool {
+func (us *MemoryUserRepo) RecordLogin(ctx context.Context, username string, at time.Time) error {
+	return us.update(ctx, username, func(u *User) error {
+		u.LastLoginAt = at.UTC()
+		return nil
+	})
+}
+
+func (us *MemoryUserRepo) SetRecoveryCodes(ctx context.Context, username string, codes []RecoveryCode) error {
+	if err := validateRecoveryCodes(codes); err != nil {
+		return err
+	}
+
+	return us.update(ctx, username, func(u *User) error {
+		us.recoveryCodes[username] = append([]RecoveryCode(nil), codes...)
+		return nil
+	})
+}
+
+func (us *MemoryUserRepo) RecoveryCodes(ctx context.Context, username string) ([]RecoveryCode, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	us.mu.RLock()
 	defer us.mu.RUnlock()
 
-	_, exists := us.users[username]
-	return exists
+	if _, exists := us.users[username]; !exists {
+		return nil, ErrUserNotFound
+	}
+	return append([]RecoveryCode(nil), us.recoveryCodes[username]...), nil
 }
 
-// Helper functions remain the same
-func simpleHash(password string) int {
-	hash := 0
-	for i := 0; i < len(password); i++ {
-		hash = ((hash << 5) - hash) + int(password[i])
-		hash |= 0
+func (us *MemoryUserRepo) RecoverCredential(ctx context.Context, username, codeCommitment, salt, commitment string) (User, error) {
+	if err := validateCredential(salt, commitment); err != nil {
+		return User{}, err
 	}
-	return hash
+
+	var user User
+	err := us.update(ctx, username, func(u *User) error {
+		codes := us.recoveryCodes[username]
+		i := slices.IndexFunc(codes, func(code RecoveryCode) bool {
+			return code.Commitment == codeCommitment && !code.Used()
+		})
+		if i < 0 {
+			return ErrRecoveryCodeInvalid
+		}
+
+		now := timestamp()
+		codes = slices.Clone(codes)
+		codes[i].UsedAt = now
+		us.recoveryCodes[username] = codes
+
+		u.Salt = salt
+		u.Commitment = commitment
+		u.UpdatedAt = now
+		user = *u
+		return nil
+	})
+	return user, err
 }
 
-func generateRandomSalt() string {
-	return strconv.Itoa(rand.Intn(1000000) + 100000)
+// update applies fn to a copy of the user and stores it if fn succeeds. fn
+// runs under the write lock, so it may also change recoveryCodes
+func (us *MemoryUserRepo) update(ctx context.Context, username string, fn func(u *User) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	user, exists := us.users[username]
+	if !exists {
+		return ErrUserNotFound
+	}
+	if err := fn(&user); err != nil {
+		return err
+	}
+	us.users[username] = user
+	return nil
+}
+
+// validateCredential rejects salts and commitments that are not field elements
+// before any implementation stores them
+func validateCredential(salt, commitment string) error {
+	if _, err := credential.ParseFieldElement(salt); err != nil {
+		return &UserError{Message: "invalid salt: " + err.Error()}
+	}
+	if _, err := credential.ParseFieldElement(commitment); err != nil {
+		return &UserError{Message: "invalid commitment: " + err.Error()}
+	}
+	return nil
+}
+
+// timestamp is the current time at the microsecond precision every store keeps
+func timestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
 }
 
 // Errors
-var ErrUserExists = &UserError{Message: "user already exists"}
+var (
+	ErrUserExists        = &UserError{Message: "user already exists"}
+	ErrUserNotFound      = &UserError{Message: "user not found"}
+	ErrCredentialChanged = &UserError{Message: "credential was changed concurrently"}
+)
 
 type UserError struct {
 	Message string
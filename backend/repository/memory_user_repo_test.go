@@ -0,0 +1,20 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/threehook/zkp-auth/backend/repository"
+	"github.com/threehook/zkp-auth/backend/repository/repotest"
+)
+
+func TestMemoryUserRepo(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.UserRepo {
+		return repository.NewMemoryUserRepo()
+	})
+}
+
+func TestMemorySessionRepo(t *testing.T) {
+	repotest.RunSessionRepo(t, func(t *testing.T) repository.SessionRepo {
+		return repository.NewMemoryUserRepo()
+	})
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	metaBucket       = []byte("meta")
+	schemaVersionKey = []byte("schema_version")
+)
+
+// migration upgrades the bolt schema by one version. Migrations are append-only:
+// never edit one that has shipped, add a new one instead
+type migration struct {
+	version     int
+	description string
+	apply       func(tx *bolt.Tx) error
+}
+
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create users bucket",
+		apply: func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(usersBucket)
+			return err
+		},
+	},
+	{
+		version:     2,
+		description: "backfill user timestamps",
+		apply: func(tx *bolt.Tx) error {
+			// Users created before timestamps existed get the migration time.
+			// The bucket must not change under ForEach, so collect first
+			now := timestamp()
+			users := tx.Bucket(usersBucket)
+			backfilled := make(map[string][]byte)
+			err := users.ForEach(func(k, v []byte) error {
+				var user User
+				if err := json.Unmarshal(v, &user); err != nil {
+					return fmt.Errorf("decode user %s: %w", k, err)
+				}
+				if !user.CreatedAt.IsZero() {
+					return nil
+				}
+				user.CreatedAt = now
+				user.UpdatedAt = now
+
+				data, err := json.Marshal(user)
+				if err != nil {
+					return err
+				}
+				backfilled[string(k)] = data
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for username, data := range backfilled {
+				if err := users.Put([]byte(username), data); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		version:     3,
+		description: "create recovery codes bucket",
+		apply: func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(recoveryCodesBucket)
+			return err
+		},
+	},
+	{
+		version:     4,
+		description: "create session buckets",
+		apply: func(tx *bolt.Tx) error {
+			for _, name := range [][]byte{sessionVersionsBucket, revokedTokensBucket, refreshFamiliesBucket, refreshTokensBucket} {
+				if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// migrate applies every migration newer than the stored schema version, each
+// in its own transaction together with the version bump
+func migrate(db *bolt.DB) error {
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	latest := migrations[len(migrations)-1].version
+	if current > latest {
+		return fmt.Errorf("user database schema v%d is newer than this build (v%d)", current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		err := db.Update(func(tx *bolt.Tx) error {
+			if err := m.apply(tx); err != nil {
+				return err
+			}
+			meta, err := tx.CreateBucketIfNotExists(metaBucket)
+			if err != nil {
+				return err
+			}
+			var buf [8]byte
+			binary.BigEndian.PutUint64(buf[:], uint64(m.version))
+			return meta.Put(schemaVersionKey, buf[:])
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.description, err)
+		}
+		log.Printf("🗄️ Applied user database migration %d: %s", m.version, m.description)
+	}
+	return nil
+}
+
+func schemaVersion(db *bolt.DB) (int, error) {
+	var version int
+	err := db.View(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		if meta == nil {
+			return nil
+		}
+		data := meta.Get(schemaVersionKey)
+		if len(data) != 8 {
+			return fmt.Errorf("corrupt schema version")
+		}
+		version = int(binary.BigEndian.Uint64(data))
+		return nil
+	})
+	return version, err
+}
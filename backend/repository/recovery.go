@@ -0,0 +1,53 @@
+package repository
+
+import "time"
+
+// MaxRecoveryCodes bounds how many recovery codes a user can hold
+const MaxRecoveryCodes = 16
+
+// RecoveryCode is a one-time code that lets a user who forgot their password
+// set a new credential. As with the password, only Poseidon(code, salt) is
+// stored; the user proves knowledge of the code with the password circuit
+type RecoveryCode struct {
+	Salt       string    `json:"salt"`
+	Commitment string    `json:"commitment"`
+	UsedAt     time.Time `json:"usedAt,omitzero"` // zero until the code is burned
+}
+
+// Used reports whether the code has been burned
+func (c RecoveryCode) Used() bool {
+	return !c.UsedAt.IsZero()
+}
+
+var ErrRecoveryCodeInvalid = &UserError{Message: "recovery code is unknown or already used"}
+
+// validateRecoveryCodes rejects code sets no implementation should store:
+// too many codes, values that are not field elements, or duplicates
+func validateRecoveryCodes(codes []RecoveryCode) error {
+	if len(codes) > MaxRecoveryCodes {
+		return &UserError{Message: "too many recovery codes"}
+	}
+
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		if err := validateCredential(code.Salt, code.Commitment); err != nil {
+			return &UserError{Message: "recovery code: " + err.Error()}
+		}
+		if seen[code.Commitment] {
+			return &UserError{Message: "duplicate recovery code"}
+		}
+		seen[code.Commitment] = true
+	}
+	return nil
+}
+
+// UnusedRecoveryCodes counts the codes that can still be used
+func UnusedRecoveryCodes(codes []RecoveryCode) int {
+	n := 0
+	for _, code := range codes {
+		if !code.Used() {
+			n++
+		}
+	}
+	return n
+}
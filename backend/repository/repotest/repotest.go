@@ -0,0 +1,616 @@
+// Package repotest holds the conformance suites every repository.UserRepo and
+// repository.SessionRepo implementation must pass. An implementation's tests
+// run them against fresh, empty instances:
+//
+//	func TestBoltUserRepo(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) repository.UserRepo {
+//			repo, err := repository.OpenBoltUserRepo(filepath.Join(t.TempDir(), "users.db"))
+//			if err != nil {
+//				t.Fatal(err)
+//			}
+//			t.Cleanup(func() { repo.Close() })
+//			return repo
+//		})
+//	}
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/threehook/zkp-auth/backend/repository"
+)
+
+// Valid field elements for credentials; the repository never computes them
+const (
+	salt       = "123456"
+	commitment = "7853200120776062878684798364095072458815029376092732009249414926327459813530"
+)
+
+// Run runs the conformance suite. newRepo must return an empty
+// repository each time it is called
+func Run(t *testing.T, newRepo func(t *testing.T) repository.UserRepo) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateUser(t.Context(), "alice", salt, commitment)
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if created.Username != "alice" || created.Salt != salt || created.Commitment != commitment {
+			t.Fatalf("CreateUser returned %+v", created)
+		}
+		if created.CreatedAt.IsZero() || !created.UpdatedAt.Equal(created.CreatedAt) {
+			t.Fatalf("CreateUser timestamps: created %v, updated %v", created.CreatedAt, created.UpdatedAt)
+		}
+		if created.Disabled || !created.LastLoginAt.IsZero() {
+			t.Fatalf("new user is disabled or has logged in: %+v", created)
+		}
+
+		got, exists := getUser(t, repo, "alice")
+		if !exists {
+			t.Fatal("GetUser did not find the created user")
+		}
+		assertSameUser(t, got, created)
+		if !userExists(t, repo, "alice") {
+			t.Fatal("UserExists is false for the created user")
+		}
+	})
+
+	t.Run("MissingUser", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, exists := getUser(t, repo, "nobody"); exists {
+			t.Fatal("GetUser found a user that was never created")
+		}
+		if userExists(t, repo, "nobody") {
+			t.Fatal("UserExists is true for a user that was never created")
+		}
+	})
+
+	t.Run("DuplicateUsername", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.CreateUser(t.Context(), "alice", salt, commitment); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		_, err := repo.CreateUser(t.Context(), "alice", "654321", commitment)
+		if !errors.Is(err, repository.ErrUserExists) {
+			t.Fatalf("second CreateUser returned %v, want ErrUserExists", err)
+		}
+
+		got, _ := getUser(t, repo, "alice")
+		if got.Salt != salt {
+			t.Fatalf("duplicate CreateUser overwrote the salt with %s", got.Salt)
+		}
+	})
+
+	t.Run("InvalidCredential", func(t *testing.T) {
+		repo := newRepo(t)
+
+		cases := []struct{ salt, commitment string }{
+			{"", commitment},
+			{"not-a-number", commitment},
+			{salt, ""},
+			{salt, "-1"},
+			// The BN254 scalar field modulus itself is out of range
+			{salt, "21888242871839275222246405745257275088548364400416034343698204186575808495617"},
+		}
+		for _, c := range cases {
+			_, err := repo.CreateUser(t.Context(), "alice", c.salt, c.commitment)
+			var userErr *repository.UserError
+			if !errors.As(err, &userErr) {
+				t.Errorf("CreateUser(%q, %q) returned %v, want a *UserError", c.salt, c.commitment, err)
+			}
+		}
+		if userExists(t, repo, "alice") {
+			t.Fatal("a rejected CreateUser stored the user")
+		}
+	})
+
+	t.Run("UsersAreIndependent", func(t *testing.T) {
+		repo := newRepo(t)
+
+		for i := 0; i < 10; i++ {
+			username := fmt.Sprintf("user%d", i)
+			if _, err := repo.CreateUser(t.Context(), username, fmt.Sprint(100000+i), commitment); err != nil {
+				t.Fatalf("CreateUser(%s): %v", username, err)
+			}
+		}
+		for i := 0; i < 10; i++ {
+			username := fmt.Sprintf("user%d", i)
+			got, exists := getUser(t, repo, username)
+			if !exists || got.Salt != fmt.Sprint(100000+i) {
+				t.Fatalf("GetUser(%s) returned %+v, %v", username, got, exists)
+			}
+		}
+	})
+
+	t.Run("ConcurrentCreate", func(t *testing.T) {
+		repo := newRepo(t)
+
+		const attempts = 16
+		var wg sync.WaitGroup
+		errs := make(chan error, attempts)
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := repo.CreateUser(t.Context(), "alice", salt, commitment)
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		created := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				created++
+			case !errors.Is(err, repository.ErrUserExists):
+				t.Errorf("concurrent CreateUser returned %v", err)
+			}
+		}
+		if created != 1 {
+			t.Fatalf("%d concurrent CreateUser calls succeeded, want exactly 1", created)
+		}
+	})
+
+	t.Run("UpdateCredential", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateUser(t.Context(), "alice", salt, commitment)
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+
+		const newCommitment = "42"
+		updated, err := repo.UpdateCredential(t.Context(), "alice", "654321", newCommitment)
+		if err != nil {
+			t.Fatalf("UpdateCredential: %v", err)
+		}
+		if updated.Salt != "654321" || updated.Commitment != newCommitment {
+			t.Fatalf("UpdateCredential returned %+v", updated)
+		}
+		if !updated.CreatedAt.Equal(created.CreatedAt) || !updated.UpdatedAt.After(created.UpdatedAt) {
+			t.Fatalf("UpdateCredential timestamps: created %v, updated %v", updated.CreatedAt, updated.UpdatedAt)
+		}
+
+		got, _ := getUser(t, repo, "alice")
+		assertSameUser(t, got, updated)
+
+		var userErr *repository.UserError
+		if _, err := repo.UpdateCredential(t.Context(), "alice", "", newCommitment); !errors.As(err, &userErr) {
+			t.Fatalf("UpdateCredential with an invalid salt returned %v, want a *UserError", err)
+		}
+		if _, err := repo.UpdateCredential(t.Context(), "nobody", salt, commitment); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("UpdateCredential of a missing user returned %v, want ErrUserNotFound", err)
+		}
+	})
+
+	t.Run("RotateCredential", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.CreateUser(t.Context(), "alice", salt, commitment); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+
+		rotated, err := repo.RotateCredential(t.Context(), "alice", commitment, "654321", "42")
+		if err != nil {
+			t.Fatalf("RotateCredential: %v", err)
+		}
+		if rotated.Salt != "654321" || rotated.Commitment != "42" {
+			t.Fatalf("RotateCredential returned %+v", rotated)
+		}
+
+		// The old commitment no longer matches, so a second rotation from it fails
+		_, err = repo.RotateCredential(t.Context(), "alice", commitment, "777777", "43")
+		if !errors.Is(err, repository.ErrCredentialChanged) {
+			t.Fatalf("stale RotateCredential returned %v, want ErrCredentialChanged", err)
+		}
+		got, _ := getUser(t, repo, "alice")
+		assertSameUser(t, got, rotated)
+
+		if _, err := repo.RotateCredential(t.Context(), "nobody", commitment, salt, commitment); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("RotateCredential of a missing user returned %v, want ErrUserNotFound", err)
+		}
+	})
+
+	t.Run("ConcurrentRotate", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.CreateUser(t.Context(), "alice", salt, commitment); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+
+		const attempts = 8
+		var wg sync.WaitGroup
+		errs := make(chan error, attempts)
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := repo.RotateCredential(t.Context(), "alice", commitment, fmt.Sprint(200000+i), fmt.Sprint(i+1))
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		rotated := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				rotated++
+			case !errors.Is(err, repository.ErrCredentialChanged):
+				t.Errorf("concurrent RotateCredential returned %v", err)
+			}
+		}
+		if rotated != 1 {
+			t.Fatalf("%d concurrent RotateCredential calls succeeded, want exactly 1", rotated)
+		}
+	})
+
+	t.Run("RecoveryCodes", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.CreateUser(t.Context(), "alice", salt, commitment); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if codes := recoveryCodes(t, repo, "alice"); len(codes) != 0 {
+			t.Fatalf("new user has recovery codes %+v", codes)
+		}
+
+		codes := []repository.RecoveryCode{{Salt: salt, Commitment: "11"}, {Salt: salt, Commitment: "12"}}
+		if err := repo.SetRecoveryCodes(t.Context(), "alice", codes); err != nil {
+			t.Fatalf("SetRecoveryCodes: %v", err)
+		}
+		assertRecoveryCodes(t, recoveryCodes(t, repo, "alice"), map[string]bool{"11": false, "12": false})
+
+		// A new set replaces the old one entirely
+		if err := repo.SetRecoveryCodes(t.Context(), "alice", codes[1:]); err != nil {
+			t.Fatalf("SetRecoveryCodes: %v", err)
+		}
+		assertRecoveryCodes(t, recoveryCodes(t, repo, "alice"), map[string]bool{"12": false})
+
+		var userErr *repository.UserError
+		invalid := [][]repository.RecoveryCode{
+			{{Salt: salt, Commitment: "not-a-number"}},
+			{{Salt: salt, Commitment: "13"}, {Salt: "654321", Commitment: "13"}},
+			make([]repository.RecoveryCode, repository.MaxRecoveryCodes+1),
+		}
+		for _, codes := range invalid {
+			if err := repo.SetRecoveryCodes(t.Context(), "alice", codes); !errors.As(err, &userErr) {
+				t.Errorf("SetRecoveryCodes(%+v) returned %v, want a *UserError", codes, err)
+			}
+		}
+		assertRecoveryCodes(t, recoveryCodes(t, repo, "alice"), map[string]bool{"12": false})
+
+		if err := repo.SetRecoveryCodes(t.Context(), "nobody", codes); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("SetRecoveryCodes of a missing user returned %v, want ErrUserNotFound", err)
+		}
+		if _, err := repo.RecoveryCodes(t.Context(), "nobody"); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("RecoveryCodes of a missing user returned %v, want ErrUserNotFound", err)
+		}
+
+		// Deleting the user deletes the codes; a new user of the same name starts without any
+		if err := repo.DeleteUser(t.Context(), "alice"); err != nil {
+			t.Fatalf("DeleteUser: %v", err)
+		}
+		if _, err := repo.CreateUser(t.Context(), "alice", salt, commitment); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if codes := recoveryCodes(t, repo, "alice"); len(codes) != 0 {
+			t.Fatalf("recreated user inherited recovery codes %+v", codes)
+		}
+	})
+
+	t.Run("RecoverCredential", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateUser(t.Context(), "alice", salt, commitment)
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		codes := []repository.RecoveryCode{{Salt: salt, Commitment: "11"}, {Salt: salt, Commitment: "12"}}
+		if err := repo.SetRecoveryCodes(t.Context(), "alice", codes); err != nil {
+			t.Fatalf("SetRecoveryCodes: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+
+		recovered, err := repo.RecoverCredential(t.Context(), "alice", "11", "654321", "42")
+		if err != nil {
+			t.Fatalf("RecoverCredential: %v", err)
+		}
+		if recovered.Salt != "654321" || recovered.Commitment != "42" || !recovered.UpdatedAt.After(created.UpdatedAt) {
+			t.Fatalf("RecoverCredential returned %+v", recovered)
+		}
+		got, _ := getUser(t, repo, "alice")
+		assertSameUser(t, got, recovered)
+		assertRecoveryCodes(t, recoveryCodes(t, repo, "alice"), map[string]bool{"11": true, "12": false})
+
+		// A burned code and an unknown one both fail without touching the credential
+		for _, code := range []string{"11", "99"} {
+			_, err := repo.RecoverCredential(t.Context(), "alice", code, "777777", "43")
+			if !errors.Is(err, repository.ErrRecoveryCodeInvalid) {
+				t.Fatalf("RecoverCredential with code %s returned %v, want ErrRecoveryCodeInvalid", code, err)
+			}
+		}
+		got, _ = getUser(t, repo, "alice")
+		assertSameUser(t, got, recovered)
+
+		var userErr *repository.UserError
+		if _, err := repo.RecoverCredential(t.Context(), "alice", "12", "", "43"); !errors.As(err, &userErr) {
+			t.Fatalf("RecoverCredential with an invalid salt returned %v, want a *UserError", err)
+		}
+		assertRecoveryCodes(t, recoveryCodes(t, repo, "alice"), map[string]bool{"11": true, "12": false})
+
+		if _, err := repo.RecoverCredential(t.Context(), "nobody", "11", salt, commitment); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("RecoverCredential of a missing user returned %v, want ErrUserNotFound", err)
+		}
+	})
+
+	t.Run("ConcurrentRecover", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.CreateUser(t.Context(), "alice", salt, commitment); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if err := repo.SetRecoveryCodes(t.Context(), "alice", []repository.RecoveryCode{{Salt: salt, Commitment: "11"}}); err != nil {
+			t.Fatalf("SetRecoveryCodes: %v", err)
+		}
+
+		const attempts = 8
+		var wg sync.WaitGroup
+		errs := make(chan error, attempts)
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := repo.RecoverCredential(t.Context(), "alice", "11", fmt.Sprint(200000+i), fmt.Sprint(i+1))
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		recovered := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				recovered++
+			case !errors.Is(err, repository.ErrRecoveryCodeInvalid):
+				t.Errorf("concurrent RecoverCredential returned %v", err)
+			}
+		}
+		if recovered != 1 {
+			t.Fatalf("%d concurrent RecoverCredential calls succeeded, want exactly 1", recovered)
+		}
+	})
+
+	t.Run("DeleteUser", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.CreateUser(t.Context(), "alice", salt, commitment); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if err := repo.DeleteUser(t.Context(), "alice"); err != nil {
+			t.Fatalf("DeleteUser: %v", err)
+		}
+		if userExists(t, repo, "alice") {
+			t.Fatal("deleted user still exists")
+		}
+		if err := repo.DeleteUser(t.Context(), "alice"); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("second DeleteUser returned %v, want ErrUserNotFound", err)
+		}
+
+		// The username is free again
+		if _, err := repo.CreateUser(t.Context(), "alice", salt, commitment); err != nil {
+			t.Fatalf("CreateUser after DeleteUser: %v", err)
+		}
+	})
+
+	t.Run("SetDisabled", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.CreateUser(t.Context(), "alice", salt, commitment); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		if err := repo.SetDisabled(t.Context(), "alice", true); err != nil {
+			t.Fatalf("SetDisabled: %v", err)
+		}
+		if got, _ := getUser(t, repo, "alice"); !got.Disabled {
+			t.Fatal("user is not disabled")
+		}
+		if err := repo.SetDisabled(t.Context(), "alice", false); err != nil {
+			t.Fatalf("SetDisabled: %v", err)
+		}
+		if got, _ := getUser(t, repo, "alice"); got.Disabled {
+			t.Fatal("user is still disabled")
+		}
+		if err := repo.SetDisabled(t.Context(), "nobody", true); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("SetDisabled of a missing user returned %v, want ErrUserNotFound", err)
+		}
+	})
+
+	t.Run("RecordLogin", func(t *testing.T) {
+		repo := newRepo(t)
+
+		created, err := repo.CreateUser(t.Context(), "alice", salt, commitment)
+		if err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		at := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+		if err := repo.RecordLogin(t.Context(), "alice", at); err != nil {
+			t.Fatalf("RecordLogin: %v", err)
+		}
+
+		got, _ := getUser(t, repo, "alice")
+		if !got.LastLoginAt.Equal(at) {
+			t.Fatalf("LastLoginAt is %v, want %v", got.LastLoginAt, at)
+		}
+		if !got.UpdatedAt.Equal(created.UpdatedAt) {
+			t.Fatal("RecordLogin changed UpdatedAt")
+		}
+		if err := repo.RecordLogin(t.Context(), "nobody", at); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("RecordLogin of a missing user returned %v, want ErrUserNotFound", err)
+		}
+	})
+
+	t.Run("ListUsers", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if page, err := repo.ListUsers(t.Context(), repository.ListOptions{}); err != nil || len(page.Users) != 0 || page.NextCursor != "" {
+			t.Fatalf("ListUsers of an empty repository returned %+v, %v", page, err)
+		}
+
+		for _, username := range []string{"carol", "alice", "bob", "alex", "albert"} {
+			if _, err := repo.CreateUser(t.Context(), username, salt, commitment); err != nil {
+				t.Fatalf("CreateUser(%s): %v", username, err)
+			}
+		}
+
+		all, err := repo.ListUsers(t.Context(), repository.ListOptions{})
+		if err != nil {
+			t.Fatalf("ListUsers: %v", err)
+		}
+		assertUsernames(t, all, []string{"albert", "alex", "alice", "bob", "carol"}, "")
+
+		first, err := repo.ListUsers(t.Context(), repository.ListOptions{Limit: 2})
+		if err != nil {
+			t.Fatalf("ListUsers: %v", err)
+		}
+		assertUsernames(t, first, []string{"albert", "alex"}, "alex")
+
+		second, err := repo.ListUsers(t.Context(), repository.ListOptions{Limit: 2, After: first.NextCursor})
+		if err != nil {
+			t.Fatalf("ListUsers: %v", err)
+		}
+		assertUsernames(t, second, []string{"alice", "bob"}, "bob")
+
+		last, err := repo.ListUsers(t.Context(), repository.ListOptions{Limit: 2, After: second.NextCursor})
+		if err != nil {
+			t.Fatalf("ListUsers: %v", err)
+		}
+		assertUsernames(t, last, []string{"carol"}, "")
+
+		prefixed, err := repo.ListUsers(t.Context(), repository.ListOptions{Prefix: "al", Limit: 2})
+		if err != nil {
+			t.Fatalf("ListUsers: %v", err)
+		}
+		assertUsernames(t, prefixed, []string{"albert", "alex"}, "alex")
+
+		prefixed, err = repo.ListUsers(t.Context(), repository.ListOptions{Prefix: "al", Limit: 2, After: prefixed.NextCursor})
+		if err != nil {
+			t.Fatalf("ListUsers: %v", err)
+		}
+		assertUsernames(t, prefixed, []string{"alice"}, "")
+
+		// Prefixes are case-sensitive, like usernames
+		upper, err := repo.ListUsers(t.Context(), repository.ListOptions{Prefix: "AL"})
+		if err != nil {
+			t.Fatalf("ListUsers: %v", err)
+		}
+		assertUsernames(t, upper, nil, "")
+	})
+
+	t.Run("CanceledContext", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.CreateUser(t.Context(), "alice", salt, commitment); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		if _, err := repo.CreateUser(ctx, "bob", salt, commitment); !errors.Is(err, context.Canceled) {
+			t.Errorf("CreateUser returned %v, want context.Canceled", err)
+		}
+		if _, _, err := repo.GetUser(ctx, "alice"); !errors.Is(err, context.Canceled) {
+			t.Errorf("GetUser returned %v, want context.Canceled", err)
+		}
+		if _, err := repo.ListUsers(ctx, repository.ListOptions{}); !errors.Is(err, context.Canceled) {
+			t.Errorf("ListUsers returned %v, want context.Canceled", err)
+		}
+		if err := repo.SetDisabled(ctx, "alice", true); !errors.Is(err, context.Canceled) {
+			t.Errorf("SetDisabled returned %v, want context.Canceled", err)
+		}
+		if userExists(t, repo, "bob") {
+			t.Fatal("CreateUser with a cancelled context stored the user")
+		}
+		if got, _ := getUser(t, repo, "alice"); got.Disabled {
+			t.Fatal("SetDisabled with a cancelled context disabled the user")
+		}
+	})
+}
+
+func assertSameUser(t *testing.T, got, want repository.User) {
+	t.Helper()
+	if got.Username != want.Username || got.Salt != want.Salt || got.Commitment != want.Commitment ||
+		got.Disabled != want.Disabled ||
+		!got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) ||
+		!got.LastLoginAt.Equal(want.LastLoginAt) {
+		t.Fatalf("got user %+v, want %+v", got, want)
+	}
+}
+
+func assertUsernames(t *testing.T, page repository.UserPage, want []string, nextCursor string) {
+	t.Helper()
+	got := make([]string, len(page.Users))
+	for i, user := range page.Users {
+		got[i] = user.Username
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) || page.NextCursor != nextCursor {
+		t.Fatalf("got page %v (next %q), want %v (next %q)", got, page.NextCursor, want, nextCursor)
+	}
+}
+
+// assertRecoveryCodes compares codes with want, which maps each commitment
+// to whether it should be burned
+func assertRecoveryCodes(t *testing.T, codes []repository.RecoveryCode, want map[string]bool) {
+	t.Helper()
+	got := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		if code.Salt != salt {
+			t.Fatalf("recovery code %s has salt %s, want %s", code.Commitment, code.Salt, salt)
+		}
+		got[code.Commitment] = code.Used()
+	}
+	if len(got) != len(codes) || fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got recovery codes %+v, want %v", codes, want)
+	}
+}
+
+func recoveryCodes(t *testing.T, repo repository.UserRepo, username string) []repository.RecoveryCode {
+	t.Helper()
+	codes, err := repo.RecoveryCodes(t.Context(), username)
+	if err != nil {
+		t.Fatalf("RecoveryCodes(%s): %v", username, err)
+	}
+	return codes
+}
+
+func getUser(t *testing.T, repo repository.UserRepo, username string) (repository.User, bool) {
+	t.Helper()
+	user, exists, err := repo.GetUser(t.Context(), username)
+	if err != nil {
+		t.Fatalf("GetUser(%s): %v", username, err)
+	}
+	return user, exists
+}
+
+func userExists(t *testing.T, repo repository.UserRepo, username string) bool {
+	t.Helper()
+	exists, err := repo.UserExists(t.Context(), username)
+	if err != nil {
+		t.Fatalf("UserExists(%s): %v", username, err)
+	}
+	return exists
+}
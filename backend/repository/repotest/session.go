@@ -0,0 +1,305 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/threehook/zkp-auth/backend/repository"
+)
+
+// RunSessionRepo runs the conformance suite for repository.SessionRepo.
+// newRepo must return an empty repository each time it is called
+func RunSessionRepo(t *testing.T, newRepo func(t *testing.T) repository.SessionRepo) {
+	t.Run("SessionVersion", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if version := sessionVersion(t, repo, "alice"); version != 0 {
+			t.Fatalf("SessionVersion of a new user = %d, want 0", version)
+		}
+		for want := uint64(1); want <= 2; want++ {
+			version, err := repo.BumpSessionVersion(t.Context(), "alice")
+			if err != nil {
+				t.Fatalf("BumpSessionVersion: %v", err)
+			}
+			if version != want {
+				t.Fatalf("BumpSessionVersion = %d, want %d", version, want)
+			}
+		}
+		if version := sessionVersion(t, repo, "alice"); version != 2 {
+			t.Fatalf("SessionVersion = %d, want 2", version)
+		}
+		if version := sessionVersion(t, repo, "bob"); version != 0 {
+			t.Fatalf("SessionVersion of another user = %d, want 0", version)
+		}
+	})
+
+	t.Run("ConcurrentBump", func(t *testing.T) {
+		repo := newRepo(t)
+
+		const bumps = 8
+		var wg sync.WaitGroup
+		errs := make(chan error, bumps)
+		for range bumps {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := repo.BumpSessionVersion(t.Context(), "alice")
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("BumpSessionVersion: %v", err)
+			}
+		}
+		if version := sessionVersion(t, repo, "alice"); version != bumps {
+			t.Fatalf("SessionVersion after %d concurrent bumps = %d", bumps, version)
+		}
+	})
+
+	t.Run("RevokeToken", func(t *testing.T) {
+		repo := newRepo(t)
+
+		expiresAt := time.Now().Add(time.Hour)
+		for range 2 {
+			if err := repo.RevokeToken(t.Context(), "jti-1", expiresAt); err != nil {
+				t.Fatalf("RevokeToken: %v", err)
+			}
+		}
+		if err := repo.RevokeToken(t.Context(), "jti-expired", time.Now().Add(-time.Minute)); err != nil {
+			t.Fatalf("RevokeToken of an expired token: %v", err)
+		}
+
+		for tokenID, want := range map[string]bool{"jti-1": true, "jti-2": false, "jti-expired": false} {
+			if revoked := tokenRevoked(t, repo, tokenID); revoked != want {
+				t.Errorf("TokenRevoked(%s) = %v, want %v", tokenID, revoked, want)
+			}
+		}
+	})
+
+	t.Run("RotateRefreshToken", func(t *testing.T) {
+		repo := newRepo(t)
+		family := createFamily(t, repo, "family-1", "token-1", time.Hour)
+
+		used, err := repo.UseRefreshToken(t.Context(), "token-1")
+		if err != nil {
+			t.Fatalf("UseRefreshToken: %v", err)
+		}
+		if used.ID != family.ID || used.Username != family.Username ||
+			used.SessionVersion != family.SessionVersion || used.Revoked {
+			t.Fatalf("UseRefreshToken returned %+v, want %+v", used, family)
+		}
+
+		next := refreshToken("token-2", family.ID, time.Hour)
+		if err := repo.AddRefreshToken(t.Context(), next); err != nil {
+			t.Fatalf("AddRefreshToken: %v", err)
+		}
+		if _, err := repo.UseRefreshToken(t.Context(), "token-2"); err != nil {
+			t.Fatalf("UseRefreshToken of the rotated-in token: %v", err)
+		}
+	})
+
+	t.Run("ReuseRevokesFamily", func(t *testing.T) {
+		repo := newRepo(t)
+		family := createFamily(t, repo, "family-1", "token-1", time.Hour)
+		if _, err := repo.UseRefreshToken(t.Context(), "token-1"); err != nil {
+			t.Fatalf("UseRefreshToken: %v", err)
+		}
+		if err := repo.AddRefreshToken(t.Context(), refreshToken("token-2", family.ID, time.Hour)); err != nil {
+			t.Fatalf("AddRefreshToken: %v", err)
+		}
+
+		reused, err := repo.UseRefreshToken(t.Context(), "token-1")
+		if !errors.Is(err, repository.ErrRefreshTokenReused) {
+			t.Fatalf("reusing a rotated token: %v, want ErrRefreshTokenReused", err)
+		}
+		if reused.ID != family.ID || reused.Username != family.Username {
+			t.Fatalf("reuse returned %+v, want family %s of %s", reused, family.ID, family.Username)
+		}
+
+		if _, err := repo.UseRefreshToken(t.Context(), "token-2"); !errors.Is(err, repository.ErrRefreshTokenInvalid) {
+			t.Fatalf("newest token after reuse: %v, want ErrRefreshTokenInvalid", err)
+		}
+		err = repo.AddRefreshToken(t.Context(), refreshToken("token-3", family.ID, time.Hour))
+		if !errors.Is(err, repository.ErrRefreshTokenInvalid) {
+			t.Fatalf("AddRefreshToken to a revoked family: %v, want ErrRefreshTokenInvalid", err)
+		}
+	})
+
+	t.Run("ConcurrentUse", func(t *testing.T) {
+		repo := newRepo(t)
+		createFamily(t, repo, "family-1", "token-1", time.Hour)
+
+		const attempts = 8
+		var wg sync.WaitGroup
+		errs := make(chan error, attempts)
+		for range attempts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := repo.UseRefreshToken(t.Context(), "token-1")
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, repository.ErrRefreshTokenReused), errors.Is(err, repository.ErrRefreshTokenInvalid):
+			default:
+				t.Fatalf("UseRefreshToken: %v", err)
+			}
+		}
+		if succeeded != 1 {
+			t.Fatalf("%d of %d concurrent uses of one token succeeded, want 1", succeeded, attempts)
+		}
+	})
+
+	t.Run("InvalidRefreshTokens", func(t *testing.T) {
+		repo := newRepo(t)
+		createFamily(t, repo, "family-1", "expired", -time.Minute)
+
+		for _, tokenHash := range []string{"expired", "unknown"} {
+			if _, err := repo.UseRefreshToken(t.Context(), tokenHash); !errors.Is(err, repository.ErrRefreshTokenInvalid) {
+				t.Errorf("UseRefreshToken(%s): %v, want ErrRefreshTokenInvalid", tokenHash, err)
+			}
+		}
+		err := repo.AddRefreshToken(t.Context(), refreshToken("orphan", "no-family", time.Hour))
+		if !errors.Is(err, repository.ErrRefreshTokenInvalid) {
+			t.Fatalf("AddRefreshToken to a missing family: %v, want ErrRefreshTokenInvalid", err)
+		}
+	})
+
+	t.Run("RevokeRefreshFamily", func(t *testing.T) {
+		repo := newRepo(t)
+		createFamily(t, repo, "family-1", "token-1", time.Hour)
+		createFamily(t, repo, "family-2", "token-2", time.Hour)
+
+		if familyID := refreshFamilyOf(t, repo, "token-1"); familyID != "family-1" {
+			t.Fatalf("RefreshFamilyOf = %q, want family-1", familyID)
+		}
+		if familyID := refreshFamilyOf(t, repo, "unknown"); familyID != "" {
+			t.Fatalf("RefreshFamilyOf an unknown token = %q, want empty", familyID)
+		}
+
+		if err := repo.RevokeRefreshFamily(t.Context(), "family-1"); err != nil {
+			t.Fatalf("RevokeRefreshFamily: %v", err)
+		}
+		if err := repo.RevokeRefreshFamily(t.Context(), "unknown"); err != nil {
+			t.Fatalf("RevokeRefreshFamily of an unknown family: %v", err)
+		}
+		if _, err := repo.UseRefreshToken(t.Context(), "token-1"); !errors.Is(err, repository.ErrRefreshTokenInvalid) {
+			t.Fatalf("token of a revoked family: %v, want ErrRefreshTokenInvalid", err)
+		}
+		if _, err := repo.UseRefreshToken(t.Context(), "token-2"); err != nil {
+			t.Fatalf("token of another family: %v", err)
+		}
+	})
+
+	t.Run("PurgeExpired", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.RevokeToken(t.Context(), "jti-short", time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("RevokeToken: %v", err)
+		}
+		if err := repo.RevokeToken(t.Context(), "jti-long", time.Now().Add(3*time.Hour)); err != nil {
+			t.Fatalf("RevokeToken: %v", err)
+		}
+		createFamily(t, repo, "family-short", "token-short", time.Hour)
+		createFamily(t, repo, "family-long", "token-long", 3*time.Hour)
+
+		// Purging as of two hours from now drops what expires within the hour
+		if err := repo.PurgeExpired(t.Context(), time.Now().Add(2*time.Hour)); err != nil {
+			t.Fatalf("PurgeExpired: %v", err)
+		}
+		if tokenRevoked(t, repo, "jti-short") {
+			t.Error("revocation expiring before the purge was kept")
+		}
+		if !tokenRevoked(t, repo, "jti-long") {
+			t.Error("revocation expiring after the purge was dropped")
+		}
+		if familyID := refreshFamilyOf(t, repo, "token-short"); familyID != "" {
+			t.Errorf("refresh token expiring before the purge was kept in %s", familyID)
+		}
+		if familyID := refreshFamilyOf(t, repo, "token-long"); familyID != "family-long" {
+			t.Errorf("refresh token expiring after the purge: family %q, want family-long", familyID)
+		}
+	})
+
+	t.Run("CanceledContext", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		if _, err := repo.SessionVersion(ctx, "alice"); err == nil {
+			t.Error("SessionVersion succeeded with a canceled context")
+		}
+		if _, err := repo.BumpSessionVersion(ctx, "alice"); err == nil {
+			t.Error("BumpSessionVersion succeeded with a canceled context")
+		}
+		if _, err := repo.TokenRevoked(ctx, "jti-1"); err == nil {
+			t.Error("TokenRevoked succeeded with a canceled context")
+		}
+		if _, err := repo.UseRefreshToken(ctx, "token-1"); err == nil {
+			t.Error("UseRefreshToken succeeded with a canceled context")
+		}
+	})
+}
+
+func createFamily(t *testing.T, repo repository.SessionRepo, familyID, tokenHash string, ttl time.Duration) repository.RefreshFamily {
+	t.Helper()
+	token := refreshToken(tokenHash, familyID, ttl)
+	family := repository.RefreshFamily{
+		ID:             familyID,
+		Username:       "alice",
+		SessionVersion: 3,
+		ExpiresAt:      token.ExpiresAt,
+	}
+	if err := repo.CreateRefreshFamily(t.Context(), family, token); err != nil {
+		t.Fatalf("CreateRefreshFamily: %v", err)
+	}
+	return family
+}
+
+func refreshToken(hash, familyID string, ttl time.Duration) repository.RefreshTokenRecord {
+	return repository.RefreshTokenRecord{
+		Hash:      hash,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+}
+
+func sessionVersion(t *testing.T, repo repository.SessionRepo, username string) uint64 {
+	t.Helper()
+	version, err := repo.SessionVersion(t.Context(), username)
+	if err != nil {
+		t.Fatalf("SessionVersion: %v", err)
+	}
+	return version
+}
+
+func tokenRevoked(t *testing.T, repo repository.SessionRepo, tokenID string) bool {
+	t.Helper()
+	revoked, err := repo.TokenRevoked(t.Context(), tokenID)
+	if err != nil {
+		t.Fatalf("TokenRevoked: %v", err)
+	}
+	return revoked
+}
+
+func refreshFamilyOf(t *testing.T, repo repository.SessionRepo, tokenHash string) string {
+	t.Helper()
+	family, err := repo.RefreshFamilyOf(t.Context(), tokenHash)
+	if err != nil {
+		t.Fatalf("RefreshFamilyOf: %v", err)
+	}
+	return family.ID
+}
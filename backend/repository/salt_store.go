@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/threehook/zkp-auth/backend/credential"
+)
+
+// maxSaltsPerClient bounds outstanding salts per username and client IP;
+// issuing more evicts the oldest one. Keying by IP as well keeps a client
+// elsewhere from evicting the salt of a user who is registering
+const maxSaltsPerClient = 5
+
+// maxPendingSalts bounds every outstanding salt together. Salts are handed out
+// before anyone has authenticated, so at the limit Issue refuses new ones
+// instead of evicting salts that others are about to spend
+const maxPendingSalts = 100_000
+
+var (
+	ErrSaltNotIssued = &UserError{Message: "salt was not issued for this username or has expired"}
+	ErrTooManySalts  = &UserError{Message: "too many salts outstanding"}
+)
+
+type pendingSalt struct {
+	client    saltClient
+	expiresAt time.Time
+}
+
+// saltClient identifies the client a salt was issued to
+type saltClient struct {
+	username  string
+	ipAddress string
+}
+
+// SaltStore remembers the salts handed out for pending registrations and
+// credential changes so the client cannot pick its own. Salts are drawn from
+// crypto/rand and keyed by their own value: each one is a single-use ticket,
+// and issuing another salt for the same username leaves the earlier ones valid,
+// so nobody can invalidate someone else's pending registration
+type SaltStore struct {
+	mu       sync.Mutex
+	pending  map[string]pendingSalt
+	byClient map[saltClient][]string // outstanding salts, oldest first
+	issued   []string                // every issued salt, oldest first
+	ttl      time.Duration
+}
+
+func NewSaltStore(ttl time.Duration) *SaltStore {
+	return &SaltStore{
+		pending:  make(map[string]pendingSalt),
+		byClient: make(map[saltClient][]string),
+		ttl:      ttl,
+	}
+}
+
+// Issue hands out a fresh salt for the username to the client at ipAddress
+func (s *SaltStore) Issue(ctx context.Context, username, ipAddress string) (string, time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return "", time.Time{}, err
+	}
+
+	salt, err := credential.GenerateSalt()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cleanExpired()
+
+	client := saltClient{username: username, ipAddress: ipAddress}
+	if outstanding := s.byClient[client]; len(outstanding) >= maxSaltsPerClient {
+		s.remove(outstanding[0])
+	} else if len(s.pending) >= maxPendingSalts {
+		return "", time.Time{}, ErrTooManySalts
+	}
+
+	entry := pendingSalt{
+		client:    client,
+		expiresAt: time.Now().Add(s.ttl),
+	}
+	s.pending[salt] = entry
+	s.byClient[client] = append(s.byClient[client], salt)
+	s.issued = append(s.issued, salt)
+	return salt, entry.expiresAt, nil
+}
+
+// Consume checks that salt was issued for username and has not expired, and
+// removes it. The salt is the ticket, so it may be spent from any client IP
+func (s *SaltStore) Consume(ctx context.Context, username, salt string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.pending[salt]
+	if !exists || entry.client.username != username || time.Now().After(entry.expiresAt) {
+		return ErrSaltNotIssued
+	}
+	s.remove(salt)
+	return nil
+}
+
+// cleanExpired drops salts from the front of the issue order until it reaches
+// one that is still valid. Every salt lives for the same TTL, so the rest
+// expire later
+func (s *SaltStore) cleanExpired() {
+	now := time.Now()
+	expired := 0
+	for _, salt := range s.issued {
+		entry, exists := s.pending[salt]
+		if exists && !now.After(entry.expiresAt) {
+			break
+		}
+		s.remove(salt)
+		expired++
+	}
+	s.issued = s.issued[expired:]
+}
+
+// remove forgets an outstanding salt. Its entry in issued is dropped by
+// cleanExpired
+func (s *SaltStore) remove(salt string) {
+	entry, exists := s.pending[salt]
+	if !exists {
+		return
+	}
+	delete(s.pending, salt)
+
+	outstanding := slices.DeleteFunc(s.byClient[entry.client], func(v string) bool { return v == salt })
+	if len(outstanding) == 0 {
+		delete(s.byClient, entry.client)
+	} else {
+		s.byClient[entry.client] = outstanding
+	}
+}
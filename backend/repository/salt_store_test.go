@@ -0,0 +1,111 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/threehook/zkp-auth/backend/repository"
+)
+
+func TestSaltStoreKeepsEarlierSalts(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewSaltStore(time.Minute)
+
+	first, _, err := store.Issue(ctx, "alice", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	// Someone else asking for a salt for the same name does not invalidate it
+	second, _, err := store.Issue(ctx, "alice", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if first == second {
+		t.Fatal("Issue returned the same salt twice")
+	}
+
+	if err := store.Consume(ctx, "bob", first); !errors.Is(err, repository.ErrSaltNotIssued) {
+		t.Fatalf("Consume for another username = %v, want ErrSaltNotIssued", err)
+	}
+	if err := store.Consume(ctx, "alice", first); err != nil {
+		t.Fatalf("Consume of the first salt: %v", err)
+	}
+	if err := store.Consume(ctx, "alice", first); !errors.Is(err, repository.ErrSaltNotIssued) {
+		t.Fatalf("second Consume = %v, want ErrSaltNotIssued", err)
+	}
+	if err := store.Consume(ctx, "alice", second); err != nil {
+		t.Fatalf("Consume of the second salt: %v", err)
+	}
+}
+
+func TestSaltStoreExpires(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewSaltStore(10 * time.Millisecond)
+
+	salt, _, err := store.Issue(ctx, "alice", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := store.Consume(ctx, "alice", salt); !errors.Is(err, repository.ErrSaltNotIssued) {
+		t.Fatalf("Consume of an expired salt = %v, want ErrSaltNotIssued", err)
+	}
+}
+
+func TestSaltStoreCapsSaltsPerClient(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewSaltStore(time.Minute)
+
+	elsewhere, _, err := store.Issue(ctx, "alice", "198.51.100.7")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	var salts []string
+	for range 6 {
+		salt, _, err := store.Issue(ctx, "alice", "192.0.2.1")
+		if err != nil {
+			t.Fatalf("Issue: %v", err)
+		}
+		salts = append(salts, salt)
+	}
+
+	// The sixth salt from one client evicted its oldest, and only that one
+	if err := store.Consume(ctx, "alice", salts[0]); !errors.Is(err, repository.ErrSaltNotIssued) {
+		t.Fatalf("Consume of the evicted salt = %v, want ErrSaltNotIssued", err)
+	}
+	for _, salt := range append(salts[1:], elsewhere) {
+		if err := store.Consume(ctx, "alice", salt); err != nil {
+			t.Fatalf("Consume: %v", err)
+		}
+	}
+}
+
+func TestSaltStoreRefusesWhenFull(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewSaltStore(time.Minute)
+
+	var first string
+	for i := range 100_000 {
+		salt, _, err := store.Issue(ctx, strconv.Itoa(i), "192.0.2.1")
+		if err != nil {
+			t.Fatalf("Issue %d: %v", i, err)
+		}
+		if i == 0 {
+			first = salt
+		}
+	}
+	if _, _, err := store.Issue(ctx, "alice", "192.0.2.1"); !errors.Is(err, repository.ErrTooManySalts) {
+		t.Fatalf("Issue on a full store = %v, want ErrTooManySalts", err)
+	}
+
+	// Salts already handed out stay valid, and spending one makes room
+	if err := store.Consume(ctx, "0", first); err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if _, _, err := store.Issue(ctx, "alice", "192.0.2.1"); err != nil {
+		t.Fatalf("Issue after a salt was spent: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// SessionRepo stores the server-side session state behind access and refresh
+// tokens: per-user session versions, revoked access token IDs and refresh
+// token families. Kept in the user store, it survives restarts and, with a
+// shared database, is seen by every instance. Like UserRepo, every method
+// takes the request context first
+type SessionRepo interface {
+	// SessionVersion returns the user's session version, 0 until the first
+	// BumpSessionVersion. Versions are kept when the user is deleted, so
+	// tokens issued to a deleted account stay dead if the name is reused
+	SessionVersion(ctx context.Context, username string) (uint64, error)
+	// BumpSessionVersion increments the user's session version and returns
+	// the new one
+	BumpSessionVersion(ctx context.Context, username string) (uint64, error)
+
+	// RevokeToken rejects the access token with tokenID until expiresAt
+	RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	// TokenRevoked reports whether tokenID was revoked and has not expired
+	TokenRevoked(ctx context.Context, tokenID string) (bool, error)
+
+	// CreateRefreshFamily stores a new family together with its first token
+	CreateRefreshFamily(ctx context.Context, family RefreshFamily, token RefreshTokenRecord) error
+	// AddRefreshToken adds a token to its family and moves the family's
+	// expiry to the token's. A missing or revoked family fails with
+	// ErrRefreshTokenInvalid
+	AddRefreshToken(ctx context.Context, token RefreshTokenRecord) error
+	// UseRefreshToken marks the token with tokenHash as rotated and returns
+	// its family. Unknown and expired tokens and revoked families fail with
+	// ErrRefreshTokenInvalid. A token that was already rotated revokes its
+	// family in the same step and fails with ErrRefreshTokenReused, still
+	// returning the family. Of two concurrent calls for one token, only one
+	// succeeds
+	UseRefreshToken(ctx context.Context, tokenHash string) (RefreshFamily, error)
+	// RevokeRefreshFamily ends a family. Unknown families are ignored
+	RevokeRefreshFamily(ctx context.Context, familyID string) error
+	// RefreshFamilyOf returns the family of the token with tokenHash without
+	// using the token, or a zero RefreshFamily if the token is unknown
+	RefreshFamilyOf(ctx context.Context, tokenHash string) (RefreshFamily, error)
+
+	// PurgeExpired drops revocations, refresh tokens and families that
+	// expired before now. Rotated tokens are kept until they expire, so
+	// their reuse is still detected
+	PurgeExpired(ctx context.Context, now time.Time) error
+}
+
+// Store is everything a backend instance keeps in its user store
+type Store interface {
+	UserRepo
+	SessionRepo
+}
+
+// RefreshFamily is the chain of refresh tokens descending from one login.
+// It is only valid while the user's session version is the one it was
+// started under
+type RefreshFamily struct {
+	ID             string    `json:"id"`
+	Username       string    `json:"username"`
+	SessionVersion uint64    `json:"sessionVersion"`
+	ExpiresAt      time.Time `json:"expiresAt"` // of the newest token in the family
+	Revoked        bool      `json:"revoked"`
+}
+
+// RefreshTokenRecord is one refresh token, stored as a hash of its value
+type RefreshTokenRecord struct {
+	Hash      string    `json:"hash"`
+	FamilyID  string    `json:"familyId"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Rotated   bool      `json:"rotated"` // exchanged for a newer token
+}
+
+var (
+	// ErrRefreshTokenInvalid covers unknown, expired and revoked refresh tokens
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	// ErrRefreshTokenReused means a refresh token was presented again after it
+	// had been rotated, so it was probably stolen; its family is revoked
+	ErrRefreshTokenReused = errors.New("refresh token was already used")
+)
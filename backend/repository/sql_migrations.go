@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// sqlMigration upgrades the SQL schema by one version. Statements stick to
+// types every supported dialect accepts. Like the bolt migrations these are
+// append-only
+type sqlMigration struct {
+	version     int
+	description string
+	statements  []string
+}
+
+var sqlMigrations = []sqlMigration{
+	{
+		version:     1,
+		description: "create users and credentials tables",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS users (
+				username   VARCHAR(255) NOT NULL PRIMARY KEY,
+				created_at TIMESTAMP    NOT NULL
+			)`,
+			`CREATE TABLE IF NOT EXISTS credentials (
+				username   VARCHAR(255) NOT NULL PRIMARY KEY REFERENCES users (username) ON DELETE CASCADE,
+				salt       VARCHAR(80)  NOT NULL,
+				commitment VARCHAR(80)  NOT NULL,
+				updated_at TIMESTAMP    NOT NULL
+			)`,
+		},
+	},
+	{
+		version:     2,
+		description: "add user lifecycle columns",
+		statements: []string{
+			`ALTER TABLE users ADD COLUMN updated_at TIMESTAMP`,
+			`UPDATE users SET updated_at = created_at`,
+			`ALTER TABLE users ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT FALSE`,
+			`ALTER TABLE users ADD COLUMN last_login_at TIMESTAMP`,
+		},
+	},
+	{
+		version:     3,
+		description: "create recovery codes table",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS recovery_codes (
+				username   VARCHAR(255) NOT NULL REFERENCES users (username) ON DELETE CASCADE,
+				commitment VARCHAR(80)  NOT NULL,
+				salt       VARCHAR(80)  NOT NULL,
+				used_at    TIMESTAMP,
+				PRIMARY KEY (username, commitment)
+			)`,
+		},
+	},
+	{
+		version:     4,
+		description: "create session tables",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS session_versions (
+				username VARCHAR(255) NOT NULL PRIMARY KEY,
+				version  BIGINT       NOT NULL
+			)`,
+			`CREATE TABLE IF NOT EXISTS revoked_tokens (
+				token_id   VARCHAR(255) NOT NULL PRIMARY KEY,
+				expires_at TIMESTAMP    NOT NULL
+			)`,
+			`CREATE TABLE IF NOT EXISTS refresh_families (
+				family_id       VARCHAR(64)  NOT NULL PRIMARY KEY,
+				username        VARCHAR(255) NOT NULL,
+				session_version BIGINT       NOT NULL,
+				expires_at      TIMESTAMP    NOT NULL,
+				revoked         BOOLEAN      NOT NULL DEFAULT FALSE
+			)`,
+			`CREATE TABLE IF NOT EXISTS refresh_tokens (
+				token_hash VARCHAR(64) NOT NULL PRIMARY KEY,
+				family_id  VARCHAR(64) NOT NULL REFERENCES refresh_families (family_id) ON DELETE CASCADE,
+				expires_at TIMESTAMP   NOT NULL,
+				rotated    BOOLEAN     NOT NULL DEFAULT FALSE
+			)`,
+			`CREATE INDEX revoked_tokens_expires_at ON revoked_tokens (expires_at)`,
+			`CREATE INDEX refresh_families_expires_at ON refresh_families (expires_at)`,
+			`CREATE INDEX refresh_tokens_expires_at ON refresh_tokens (expires_at)`,
+		},
+	},
+}
+
+const createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version     INTEGER      NOT NULL PRIMARY KEY,
+	description VARCHAR(255) NOT NULL,
+	applied_at  TIMESTAMP    NOT NULL
+)`
+
+// migrationLockID is the Postgres advisory lock every migration step holds,
+// "zkp_mig" in ASCII
+const migrationLockID = 0x7a6b705f6d6967
+
+// migrateSQL applies every migration newer than the highest recorded version,
+// each in its own transaction together with its schema_migrations row. On
+// Postgres each transaction first takes an advisory lock and then re-reads the
+// schema version, so instances starting together wait for each other and skip
+// what another one applied; DDL there would otherwise fail on the duplicate
+// tables and columns. SQLite serialises writers on its own
+func migrateSQL(ctx context.Context, db *sql.DB, dialect SQLDialect) error {
+	err := withMigrationLock(ctx, db, dialect, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, createSchemaMigrations)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	current, err := sqlSchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	latest := sqlMigrations[len(sqlMigrations)-1].version
+	if current > latest {
+		return fmt.Errorf("user database schema v%d is newer than this build (v%d)", current, latest)
+	}
+
+	for _, m := range sqlMigrations {
+		if m.version <= current {
+			continue
+		}
+
+		applied, err := applySQLMigration(ctx, db, dialect, m)
+		if err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.description, err)
+		}
+		if applied {
+			log.Printf("🗄️ Applied user database migration %d: %s", m.version, m.description)
+		}
+	}
+	return nil
+}
+
+// applySQLMigration applies m unless the schema already reached its version,
+// and reports whether it did
+func applySQLMigration(ctx context.Context, db *sql.DB, dialect SQLDialect, m sqlMigration) (bool, error) {
+	applied := false
+	err := withMigrationLock(ctx, db, dialect, func(tx *sql.Tx) error {
+		current, err := sqlSchemaVersion(ctx, tx)
+		if err != nil || current >= m.version {
+			return err
+		}
+
+		for _, stmt := range m.statements {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, dialect.rebind(
+			`INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)`),
+			m.version, m.description, time.Now().UTC())
+		applied = err == nil
+		return err
+	})
+	return applied, err
+}
+
+// withMigrationLock runs fn in a transaction that on Postgres holds the
+// migration lock until it commits or rolls back
+func withMigrationLock(ctx context.Context, db *sql.DB, dialect SQLDialect, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if dialect == DialectPostgres {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, int64(migrationLockID)); err != nil {
+			return fmt.Errorf("lock migrations: %w", err)
+		}
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func sqlSchemaVersion(ctx context.Context, db sqlExecer) (int, error) {
+	var version int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+func (r *SQLUserRepo) SessionVersion(ctx context.Context, username string) (uint64, error) {
+	return getSQLSessionVersion(ctx, r.db, r.dialect, username)
+}
+
+// BumpSessionVersion increments the row, inserting it on first use. When two
+// instances insert the same user's first row at once, the loser retries and
+// increments the winner's
+func (r *SQLUserRepo) BumpSessionVersion(ctx context.Context, username string) (uint64, error) {
+	var version uint64
+	bump := func(tx *sql.Tx) error {
+		err := r.execOne(ctx, tx, `UPDATE session_versions SET version = version + 1 WHERE username = ?`, username)
+		if errors.Is(err, ErrUserNotFound) {
+			_, err = tx.ExecContext(ctx, r.dialect.rebind(
+				`INSERT INTO session_versions (username, version) VALUES (?, 1)`), username)
+		}
+		if err != nil {
+			return err
+		}
+		version, err = getSQLSessionVersion(ctx, tx, r.dialect, username)
+		return err
+	}
+
+	err := r.withTx(ctx, bump)
+	if isUniqueViolation(err) {
+		err = r.withTx(ctx, bump)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// RevokeToken inserts the revocation; revoking a token twice keeps the first row
+func (r *SQLUserRepo) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	if !time.Now().Before(expiresAt) {
+		return nil
+	}
+
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		`INSERT INTO revoked_tokens (token_id, expires_at) VALUES (?, ?)`),
+		tokenID, sqlTime(expiresAt))
+	if isUniqueViolation(err) {
+		return nil
+	}
+	return err
+}
+
+func (r *SQLUserRepo) TokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var expiresAt time.Time
+	err := r.db.QueryRowContext(ctx, r.dialect.rebind(
+		`SELECT expires_at FROM revoked_tokens WHERE token_id = ?`), tokenID).Scan(&expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return time.Now().Before(expiresAt), nil
+}
+
+func (r *SQLUserRepo) CreateRefreshFamily(ctx context.Context, family RefreshFamily, token RefreshTokenRecord) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, r.dialect.rebind(
+			`INSERT INTO refresh_families (family_id, username, session_version, expires_at, revoked) VALUES (?, ?, ?, ?, ?)`),
+			family.ID, family.Username, int64(family.SessionVersion), sqlTime(family.ExpiresAt), family.Revoked)
+		if err != nil {
+			return err
+		}
+		return r.insertRefreshToken(ctx, tx, token)
+	})
+}
+
+// AddRefreshToken moves the family's expiry only while it is not revoked, so
+// a token cannot be added to a family revoked concurrently
+func (r *SQLUserRepo) AddRefreshToken(ctx context.Context, token RefreshTokenRecord) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		err := r.execOne(ctx, tx,
+			`UPDATE refresh_families SET expires_at = ? WHERE family_id = ? AND revoked = ?`,
+			sqlTime(token.ExpiresAt), token.FamilyID, false)
+		if errors.Is(err, ErrUserNotFound) {
+			return ErrRefreshTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+		return r.insertRefreshToken(ctx, tx, token)
+	})
+}
+
+// UseRefreshToken marks the token with a conditional UPDATE, so of two
+// concurrent calls only one matches the unrotated row; the other revokes the
+// family as a reuse
+func (r *SQLUserRepo) UseRefreshToken(ctx context.Context, tokenHash string) (RefreshFamily, error) {
+	var family RefreshFamily
+	var reused bool
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		var tokenExpiresAt time.Time
+		var rotated bool
+		var version int64
+		err := tx.QueryRowContext(ctx, r.dialect.rebind(
+			`SELECT t.expires_at, t.rotated, f.family_id, f.username, f.session_version, f.expires_at, f.revoked
+			  FROM refresh_tokens t
+			  JOIN refresh_families f ON f.family_id = t.family_id
+			 WHERE t.token_hash = ?`), tokenHash).
+			Scan(&tokenExpiresAt, &rotated, &family.ID, &family.Username, &version, &family.ExpiresAt, &family.Revoked)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRefreshTokenInvalid
+		}
+		if err != nil {
+			return err
+		}
+		family.SessionVersion = uint64(version)
+		family.ExpiresAt = family.ExpiresAt.UTC()
+		if !time.Now().Before(tokenExpiresAt) || family.Revoked {
+			return ErrRefreshTokenInvalid
+		}
+
+		if !rotated {
+			err := r.execOne(ctx, tx,
+				`UPDATE refresh_tokens SET rotated = ? WHERE token_hash = ? AND rotated = ?`,
+				true, tokenHash, false)
+			if !errors.Is(err, ErrUserNotFound) {
+				return err
+			}
+		}
+
+		reused = true
+		family.Revoked = true
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(
+			`UPDATE refresh_families SET revoked = ? WHERE family_id = ?`), true, family.ID)
+		return err
+	})
+	if err != nil {
+		return RefreshFamily{}, err
+	}
+	if reused {
+		return family, ErrRefreshTokenReused
+	}
+	return family, nil
+}
+
+func (r *SQLUserRepo) RevokeRefreshFamily(ctx context.Context, familyID string) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(
+		`UPDATE refresh_families SET revoked = ? WHERE family_id = ?`), true, familyID)
+	return err
+}
+
+func (r *SQLUserRepo) RefreshFamilyOf(ctx context.Context, tokenHash string) (RefreshFamily, error) {
+	var family RefreshFamily
+	var version int64
+	err := r.db.QueryRowContext(ctx, r.dialect.rebind(
+		`SELECT f.family_id, f.username, f.session_version, f.expires_at, f.revoked
+		  FROM refresh_tokens t
+		  JOIN refresh_families f ON f.family_id = t.family_id
+		 WHERE t.token_hash = ?`), tokenHash).
+		Scan(&family.ID, &family.Username, &version, &family.ExpiresAt, &family.Revoked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return RefreshFamily{}, nil
+	}
+	if err != nil {
+		return RefreshFamily{}, err
+	}
+	family.SessionVersion = uint64(version)
+	family.ExpiresAt = family.ExpiresAt.UTC()
+	return family, nil
+}
+
+// PurgeExpired deletes tokens before families; a family expires with its
+// newest token, so none of its tokens outlive it
+func (r *SQLUserRepo) PurgeExpired(ctx context.Context, now time.Time) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		for _, query := range []string{
+			`DELETE FROM revoked_tokens WHERE expires_at <= ?`,
+			`DELETE FROM refresh_tokens WHERE expires_at <= ?`,
+			`DELETE FROM refresh_families WHERE expires_at <= ?`,
+		} {
+			if _, err := tx.ExecContext(ctx, r.dialect.rebind(query), sqlTime(now)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SQLUserRepo) insertRefreshToken(ctx context.Context, tx *sql.Tx, token RefreshTokenRecord) error {
+	_, err := tx.ExecContext(ctx, r.dialect.rebind(
+		`INSERT INTO refresh_tokens (token_hash, family_id, expires_at, rotated) VALUES (?, ?, ?, ?)`),
+		token.Hash, token.FamilyID, sqlTime(token.ExpiresAt), token.Rotated)
+	return err
+}
+
+func getSQLSessionVersion(ctx context.Context, db sqlExecer, dialect SQLDialect, username string) (uint64, error) {
+	var version int64
+	err := db.QueryRowContext(ctx, dialect.rebind(
+		`SELECT version FROM session_versions WHERE username = ?`), username).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return uint64(version), nil
+}
+
+// sqlTime normalises a time the way the repository stores every timestamp
+func sqlTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
@@ -0,0 +1,482 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SQLDialect covers the few places the supported databases disagree: bind
+// parameters and how a unique constraint violation is reported
+type SQLDialect int
+
+const (
+	DialectSQLite SQLDialect = iota
+	DialectPostgres
+)
+
+// DialectForDriver maps a database/sql driver name to its dialect
+func DialectForDriver(driver string) (SQLDialect, error) {
+	switch driver {
+	case "sqlite", "sqlite3":
+		return DialectSQLite, nil
+	case "pgx", "postgres":
+		return DialectPostgres, nil
+	default:
+		return 0, fmt.Errorf("unsupported SQL driver %q", driver)
+	}
+}
+
+// rebind rewrites the ? placeholders the queries are written with into the
+// dialect's bind parameters
+func (d SQLDialect) rebind(query string) string {
+	if d != DialectPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isUniqueViolation recognises duplicate key errors from the drivers' messages
+// so the repository does not import any of them
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "UNIQUE constraint failed") || // SQLite
+		strings.Contains(msg, "SQLSTATE 23505") || // Postgres (pgx)
+		strings.Contains(msg, "duplicate key value violates unique constraint") // Postgres (lib/pq)
+}
+
+// SQLUserRepo is a UserRepo and SessionRepo in a relational database shared by
+// every backend instance. The schema keeps a user row and its current
+// credential apart so credentials can be replaced without touching the account
+type SQLUserRepo struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// OpenSQLUserRepo connects with a registered database/sql driver and brings
+// the schema up to date. The caller must import the driver
+func OpenSQLUserRepo(ctx context.Context, driver, dsn string) (*SQLUserRepo, error) {
+	dialect, err := DialectForDriver(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open user database: %w", err)
+	}
+	// SQLite serialises writers, and every connection to :memory: is a
+	// separate database
+	if dialect == DialectSQLite {
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect to user database: %w", err)
+	}
+
+	repo, err := NewSQLUserRepo(ctx, db, dialect)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return repo, nil
+}
+
+// NewSQLUserRepo uses an already configured connection pool and applies any
+// pending migrations
+func NewSQLUserRepo(ctx context.Context, db *sql.DB, dialect SQLDialect) (*SQLUserRepo, error) {
+	if err := migrateSQL(ctx, db, dialect); err != nil {
+		return nil, err
+	}
+	return &SQLUserRepo{db: db, dialect: dialect}, nil
+}
+
+// Close closes the connection pool
+func (r *SQLUserRepo) Close() error {
+	return r.db.Close()
+}
+
+// CreateUser inserts the user and its credential in one transaction.
+// A concurrent insert of the same username fails on the primary key and is
+// reported as ErrUserExists
+func (r *SQLUserRepo) CreateUser(ctx context.Context, username, salt, commitment string) (User, error) {
+	if err := validateCredential(salt, commitment); err != nil {
+		return User{}, err
+	}
+
+	now := timestamp()
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, r.dialect.rebind(
+			`INSERT INTO users (username, created_at, updated_at) VALUES (?, ?, ?)`),
+			username, now, now)
+		if isUniqueViolation(err) {
+			return ErrUserExists
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(
+			`INSERT INTO credentials (username, salt, commitment, updated_at) VALUES (?, ?, ?, ?)`),
+			username, salt, commitment, now)
+		return err
+	})
+	if err != nil {
+		return User{}, err
+	}
+
+	return User{
+		Username:   username,
+		Salt:       salt,
+		Commitment: commitment,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}, nil
+}
+
+const selectUsers = `SELECT u.username, c.salt, c.commitment, u.created_at, u.updated_at, u.disabled, u.last_login_at
+  FROM users u
+  JOIN credentials c ON c.username = u.username`
+
+// GetUser loads a user and its current credential. A missing user is not
+// an error
+func (r *SQLUserRepo) GetUser(ctx context.Context, username string) (User, bool, error) {
+	return getSQLUser(ctx, r.db, r.dialect, username)
+}
+
+// UserExists reports whether a user row exists
+func (r *SQLUserRepo) UserExists(ctx context.Context, username string) (bool, error) {
+	_, exists, err := r.GetUser(ctx, username)
+	return exists, err
+}
+
+// UpdateCredential replaces the credential and returns the updated user
+func (r *SQLUserRepo) UpdateCredential(ctx context.Context, username, salt, commitment string) (User, error) {
+	if err := validateCredential(salt, commitment); err != nil {
+		return User{}, err
+	}
+
+	var user User
+	now := timestamp()
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		err := r.execOne(ctx, tx,
+			`UPDATE credentials SET salt = ?, commitment = ?, updated_at = ? WHERE username = ?`,
+			salt, commitment, now, username)
+		if err != nil {
+			return err
+		}
+		err = r.execOne(ctx, tx, `UPDATE users SET updated_at = ? WHERE username = ?`, now, username)
+		if err != nil {
+			return err
+		}
+
+		user, _, err = getSQLUser(ctx, tx, r.dialect, username)
+		return err
+	})
+	if err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// RotateCredential updates the credential row only while it still holds
+// oldCommitment. When no row matches, a second read tells a missing user
+// apart from a concurrent change
+func (r *SQLUserRepo) RotateCredential(ctx context.Context, username, oldCommitment, salt, commitment string) (User, error) {
+	if err := validateCredential(salt, commitment); err != nil {
+		return User{}, err
+	}
+
+	var user User
+	now := timestamp()
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		err := r.execOne(ctx, tx,
+			`UPDATE credentials SET salt = ?, commitment = ?, updated_at = ? WHERE username = ? AND commitment = ?`,
+			salt, commitment, now, username, oldCommitment)
+		if errors.Is(err, ErrUserNotFound) {
+			_, exists, err := getSQLUser(ctx, tx, r.dialect, username)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return ErrCredentialChanged
+			}
+			return ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+		err = r.execOne(ctx, tx, `UPDATE users SET updated_at = ? WHERE username = ?`, now, username)
+		if err != nil {
+			return err
+		}
+
+		user, _, err = getSQLUser(ctx, tx, r.dialect, username)
+		return err
+	})
+	if err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// DeleteUser removes the recovery codes, the credential and then the user. It does not rely
+// on ON DELETE CASCADE, which SQLite only honours with foreign keys enabled
+func (r *SQLUserRepo) DeleteUser(ctx context.Context, username string) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		for _, query := range []string{
+			`DELETE FROM recovery_codes WHERE username = ?`,
+			`DELETE FROM credentials WHERE username = ?`,
+		} {
+			if _, err := tx.ExecContext(ctx, r.dialect.rebind(query), username); err != nil {
+				return err
+			}
+		}
+		return r.execOne(ctx, tx, `DELETE FROM users WHERE username = ?`, username)
+	})
+}
+
+// ListUsers pages through users by username. It reads one row past the
+// limit to learn whether there is a next page
+func (r *SQLUserRepo) ListUsers(ctx context.Context, opts ListOptions) (UserPage, error) {
+	limit := opts.limit()
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(selectUsers+`
+	 WHERE SUBSTR(u.username, 1, ?) = ? AND u.username > ?
+	 ORDER BY u.username
+	 LIMIT ?`),
+		utf8.RuneCountInString(opts.Prefix), opts.Prefix, opts.After, limit+1)
+	if err != nil {
+		return UserPage{}, err
+	}
+	defer rows.Close()
+
+	var page UserPage
+	for rows.Next() {
+		if len(page.Users) == limit {
+			page.NextCursor = page.Users[limit-1].Username
+			break
+		}
+		user, err := scanUser(rows)
+		if err != nil {
+			return UserPage{}, err
+		}
+		page.Users = append(page.Users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return UserPage{}, err
+	}
+	return page, nil
+}
+
+// SetDisabled sets the disabled flag
+func (r *SQLUserRepo) SetDisabled(ctx context.Context, username string, disabled bool) error {
+	return r.execOne(ctx, r.db, `UPDATE users SET disabled = ?, updated_at = ? WHERE username = ?`,
+		disabled, timestamp(), username)
+}
+
+// RecordLogin stores the time of a successful login
+func (r *SQLUserRepo) RecordLogin(ctx context.Context, username string, at time.Time) error {
+	return r.execOne(ctx, r.db, `UPDATE users SET last_login_at = ? WHERE username = ?`,
+		at.UTC().Truncate(time.Microsecond), username)
+}
+
+// SetRecoveryCodes deletes the user's codes and inserts the new set in one
+// transaction
+func (r *SQLUserRepo) SetRecoveryCodes(ctx context.Context, username string, codes []RecoveryCode) error {
+	if err := validateRecoveryCodes(codes); err != nil {
+		return err
+	}
+
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		if err := r.requireUser(ctx, tx, username); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM recovery_codes WHERE username = ?`), username)
+		if err != nil {
+			return err
+		}
+		for _, code := range codes {
+			var usedAt sql.NullTime
+			if code.Used() {
+				usedAt = sql.NullTime{Time: code.UsedAt.UTC().Truncate(time.Microsecond), Valid: true}
+			}
+			_, err := tx.ExecContext(ctx, r.dialect.rebind(
+				`INSERT INTO recovery_codes (username, commitment, salt, used_at) VALUES (?, ?, ?, ?)`),
+				username, code.Commitment, code.Salt, usedAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RecoveryCodes reads the user's codes in commitment order
+func (r *SQLUserRepo) RecoveryCodes(ctx context.Context, username string) ([]RecoveryCode, error) {
+	if err := r.requireUser(ctx, r.db, username); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(
+		`SELECT salt, commitment, used_at FROM recovery_codes WHERE username = ? ORDER BY commitment`), username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []RecoveryCode
+	for rows.Next() {
+		var code RecoveryCode
+		var usedAt sql.NullTime
+		if err := rows.Scan(&code.Salt, &code.Commitment, &usedAt); err != nil {
+			return nil, err
+		}
+		if usedAt.Valid {
+			code.UsedAt = usedAt.Time.UTC()
+		}
+		codes = append(codes, code)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RecoverCredential burns the code with a conditional UPDATE, so of two
+// concurrent recoveries with the same code only one matches a row
+func (r *SQLUserRepo) RecoverCredential(ctx context.Context, username, codeCommitment, salt, commitment string) (User, error) {
+	if err := validateCredential(salt, commitment); err != nil {
+		return User{}, err
+	}
+
+	var user User
+	now := timestamp()
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		err := r.execOne(ctx, tx,
+			`UPDATE recovery_codes SET used_at = ? WHERE username = ? AND commitment = ? AND used_at IS NULL`,
+			now, username, codeCommitment)
+		if errors.Is(err, ErrUserNotFound) {
+			if err := r.requireUser(ctx, tx, username); err != nil {
+				return err
+			}
+			return ErrRecoveryCodeInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		err = r.execOne(ctx, tx,
+			`UPDATE credentials SET salt = ?, commitment = ?, updated_at = ? WHERE username = ?`,
+			salt, commitment, now, username)
+		if err != nil {
+			return err
+		}
+		err = r.execOne(ctx, tx, `UPDATE users SET updated_at = ? WHERE username = ?`, now, username)
+		if err != nil {
+			return err
+		}
+
+		user, _, err = getSQLUser(ctx, tx, r.dialect, username)
+		return err
+	})
+	if err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// requireUser fails with ErrUserNotFound unless the user row exists
+func (r *SQLUserRepo) requireUser(ctx context.Context, db sqlExecer, username string) error {
+	var found int
+	err := db.QueryRowContext(ctx, r.dialect.rebind(`SELECT 1 FROM users WHERE username = ?`), username).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// sqlExecer is the part of *sql.DB and *sql.Tx the helpers below need
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// execOne runs a statement that must touch exactly one user, mapping no rows
+// to ErrUserNotFound
+func (r *SQLUserRepo) execOne(ctx context.Context, db sqlExecer, query string, args ...any) error {
+	result, err := db.ExecContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func getSQLUser(ctx context.Context, db sqlExecer, dialect SQLDialect, username string) (User, bool, error) {
+	user, err := scanUser(db.QueryRowContext(ctx, dialect.rebind(selectUsers+`
+	 WHERE u.username = ?`), username))
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, false, nil
+	}
+	if err != nil {
+		return User{}, false, err
+	}
+	return user, true, nil
+}
+
+func scanUser(row interface{ Scan(dest ...any) error }) (User, error) {
+	var user User
+	var lastLogin sql.NullTime
+	err := row.Scan(&user.Username, &user.Salt, &user.Commitment,
+		&user.CreatedAt, &user.UpdatedAt, &user.Disabled, &lastLogin)
+	if err != nil {
+		return User{}, err
+	}
+	user.CreatedAt = user.CreatedAt.UTC()
+	user.UpdatedAt = user.UpdatedAt.UTC()
+	if lastLogin.Valid {
+		user.LastLoginAt = lastLogin.Time.UTC()
+	}
+	return user, nil
+}
+
+// withTx runs fn in a transaction, committing if it returns nil
+func (r *SQLUserRepo) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
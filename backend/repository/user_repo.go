@@ -1,13 +1,87 @@
 package repository
 
+import (
+	"context"
+	"time"
+)
+
+// UserRepo stores users and their credentials. Every method takes the
+// request context first so persistent backends can honour cancellation and
+// deadlines; implementations return ctx.Err() once it is done
 type UserRepo interface {
-	CreateUser(username, password string) (User, error)
-	GetUser(username string) (User, bool)
-	UserExists(username string) bool
+	// CreateUser stores a credential computed by the client; the repository
+	// never sees the password itself
+	CreateUser(ctx context.Context, username, salt, commitment string) (User, error)
+	// GetUser reports a missing user as false with a nil error
+	GetUser(ctx context.Context, username string) (User, bool, error)
+	UserExists(ctx context.Context, username string) (bool, error)
+
+	// UpdateCredential replaces a user's salt and commitment, e.g. after a
+	// password change. Unknown users fail with ErrUserNotFound
+	UpdateCredential(ctx context.Context, username, salt, commitment string) (User, error)
+	// RotateCredential replaces the credential only if the stored commitment
+	// is still oldCommitment, so a change authorised by a proof against the
+	// old credential cannot overwrite a concurrent one. A mismatch fails with
+	// ErrCredentialChanged
+	RotateCredential(ctx context.Context, username, oldCommitment, salt, commitment string) (User, error)
+	// DeleteUser removes a user and its credential
+	DeleteUser(ctx context.Context, username string) error
+	// ListUsers returns users in username order, one page at a time
+	ListUsers(ctx context.Context, opts ListOptions) (UserPage, error)
+	// SetDisabled blocks or re-allows logins for a user. Tokens already issued
+	// stay valid; callers revoke them with session.Store.RevokeAll
+	SetDisabled(ctx context.Context, username string, disabled bool) error
+	// RecordLogin stores the time of a successful login
+	RecordLogin(ctx context.Context, username string, at time.Time) error
+
+	// SetRecoveryCodes replaces all of a user's recovery codes, used or not
+	SetRecoveryCodes(ctx context.Context, username string, codes []RecoveryCode) error
+	// RecoveryCodes returns a user's recovery codes, including burned ones,
+	// in no particular order
+	RecoveryCodes(ctx context.Context, username string) ([]RecoveryCode, error)
+	// RecoverCredential burns the unused recovery code with codeCommitment and
+	// replaces the credential in one step, so each code resets the password at
+	// most once. An unknown or burned code fails with ErrRecoveryCodeInvalid
+	RecoverCredential(ctx context.Context, username, codeCommitment, salt, commitment string) (User, error)
 }
 
 type User struct {
-	Username     string `json:"username"`
-	Salt         string `json:"salt"`
-	PasswordHash string `json:"passwordHash"`
+	Username    string    `json:"username"`
+	Salt        string    `json:"salt"`
+	Commitment  string    `json:"commitment"` // Poseidon(password, salt) as a decimal field element
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Disabled    bool      `json:"disabled"`
+	LastLoginAt time.Time `json:"lastLoginAt,omitzero"` // zero until the first login
+}
+
+// DefaultListLimit and MaxListLimit bound ListOptions.Limit
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+// ListOptions selects a page of users. Pages are keyed by username rather
+// than offset, so users created while paging do not shift later pages
+type ListOptions struct {
+	Prefix string // only usernames starting with Prefix
+	After  string // only usernames after this one: the previous page's NextCursor
+	Limit  int    // 0 means DefaultListLimit; capped at MaxListLimit
+}
+
+func (o ListOptions) limit() int {
+	switch {
+	case o.Limit <= 0:
+		return DefaultListLimit
+	case o.Limit > MaxListLimit:
+		return MaxListLimit
+	default:
+		return o.Limit
+	}
+}
+
+// UserPage is one page of ListUsers. NextCursor is empty on the last page
+type UserPage struct {
+	Users      []User `json:"users"`
+	NextCursor string `json:"nextCursor,omitempty"`
 }
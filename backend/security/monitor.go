@@ -1,6 +1,7 @@
 package security
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"sync"
@@ -32,7 +33,10 @@ func NewSecurityMonitor(maxEvents int) *SecurityMonitor {
 	}
 }
 
-func (sm *SecurityMonitor) LogEvent(eventType, username, ipAddress, userAgent, sessionID, nonce, details, severity string) {
+// LogEvent records an event. ctx carries request-scoped values for tracing;
+// the event is recorded even when ctx is already cancelled, since a client
+// hanging up must not erase the audit trail
+func (sm *SecurityMonitor) LogEvent(ctx context.Context, eventType, username, ipAddress, userAgent, sessionID, nonce, details, severity string) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
@@ -62,7 +66,11 @@ func (sm *SecurityMonitor) LogEvent(eventType, username, ipAddress, userAgent, s
 		emoji, eventType, username, ipAddress, details)
 }
 
-func (sm *SecurityMonitor) GetEvents(since time.Time) []SecurityEvent {
+func (sm *SecurityMonitor) GetEvents(ctx context.Context, since time.Time) ([]SecurityEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
 
@@ -72,11 +80,14 @@ func (sm *SecurityMonitor) GetEvents(since time.Time) []SecurityEvent {
 			filtered = append(filtered, event)
 		}
 	}
-	return filtered
+	return filtered, nil
 }
 
-func (sm *SecurityMonitor) GetEventsJSON(since time.Time) (string, error) {
-	events := sm.GetEvents(since)
+func (sm *SecurityMonitor) GetEventsJSON(ctx context.Context, since time.Time) (string, error) {
+	events, err := sm.GetEvents(ctx, since)
+	if err != nil {
+		return "", err
+	}
 	jsonData, err := json.MarshalIndent(events, "", "  ")
 	if err != nil {
 		return "", err
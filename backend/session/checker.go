@@ -0,0 +1,38 @@
+package session
+
+import (
+	"context"
+
+	"github.com/threehook/zkp-auth/backend/tokenauth"
+)
+
+// TokenChecker is the issuer's tokenauth.RevocationChecker: a token is revoked
+// once its jti was revoked on logout, or once its user's session version moved
+// past the one it carries
+type TokenChecker struct {
+	sessions    *Store
+	revocations *RevocationStore
+}
+
+func NewTokenChecker(sessions *Store, revocations *RevocationStore) *TokenChecker {
+	return &TokenChecker{sessions: sessions, revocations: revocations}
+}
+
+func (c *TokenChecker) CheckRevoked(ctx context.Context, token string, claims *tokenauth.Claims) error {
+	revoked, err := c.revocations.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return tokenauth.ErrTokenRevoked
+	}
+
+	current, err := c.sessions.Valid(ctx, claims.Subject, claims.SessionVersion)
+	if err != nil {
+		return err
+	}
+	if !current {
+		return tokenauth.ErrSessionRevoked
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package session_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/threehook/zkp-auth/backend/repository"
+	"github.com/threehook/zkp-auth/backend/session"
+	"github.com/threehook/zkp-auth/backend/tokenauth"
+)
+
+func TestTokenChecker(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryUserRepo()
+	sessions := session.NewStore(repo)
+	revocations := session.NewRevocationStore(repo)
+	checker := session.NewTokenChecker(sessions, revocations)
+
+	claims := func(tokenID string, version uint64) *tokenauth.Claims {
+		return &tokenauth.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{Subject: "alice", ID: tokenID},
+			SessionVersion:   version,
+		}
+	}
+
+	if err := checker.CheckRevoked(ctx, "", claims("jti-1", 0)); err != nil {
+		t.Fatalf("CheckRevoked of a live token: %v", err)
+	}
+
+	if err := revocations.Revoke(ctx, "jti-1", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if err := checker.CheckRevoked(ctx, "", claims("jti-1", 0)); !errors.Is(err, tokenauth.ErrTokenRevoked) {
+		t.Fatalf("CheckRevoked after logout: %v, want ErrTokenRevoked", err)
+	}
+
+	version, err := sessions.RevokeAll(ctx, "alice")
+	if err != nil {
+		t.Fatalf("RevokeAll: %v", err)
+	}
+	if err := checker.CheckRevoked(ctx, "", claims("jti-2", version-1)); !errors.Is(err, tokenauth.ErrSessionRevoked) {
+		t.Fatalf("CheckRevoked after RevokeAll: %v, want ErrSessionRevoked", err)
+	}
+	if err := checker.CheckRevoked(ctx, "", claims("jti-3", version)); err != nil {
+		t.Fatalf("CheckRevoked of a token issued after RevokeAll: %v", err)
+	}
+}
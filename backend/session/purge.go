@@ -0,0 +1,28 @@
+package session
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/threehook/zkp-auth/backend/repository"
+)
+
+// PurgeExpired drops expired revocations and refresh tokens from repo every
+// interval until ctx is done. Expired entries are ignored on lookup, so this
+// only keeps the store from growing
+func PurgeExpired(ctx context.Context, repo repository.SessionRepo, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := repo.PurgeExpired(ctx, time.Now()); err != nil {
+				log.Printf("Purging expired sessions failed: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/threehook/zkp-auth/backend/repository"
+)
+
+var (
+	// ErrRefreshTokenInvalid covers unknown, expired and revoked refresh tokens
+	ErrRefreshTokenInvalid = repository.ErrRefreshTokenInvalid
+	// ErrRefreshTokenReused means a refresh token was presented again after it
+	// had been rotated, so it was probably stolen; its family is revoked
+	ErrRefreshTokenReused = repository.ErrRefreshTokenReused
+)
+
+// RefreshToken is an opaque token that can be exchanged once for a new access
+// token and a new refresh token
+type RefreshToken struct {
+	Token     string
+	Username  string
+	FamilyID  string // shared by every token rotated from the same login
+	ExpiresAt time.Time
+}
+
+// RefreshStore keeps refresh tokens server-side. Each exchange rotates the
+// token: the old one is marked as used and a new one in the same family takes
+// its place. Presenting a used token again revokes the whole family, which
+// locks out whichever of the legitimate client and a thief holds the newer
+// token. Tokens are stored as SHA-256 hashes, and a family only stays valid
+// while the user's session version is the one it was started under, so
+// RevokeAll on the Store ends refresh families too
+type RefreshStore struct {
+	sessions *Store
+	repo     repository.SessionRepo
+	ttl      time.Duration
+}
+
+func NewRefreshStore(sessions *Store, repo repository.SessionRepo, ttl time.Duration) *RefreshStore {
+	return &RefreshStore{
+		sessions: sessions,
+		repo:     repo,
+		ttl:      ttl,
+	}
+}
+
+// Issue starts a new family for a fresh login and returns its first token
+func (s *RefreshStore) Issue(ctx context.Context, username string) (RefreshToken, error) {
+	version, err := s.sessions.Version(ctx, username)
+	if err != nil {
+		return RefreshToken{}, err
+	}
+	familyID, err := randomToken(16)
+	if err != nil {
+		return RefreshToken{}, err
+	}
+	token, record, err := s.newToken(familyID)
+	if err != nil {
+		return RefreshToken{}, err
+	}
+
+	family := repository.RefreshFamily{
+		ID:             familyID,
+		Username:       username,
+		SessionVersion: version,
+		ExpiresAt:      record.ExpiresAt,
+	}
+	if err := s.repo.CreateRefreshFamily(ctx, family, record); err != nil {
+		return RefreshToken{}, err
+	}
+	return RefreshToken{
+		Token:     token,
+		Username:  username,
+		FamilyID:  familyID,
+		ExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// Rotate exchanges token for a new one in the same family. A token that was
+// already rotated fails with ErrRefreshTokenReused and revokes its family; the
+// returned RefreshToken then still names the user and family for logging
+func (s *RefreshStore) Rotate(ctx context.Context, token string) (RefreshToken, error) {
+	family, err := s.repo.UseRefreshToken(ctx, hashToken(token))
+	if errors.Is(err, ErrRefreshTokenReused) {
+		return RefreshToken{Username: family.Username, FamilyID: family.ID}, err
+	}
+	if err != nil {
+		return RefreshToken{}, err
+	}
+
+	current, err := s.sessions.Valid(ctx, family.Username, family.SessionVersion)
+	if err != nil {
+		return RefreshToken{}, err
+	}
+	if !current {
+		if err := s.repo.RevokeRefreshFamily(ctx, family.ID); err != nil {
+			return RefreshToken{}, err
+		}
+		return RefreshToken{}, ErrRefreshTokenInvalid
+	}
+
+	next, record, err := s.newToken(family.ID)
+	if err != nil {
+		return RefreshToken{}, err
+	}
+	if err := s.repo.AddRefreshToken(ctx, record); err != nil {
+		return RefreshToken{}, err
+	}
+	return RefreshToken{
+		Token:     next,
+		Username:  family.Username,
+		FamilyID:  family.ID,
+		ExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// Owner returns the user and family token was issued to without using it, so
+// whatever must succeed before a rotation can be done first. Unknown tokens
+// fail with ErrRefreshTokenInvalid; Rotate still checks everything else
+func (s *RefreshStore) Owner(ctx context.Context, token string) (RefreshToken, error) {
+	family, err := s.repo.RefreshFamilyOf(ctx, hashToken(token))
+	if err != nil {
+		return RefreshToken{}, err
+	}
+	if family.ID == "" {
+		return RefreshToken{}, ErrRefreshTokenInvalid
+	}
+	return RefreshToken{Username: family.Username, FamilyID: family.ID}, nil
+}
+
+// RevokeFamily ends the family token belongs to, e.g. on logout. Unknown
+// tokens are ignored
+func (s *RefreshStore) RevokeFamily(ctx context.Context, token string) error {
+	family, err := s.repo.RefreshFamilyOf(ctx, hashToken(token))
+	if err != nil || family.ID == "" {
+		return err
+	}
+	return s.repo.RevokeRefreshFamily(ctx, family.ID)
+}
+
+func (s *RefreshStore) newToken(familyID string) (string, repository.RefreshTokenRecord, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", repository.RefreshTokenRecord{}, err
+	}
+	return token, repository.RefreshTokenRecord{
+		Hash:      hashToken(token),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}, nil
+}
+
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
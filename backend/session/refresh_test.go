@@ -0,0 +1,143 @@
+package session_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/threehook/zkp-auth/backend/repository"
+	"github.com/threehook/zkp-auth/backend/session"
+)
+
+func newRefreshStore(ttl time.Duration) (*session.Store, *session.RefreshStore) {
+	repo := repository.NewMemoryUserRepo()
+	sessions := session.NewStore(repo)
+	return sessions, session.NewRefreshStore(sessions, repo, ttl)
+}
+
+func TestRefreshRotate(t *testing.T) {
+	ctx := context.Background()
+	_, refresh := newRefreshStore(time.Hour)
+
+	issued, err := refresh.Issue(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	owner, err := refresh.Owner(ctx, issued.Token)
+	if err != nil {
+		t.Fatalf("Owner: %v", err)
+	}
+	if owner.Username != "alice" || owner.FamilyID != issued.FamilyID {
+		t.Fatalf("Owner = %+v, want alice in family %s", owner, issued.FamilyID)
+	}
+
+	rotated, err := refresh.Rotate(ctx, issued.Token)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if rotated.Token == issued.Token || rotated.Token == "" {
+		t.Fatal("Rotate did not return a new token")
+	}
+	if rotated.Username != "alice" || rotated.FamilyID != issued.FamilyID {
+		t.Fatalf("Rotate = %+v, want alice in family %s", rotated, issued.FamilyID)
+	}
+	if _, err := refresh.Rotate(ctx, rotated.Token); err != nil {
+		t.Fatalf("Rotate of the new token: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	_, refresh := newRefreshStore(time.Hour)
+
+	stolen, err := refresh.Issue(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	other, err := refresh.Issue(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	newest, err := refresh.Rotate(ctx, stolen.Token)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	reused, err := refresh.Rotate(ctx, stolen.Token)
+	if !errors.Is(err, session.ErrRefreshTokenReused) {
+		t.Fatalf("Rotate of a rotated token: %v, want ErrRefreshTokenReused", err)
+	}
+	if reused.Username != "alice" || reused.FamilyID != stolen.FamilyID || reused.Token != "" {
+		t.Fatalf("reuse returned %+v, want only alice and family %s", reused, stolen.FamilyID)
+	}
+
+	if _, err := refresh.Rotate(ctx, newest.Token); !errors.Is(err, session.ErrRefreshTokenInvalid) {
+		t.Fatalf("newest token of a revoked family: %v, want ErrRefreshTokenInvalid", err)
+	}
+	if _, err := refresh.Rotate(ctx, other.Token); err != nil {
+		t.Fatalf("token of another login: %v", err)
+	}
+}
+
+func TestRefreshRevokeAllEndsFamilies(t *testing.T) {
+	ctx := context.Background()
+	sessions, refresh := newRefreshStore(time.Hour)
+
+	issued, err := refresh.Issue(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if _, err := sessions.RevokeAll(ctx, "alice"); err != nil {
+		t.Fatalf("RevokeAll: %v", err)
+	}
+	if _, err := refresh.Rotate(ctx, issued.Token); !errors.Is(err, session.ErrRefreshTokenInvalid) {
+		t.Fatalf("Rotate after RevokeAll: %v, want ErrRefreshTokenInvalid", err)
+	}
+
+	fresh, err := refresh.Issue(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if _, err := refresh.Rotate(ctx, fresh.Token); err != nil {
+		t.Fatalf("Rotate of a token issued after RevokeAll: %v", err)
+	}
+}
+
+func TestRefreshRevokeFamily(t *testing.T) {
+	ctx := context.Background()
+	_, refresh := newRefreshStore(time.Hour)
+
+	issued, err := refresh.Issue(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if err := refresh.RevokeFamily(ctx, issued.Token); err != nil {
+		t.Fatalf("RevokeFamily: %v", err)
+	}
+	if err := refresh.RevokeFamily(ctx, "unknown"); err != nil {
+		t.Fatalf("RevokeFamily of an unknown token: %v", err)
+	}
+	if _, err := refresh.Rotate(ctx, issued.Token); !errors.Is(err, session.ErrRefreshTokenInvalid) {
+		t.Fatalf("Rotate after RevokeFamily: %v, want ErrRefreshTokenInvalid", err)
+	}
+}
+
+func TestRefreshRejectsInvalidTokens(t *testing.T) {
+	ctx := context.Background()
+	// Tokens from this store have expired by the time they are issued
+	_, refresh := newRefreshStore(-time.Minute)
+
+	expired, err := refresh.Issue(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	for name, token := range map[string]string{"expired": expired.Token, "unknown": "unknown"} {
+		if _, err := refresh.Rotate(ctx, token); !errors.Is(err, session.ErrRefreshTokenInvalid) {
+			t.Errorf("Rotate of an %s token: %v, want ErrRefreshTokenInvalid", name, err)
+		}
+	}
+	if _, err := refresh.Owner(ctx, "unknown"); !errors.Is(err, session.ErrRefreshTokenInvalid) {
+		t.Fatalf("Owner of an unknown token: %v, want ErrRefreshTokenInvalid", err)
+	}
+}
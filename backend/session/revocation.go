@@ -0,0 +1,29 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/threehook/zkp-auth/backend/repository"
+)
+
+// RevocationStore remembers individually revoked access tokens by their jti.
+// An entry is only needed until the token's own expiry, after which the token
+// is rejected anyway; PurgeExpired drops it then
+type RevocationStore struct {
+	repo repository.SessionRepo
+}
+
+func NewRevocationStore(repo repository.SessionRepo) *RevocationStore {
+	return &RevocationStore{repo: repo}
+}
+
+// Revoke rejects the token with tokenID from now until expiresAt
+func (s *RevocationStore) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	return s.repo.RevokeToken(ctx, tokenID, expiresAt)
+}
+
+// IsRevoked reports whether the token with tokenID was revoked
+func (s *RevocationStore) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	return s.repo.TokenRevoked(ctx, tokenID)
+}
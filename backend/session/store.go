@@ -0,0 +1,40 @@
+package session
+
+import (
+	"context"
+
+	"github.com/threehook/zkp-auth/backend/repository"
+)
+
+// Store tracks a session version per user. Every access token carries the
+// version current when it was issued; revoking a user's sessions bumps the
+// version, which invalidates all of their outstanding tokens at once without
+// remembering each token. Versions live in the user store, so a revocation
+// survives restarts and reaches every instance sharing it
+type Store struct {
+	repo repository.SessionRepo
+}
+
+func NewStore(repo repository.SessionRepo) *Store {
+	return &Store{repo: repo}
+}
+
+// Version returns the session version new tokens for username must carry
+func (s *Store) Version(ctx context.Context, username string) (uint64, error) {
+	return s.repo.SessionVersion(ctx, username)
+}
+
+// RevokeAll invalidates every token issued to username so far and returns the
+// version tokens issued from now on carry
+func (s *Store) RevokeAll(ctx context.Context, username string) (uint64, error) {
+	return s.repo.BumpSessionVersion(ctx, username)
+}
+
+// Valid reports whether a token carrying version is still current for username
+func (s *Store) Valid(ctx context.Context, username string, version uint64) (bool, error) {
+	current, err := s.Version(ctx, username)
+	if err != nil {
+		return false, err
+	}
+	return version == current, nil
+}
@@ -0,0 +1,238 @@
+// Package signing holds the keys access tokens are signed with. Asymmetric
+// keys carry a kid header and are published as a JWK Set, so other services
+// can verify tokens without holding any secret
+package signing
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Supported JWS algorithms
+const (
+	AlgEdDSA = "EdDSA" // Ed25519
+	AlgES256 = "ES256" // ECDSA P-256 with SHA-256
+	AlgRS256 = "RS256" // RSASSA-PKCS1-v1_5 with SHA-256
+	AlgHS256 = "HS256" // shared secret; nothing is published
+)
+
+const (
+	minRSABits = 2048
+	rsaBits    = 3072 // size of generated RSA keys
+)
+
+// Config selects the asymmetric keys of a KeyRing
+type Config struct {
+	Algorithm string // of generated keys; loaded keys keep their own
+	Dir       string // PEM key files; generated keys are written here. Empty keeps keys in memory only
+
+	// Each key signs for Rotation. Its successor is generated and published
+	// Overlap before it takes over, so verifiers that cache the JWK Set learn
+	// it in time, and a retired key stays published for Overlap afterwards so
+	// the tokens it signed remain verifiable. Overlap must cover the access
+	// token lifetime and the verifiers' cache time. A Rotation of 0 leaves
+	// rotation to whoever provisions the key files; a key is only generated
+	// when there is none
+	Rotation time.Duration
+	Overlap  time.Duration
+}
+
+// Key is one signing key. Keys are ordered by CreatedAt; a key signs from
+// CreatedAt+Overlap until its successor does, except that the oldest key
+// signs from its creation
+type Key struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+
+	private crypto.Signer
+	public  crypto.PublicKey
+	jwk     JWK
+}
+
+// KeyRing signs access tokens with its current key and verifies them with
+// any key it still publishes
+type KeyRing struct {
+	mu     sync.RWMutex
+	cfg    Config
+	secret []byte // HS256 only
+	keys   []*Key // oldest first
+
+	// retired keys are not picked up again from files left in the key directory
+	retired map[string]bool
+}
+
+// NewHMACKeyRing signs and verifies with a shared HS256 secret, as before
+// asymmetric keys were supported
+func NewHMACKeyRing(secret []byte) *KeyRing {
+	return &KeyRing{secret: secret}
+}
+
+// OpenKeyRing loads every key in cfg.Dir and generates one if none is usable
+func OpenKeyRing(cfg Config) (*KeyRing, error) {
+	switch cfg.Algorithm {
+	case AlgEdDSA, AlgES256, AlgRS256:
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q (want EdDSA, ES256, RS256 or HS256)", cfg.Algorithm)
+	}
+	if cfg.Overlap < 0 || (cfg.Rotation != 0 && cfg.Rotation <= cfg.Overlap) {
+		return nil, fmt.Errorf("key rotation interval %s must be longer than the overlap %s", cfg.Rotation, cfg.Overlap)
+	}
+
+	r := &KeyRing{cfg: cfg, retired: make(map[string]bool)}
+	if err := r.Rotate(time.Now()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Run checks the rotation schedule every interval until ctx is done
+func (r *KeyRing) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Rotate(time.Now()); err != nil {
+				log.Printf("Signing key rotation failed: %v", err)
+			}
+		}
+	}
+}
+
+// Rotate picks up keys added to the key directory, e.g. by another instance
+// sharing it, generates the next key once it is due for publication and drops
+// keys whose successor has been signing for Overlap. It does nothing for an
+// HS256 key ring
+func (r *KeyRing) Rotate(now time.Time) error {
+	if r.secret != nil {
+		return nil
+	}
+
+	loaded, err := loadKeys(r.cfg.Dir)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range loaded {
+		if !r.retired[key.ID] && !slices.ContainsFunc(r.keys, func(known *Key) bool { return known.ID == key.ID }) {
+			r.keys = append(r.keys, key)
+		}
+	}
+	slices.SortStableFunc(r.keys, func(a, b *Key) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	n := len(r.keys)
+	if n == 0 || (r.cfg.Rotation > 0 && !now.Before(r.activeAt(n-1).Add(r.cfg.Rotation-r.cfg.Overlap))) {
+		key, err := generateKey(r.cfg.Algorithm, now)
+		if err != nil {
+			return err
+		}
+		if err := saveKey(r.cfg.Dir, key); err != nil {
+			return err
+		}
+		r.keys = append(r.keys, key)
+		log.Printf("🔑 Generated %s token signing key %s, signing from %s", key.Algorithm, key.ID,
+			r.activeAt(len(r.keys)-1).Format(time.RFC3339))
+	}
+
+	retired := 0
+	for i := 0; i < len(r.keys)-1; i++ {
+		if now.Before(r.activeAt(i + 1).Add(r.cfg.Overlap)) {
+			break
+		}
+		retired = i + 1
+	}
+	for _, key := range r.keys[:retired] {
+		if err := removeKey(r.cfg.Dir, key); err != nil {
+			log.Printf("Could not remove retired signing key %s: %v", key.ID, err)
+		}
+		r.retired[key.ID] = true
+		log.Printf("🔑 Retired token signing key %s", key.ID)
+	}
+	r.keys = r.keys[retired:]
+	return nil
+}
+
+// activeAt is when keys[i] starts signing
+func (r *KeyRing) activeAt(i int) time.Time {
+	if i == 0 {
+		return r.keys[0].CreatedAt
+	}
+	return r.keys[i].CreatedAt.Add(r.cfg.Overlap)
+}
+
+// signingKey is the newest key that has started signing
+func (r *KeyRing) signingKey(now time.Time) *Key {
+	for i := len(r.keys) - 1; i > 0; i-- {
+		if !now.Before(r.activeAt(i)) {
+			return r.keys[i]
+		}
+	}
+	return r.keys[0]
+}
+
+// Sign signs claims with the current key, naming it in the kid header
+func (r *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	if r.secret != nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(r.secret)
+	}
+
+	r.mu.RLock()
+	key := r.signingKey(time.Now())
+	r.mu.RUnlock()
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
+}
+
+// Keyfunc resolves the verification key of a token for jwt.Parse. The token's
+// algorithm must be the key's, so a public key is never used as an HMAC secret
+func (r *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if r.secret != nil {
+		if token.Method.Alg() != AlgHS256 {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return r.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := slices.IndexFunc(r.keys, func(key *Key) bool { return key.ID == kid })
+	if i < 0 {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != r.keys[i].Algorithm {
+		return nil, fmt.Errorf("signing method %s does not match key %s", token.Method.Alg(), kid)
+	}
+	return r.keys[i].public, nil
+}
+
+// JWKS returns the public keys: the current one, its pre-published successor
+// and any retired key still in its overlap. It is empty for an HS256 key ring
+func (r *KeyRing) JWKS() JWKSet {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	set := JWKSet{Keys: make([]JWK, 0, len(r.keys))}
+	for _, key := range r.keys {
+		set.Keys = append(set.Keys, key.jwk)
+	}
+	return set
+}
@@ -0,0 +1,248 @@
+package signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func openTestKeyRing(t *testing.T, algorithm, dir string) *KeyRing {
+	t.Helper()
+	r, err := OpenKeyRing(Config{Algorithm: algorithm, Dir: dir, Rotation: 10 * time.Hour, Overlap: 2 * time.Hour})
+	if err != nil {
+		t.Fatalf("OpenKeyRing: %v", err)
+	}
+	return r
+}
+
+func signTestToken(t *testing.T, r *KeyRing) string {
+	t.Helper()
+	token, err := r.Sign(jwt.RegisteredClaims{Subject: "alice"})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return token
+}
+
+func TestKeyRingSignsAndPublishes(t *testing.T) {
+	for _, algorithm := range []string{AlgEdDSA, AlgES256, AlgRS256} {
+		t.Run(algorithm, func(t *testing.T) {
+			r := openTestKeyRing(t, algorithm, t.TempDir())
+
+			parsed, err := jwt.Parse(signTestToken(t, r), r.Keyfunc)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if parsed.Header["kid"] != r.keys[0].ID || parsed.Method.Alg() != algorithm {
+				t.Fatalf("token header %v, want kid %s and alg %s", parsed.Header, r.keys[0].ID, algorithm)
+			}
+
+			set := r.JWKS()
+			if len(set.Keys) != 1 {
+				t.Fatalf("JWKS has %d keys, want 1", len(set.Keys))
+			}
+			pub, err := set.Keys[0].PublicKey()
+			if err != nil {
+				t.Fatalf("PublicKey: %v", err)
+			}
+			kid, err := Thumbprint(pub)
+			if err != nil {
+				t.Fatalf("Thumbprint: %v", err)
+			}
+			if kid != set.Keys[0].KeyID || set.Keys[0].Algorithm != algorithm {
+				t.Fatalf("published key %+v, want kid %s and alg %s", set.Keys[0], kid, algorithm)
+			}
+		})
+	}
+}
+
+func TestKeyRingReloadsKeys(t *testing.T) {
+	dir := t.TempDir()
+	r := openTestKeyRing(t, AlgEdDSA, dir)
+	token := signTestToken(t, r)
+
+	reopened := openTestKeyRing(t, AlgEdDSA, dir)
+	if len(reopened.keys) != 1 || reopened.keys[0].ID != r.keys[0].ID {
+		t.Fatalf("reopened key ring has %d keys, want the saved key %s", len(reopened.keys), r.keys[0].ID)
+	}
+	if _, err := jwt.Parse(token, reopened.Keyfunc); err != nil {
+		t.Fatalf("token from before the restart: %v", err)
+	}
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	dir := t.TempDir()
+	r := openTestKeyRing(t, AlgEdDSA, dir)
+	first := r.keys[0]
+	start := first.CreatedAt
+	token := signTestToken(t, r)
+
+	// The successor is published Overlap before it takes over at Rotation
+	if err := r.Rotate(start.Add(7 * time.Hour)); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if len(r.keys) != 1 {
+		t.Fatalf("%d keys before the successor is due, want 1", len(r.keys))
+	}
+	if err := r.Rotate(start.Add(8 * time.Hour)); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if len(r.keys) != 2 || len(r.JWKS().Keys) != 2 {
+		t.Fatalf("%d keys once the successor is due, want 2", len(r.keys))
+	}
+	second := r.keys[1]
+	if got := r.signingKey(start.Add(9 * time.Hour)); got != first {
+		t.Fatalf("signing key before the handover is %s, want %s", got.ID, first.ID)
+	}
+	if got := r.signingKey(start.Add(10 * time.Hour)); got != second {
+		t.Fatalf("signing key after the handover is %s, want %s", got.ID, second.ID)
+	}
+
+	// The old key stays published for Overlap after the handover
+	if err := r.Rotate(start.Add(11 * time.Hour)); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if _, err := jwt.Parse(token, r.Keyfunc); err != nil {
+		t.Fatalf("token of the previous key during the overlap: %v", err)
+	}
+
+	if err := r.Rotate(start.Add(12 * time.Hour)); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if len(r.keys) != 1 || r.keys[0] != second {
+		t.Fatalf("keys after the overlap: %d, want only %s", len(r.keys), second.ID)
+	}
+	if set := r.JWKS(); len(set.Keys) != 1 || set.Keys[0].KeyID != second.ID {
+		t.Fatalf("JWKS after the overlap: %+v, want only %s", set.Keys, second.ID)
+	}
+	if _, err := jwt.Parse(token, r.Keyfunc); err == nil {
+		t.Fatal("token signed by a retired key was accepted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, first.ID+".pem")); !os.IsNotExist(err) {
+		t.Fatalf("retired key file: %v, want it removed", err)
+	}
+}
+
+func TestKeyRingPicksUpSharedKeys(t *testing.T) {
+	dir := t.TempDir()
+	r := openTestKeyRing(t, AlgES256, dir)
+	other := openTestKeyRing(t, AlgES256, dir)
+	start := r.keys[0].CreatedAt
+
+	// One instance generates the successor, the other loads it from the directory
+	if err := r.Rotate(start.Add(8 * time.Hour)); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if err := other.Rotate(start.Add(8 * time.Hour)); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if len(other.keys) != 2 || other.keys[1].ID != r.keys[1].ID {
+		t.Fatalf("other instance has %d keys, want the shared successor %s", len(other.keys), r.keys[1].ID)
+	}
+}
+
+func TestKeyRingRejectsForeignTokens(t *testing.T) {
+	r := openTestKeyRing(t, AlgES256, t.TempDir())
+	key := r.keys[0]
+
+	hmac, err := NewHMACKeyRing([]byte("secret")).Sign(jwt.RegisteredClaims{Subject: "alice"})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	// An HS256 token naming the public key must not be verified with it as a secret
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: "alice"})
+	confused.Header["kid"] = key.ID
+	confusedToken, err := confused.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	stranger, err := generateKey(AlgES256, time.Now())
+	if err != nil {
+		t.Fatalf("generateKey: %v", err)
+	}
+	unknown := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{Subject: "alice"})
+	unknown.Header["kid"] = stranger.ID
+	unknownToken, err := unknown.SignedString(stranger.private)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	for name, token := range map[string]string{
+		"HS256 without kid":  hmac,
+		"HS256 naming a key": confusedToken,
+		"unknown kid":        unknownToken,
+	} {
+		if _, err := jwt.Parse(token, r.Keyfunc); err == nil {
+			t.Errorf("%s token was accepted", name)
+		}
+	}
+}
+
+func TestHMACKeyRing(t *testing.T) {
+	r := NewHMACKeyRing([]byte("secret"))
+	if _, err := jwt.Parse(signTestToken(t, r), r.Keyfunc); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if err := r.Rotate(time.Now()); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if keys := r.JWKS().Keys; len(keys) != 0 {
+		t.Fatalf("HS256 key ring published %d keys", len(keys))
+	}
+
+	asymmetric := openTestKeyRing(t, AlgEdDSA, "")
+	if _, err := jwt.Parse(signTestToken(t, asymmetric), r.Keyfunc); err == nil {
+		t.Fatal("HS256 key ring accepted an EdDSA token")
+	}
+}
+
+func TestOpenKeyRingLoadsProvisionedKeys(t *testing.T) {
+	dir := t.TempDir()
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "provisioned.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A SEC1 ECDSA key is used as is, whatever algorithm new keys would get
+	r, err := OpenKeyRing(Config{Algorithm: AlgEdDSA, Dir: dir, Overlap: time.Hour})
+	if err != nil {
+		t.Fatalf("OpenKeyRing: %v", err)
+	}
+	if len(r.keys) != 1 || r.keys[0].Algorithm != AlgES256 {
+		t.Fatalf("loaded %d keys, want the provisioned ES256 key", len(r.keys))
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.pem")); len(files) != 1 {
+		t.Fatalf("key directory holds %v, want only the provisioned key", files)
+	}
+}
+
+func TestOpenKeyRingRejectsBadConfig(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"unsupported algorithm":   {Algorithm: "none", Rotation: 10 * time.Hour, Overlap: time.Hour},
+		"HS256 as asymmetric":     {Algorithm: AlgHS256, Rotation: 10 * time.Hour, Overlap: time.Hour},
+		"rotation within overlap": {Algorithm: AlgEdDSA, Rotation: time.Hour, Overlap: time.Hour},
+		"negative overlap":        {Algorithm: AlgEdDSA, Overlap: -time.Hour},
+	} {
+		if _, err := OpenKeyRing(cfg); err == nil {
+			t.Errorf("OpenKeyRing accepted %s", name)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// createdHeader is the PEM header generated key files record their creation
+// time in. Files without it, e.g. made with openssl, use their modification time
+const createdHeader = "Created"
+
+// newKey derives the algorithm and key ID of a private key
+func newKey(private crypto.Signer, createdAt time.Time) (*Key, error) {
+	var algorithm string
+	switch pub := private.Public().(type) {
+	case ed25519.PublicKey:
+		algorithm = AlgEdDSA
+	case *ecdsa.PublicKey:
+		algorithm = AlgES256
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key is shorter than %d bits", minRSABits)
+		}
+		algorithm = AlgRS256
+	}
+
+	jwk, err := publicJWK(private.Public())
+	if err != nil {
+		return nil, err
+	}
+	id, err := Thumbprint(private.Public())
+	if err != nil {
+		return nil, err
+	}
+	jwk.KeyID = id
+	jwk.Algorithm = algorithm
+	jwk.Use = "sig"
+
+	return &Key{
+		ID:        id,
+		Algorithm: algorithm,
+		CreatedAt: createdAt.UTC().Truncate(time.Second),
+		private:   private,
+		public:    private.Public(),
+		jwk:       jwk,
+	}, nil
+}
+
+func generateKey(algorithm string, now time.Time) (*Key, error) {
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case AlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case AlgES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaBits)
+	default:
+		err = fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("generate signing key: %w", err)
+	}
+	return newKey(private, now)
+}
+
+// loadKeys reads every *.pem file in dir. A missing directory holds no keys
+func loadKeys(dir string) ([]*Key, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*Key
+	for _, path := range paths {
+		key, err := loadKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("load signing key %s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func loadKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+	if ec, ok := private.(*ecdsa.PrivateKey); ok && ec.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("unsupported ECDSA curve %s", ec.Curve.Params().Name)
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, block.Headers[createdHeader])
+	if err != nil {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		createdAt = info.ModTime()
+	}
+	return newKey(private, createdAt)
+}
+
+// saveKey writes a generated key to <dir>/<kid>.pem, readable only by the
+// owner. Nothing is written when dir is empty
+func saveKey(dir string, key *Key) error {
+	if dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("create signing key directory: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key.private)
+	if err != nil {
+		return err
+	}
+	data := pem.EncodeToMemory(&pem.Block{
+		Type:    "PRIVATE KEY",
+		Headers: map[string]string{createdHeader: key.CreatedAt.Format(time.RFC3339)},
+		Bytes:   der,
+	})
+
+	// Write to a temporary file first so a crash never leaves half a key behind
+	path := filepath.Join(dir, key.ID+".pem")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write signing key: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write signing key: %w", err)
+	}
+	return nil
+}
+
+// removeKey deletes the file saveKey wrote for key. Key files named otherwise
+// were provisioned by an operator and are left alone
+func removeKey(dir string, key *Key) error {
+	if dir == "" {
+		return nil
+	}
+	err := os.Remove(filepath.Join(dir, key.ID+".pem"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+// Package ginauth adapts tokenauth to Gin. It lives apart from tokenauth so
+// net/http services do not build Gin
+package ginauth
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/threehook/zkp-auth/backend/tokenauth"
+)
+
+const principalKey = "tokenauth.principal"
+
+// Middleware authenticates every request with v and requires the token to
+// grant all of scopes. Handlers find the caller with Principal, or with
+// tokenauth.FromContext on the request context
+func Middleware(v *tokenauth.Verifier, scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := v.VerifyRequest(c.Request)
+		if err == nil && !principal.HasScopes(scopes...) {
+			err = tokenauth.ScopeError(scopes)
+		}
+		if err != nil {
+			abort(c, err)
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Request = c.Request.WithContext(tokenauth.NewContext(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// RequireScopes narrows a route or group behind Middleware to tokens granting
+// all of scopes
+func RequireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := Principal(c)
+		if !ok {
+			abort(c, tokenauth.ErrMissingToken)
+			return
+		}
+		if !principal.HasScopes(scopes...) {
+			abort(c, tokenauth.ScopeError(scopes))
+			return
+		}
+		c.Next()
+	}
+}
+
+// Principal returns the caller Middleware authenticated
+func Principal(c *gin.Context) (*tokenauth.Principal, bool) {
+	principal, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	p, ok := principal.(*tokenauth.Principal)
+	return p, ok
+}
+
+func abort(c *gin.Context, err error) {
+	tokenauth.WriteError(c.Writer, err)
+	c.Abort()
+}
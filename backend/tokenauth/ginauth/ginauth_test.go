@@ -0,0 +1,69 @@
+package ginauth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/threehook/zkp-auth/backend/signing"
+	"github.com/threehook/zkp-auth/backend/tokenauth"
+	"github.com/threehook/zkp-auth/backend/tokenauth/ginauth"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secret := []byte("test-secret")
+	v, err := tokenauth.NewVerifier(tokenauth.Config{Secret: secret})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	token, err := signing.NewHMACKeyRing(secret).Sign(&tokenauth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "alice",
+			ID:        "jti-1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Scope: "user",
+	})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	router := gin.New()
+	whoami := func(c *gin.Context) {
+		principal, ok := ginauth.Principal(c)
+		fromContext, _ := tokenauth.FromContext(c.Request.Context())
+		if !ok || fromContext != principal {
+			t.Error("principal missing from the Gin or request context")
+		}
+		c.String(http.StatusOK, principal.Subject)
+	}
+	router.GET("/orders", ginauth.Middleware(v, "user"), whoami)
+	router.GET("/admin", ginauth.Middleware(v), ginauth.RequireScopes("admin"), whoami)
+	router.GET("/unprotected", ginauth.RequireScopes("user"), whoami)
+
+	for _, tc := range []struct {
+		path          string
+		authorization string
+		status        int
+	}{
+		{"/orders", "Bearer " + token, http.StatusOK},
+		{"/orders", "", http.StatusUnauthorized},
+		{"/orders", "Bearer " + token + "x", http.StatusUnauthorized},
+		{"/admin", "Bearer " + token, http.StatusForbidden},
+		{"/unprotected", "Bearer " + token, http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.authorization != "" {
+			req.Header.Set("Authorization", tc.authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tc.status {
+			t.Errorf("%s with %q: status %d, want %d (%s)", tc.path, tc.authorization, w.Code, tc.status, w.Body)
+		}
+	}
+}
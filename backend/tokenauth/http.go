@@ -0,0 +1,87 @@
+package tokenauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Middleware authenticates every request with v and requires the token to
+// grant all of scopes. Handlers find the caller with FromContext
+func (v *Verifier) Middleware(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := v.VerifyRequest(r)
+			if err == nil && !principal.HasScopes(scopes...) {
+				err = ScopeError(scopes)
+			}
+			if err != nil {
+				WriteError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), principal)))
+		})
+	}
+}
+
+// RequireScopes narrows a route behind Middleware to tokens granting all of
+// scopes
+func RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := FromContext(r.Context())
+			if !ok {
+				WriteError(w, ErrMissingToken)
+				return
+			}
+			if !principal.HasScopes(scopes...) {
+				WriteError(w, ScopeError(scopes))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ScopeError is an ErrInsufficientScope naming the scopes a route requires,
+// for the WWW-Authenticate challenge
+type ScopeError []string
+
+func (e ScopeError) Error() string {
+	return fmt.Sprintf("%v: requires %s", ErrInsufficientScope, strings.Join(e, " "))
+}
+
+func (e ScopeError) Unwrap() error {
+	return ErrInsufficientScope
+}
+
+// WriteError answers a request refused by Verify or a scope check, with the
+// status and WWW-Authenticate challenge of RFC 6750 and a JSON error body
+func WriteError(w http.ResponseWriter, err error) {
+	status, message, challenge := http.StatusUnauthorized, "Invalid token", `Bearer error="invalid_token"`
+	var scopes ScopeError
+	switch {
+	case errors.Is(err, ErrMissingToken):
+		message, challenge = "Missing token", "Bearer"
+	case errors.Is(err, ErrTokenRevoked):
+		message, challenge = "Token revoked", `Bearer error="invalid_token", error_description="token revoked"`
+	case errors.Is(err, ErrSessionRevoked):
+		message, challenge = "Session revoked", `Bearer error="invalid_token", error_description="session revoked"`
+	case errors.As(err, &scopes):
+		status, message = http.StatusForbidden, "Insufficient scope"
+		challenge = fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, strings.Join(scopes, " "))
+	case errors.Is(err, ErrInsufficientScope):
+		status, message, challenge = http.StatusForbidden, "Insufficient scope", `Bearer error="insufficient_scope"`
+	case errors.Is(err, ErrUnavailable):
+		status, message, challenge = http.StatusServiceUnavailable, "Could not check token", ""
+	}
+
+	if challenge != "" {
+		w.Header().Set("WWW-Authenticate", challenge)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,143 @@
+package tokenauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxIntrospectionSize = 64 << 10
+
+// Introspection is the issuer's answer about a token (RFC 7662). An inactive
+// token, revoked or otherwise refused, is described by Active alone
+type Introspection struct {
+	Active    bool     `json:"active"`
+	Subject   string   `json:"sub,omitempty"`
+	Scope     string   `json:"scope,omitempty"`
+	TokenID   string   `json:"jti,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  []string `json:"aud,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+}
+
+// NewIntrospection describes the active token p was verified from
+func NewIntrospection(p *Principal) Introspection {
+	in := Introspection{
+		Active:    true,
+		Subject:   p.Subject,
+		Scope:     strings.Join(p.Scopes, " "),
+		TokenID:   p.TokenID,
+		Issuer:    p.Issuer,
+		Audience:  p.Audience,
+		ExpiresAt: p.ExpiresAt.Unix(),
+		TokenType: "Bearer",
+	}
+	if !p.IssuedAt.IsZero() {
+		in.IssuedAt = p.IssuedAt.Unix()
+	}
+	return in
+}
+
+// IntrospectionChecker is a RevocationChecker for services apart from the
+// issuer. It posts each token to the issuer's introspection endpoint, e.g.
+// https://auth.example.com/api/token/introspect, authenticating with the
+// issuer's INTROSPECTION_SECRET, and treats an inactive token as revoked.
+// Active answers are cached per jti for CacheTTL, so a logout reaches the
+// service within that time; inactive ones until the token expires
+type IntrospectionChecker struct {
+	URL    string
+	Secret string
+
+	// CacheTTL of 0 asks the issuer about every request. HTTPClient defaults
+	// to a client with a 10 second timeout
+	CacheTTL   time.Duration
+	HTTPClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]introspected // by jti
+}
+
+type introspected struct {
+	err   error // nil or ErrTokenRevoked
+	until time.Time
+}
+
+// CheckRevoked asks the issuer about token unless a cached answer for its jti
+// is still fresh
+func (c *IntrospectionChecker) CheckRevoked(ctx context.Context, token string, claims *Claims) error {
+	now := time.Now()
+	c.mu.Lock()
+	cached, found := c.cache[claims.ID]
+	c.mu.Unlock()
+	if found && now.Before(cached.until) {
+		return cached.err
+	}
+
+	in, err := c.introspect(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	answer := introspected{until: now.Add(c.CacheTTL)}
+	if !in.Active {
+		answer = introspected{err: ErrTokenRevoked, until: claims.ExpiresAt.Time}
+	}
+	if now.Before(answer.until) {
+		c.store(claims.ID, answer)
+	}
+	return answer.err
+}
+
+func (c *IntrospectionChecker) introspect(ctx context.Context, token string) (Introspection, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Introspection{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+c.Secret)
+
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Introspection{}, fmt.Errorf("introspect token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Introspection{}, fmt.Errorf("introspect token: unexpected status %s", resp.Status)
+	}
+
+	var in Introspection
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxIntrospectionSize)).Decode(&in); err != nil {
+		return Introspection{}, fmt.Errorf("introspect token: decode: %w", err)
+	}
+	return in, nil
+}
+
+// store caches answer and drops the answers that went stale
+func (c *IntrospectionChecker) store(tokenID string, answer introspected) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cache == nil {
+		c.cache = make(map[string]introspected)
+	}
+	now := time.Now()
+	for id, cached := range c.cache {
+		if !now.Before(cached.until) {
+			delete(c.cache, id)
+		}
+	}
+	c.cache[tokenID] = answer
+}
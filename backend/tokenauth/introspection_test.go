@@ -0,0 +1,93 @@
+package tokenauth_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/threehook/zkp-auth/backend/tokenauth"
+)
+
+// introspectionServer answers like the issuer, counting the requests it gets
+func introspectionServer(t *testing.T, active map[string]bool, status int) (*httptest.Server, *atomic.Int64) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Authorization") != "Bearer introspection-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		json.NewEncoder(w).Encode(tokenauth.Introspection{Active: active[r.PostFormValue("token")]})
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestIntrospectionChecker(t *testing.T) {
+	live := sign(t, testClaims())
+	claims := testClaims()
+	claims.ID = "jti-2"
+	revoked := sign(t, claims)
+
+	server, requests := introspectionServer(t, map[string]bool{live: true}, http.StatusOK)
+	v := newVerifier(t, &tokenauth.IntrospectionChecker{
+		URL:      server.URL,
+		Secret:   "introspection-secret",
+		CacheTTL: time.Minute,
+	})
+
+	for range 2 {
+		if _, err := v.Verify(context.Background(), live); err != nil {
+			t.Fatalf("Verify of an active token: %v", err)
+		}
+		if _, err := v.Verify(context.Background(), revoked); !errors.Is(err, tokenauth.ErrTokenRevoked) {
+			t.Fatalf("Verify of an inactive token: %v, want ErrTokenRevoked", err)
+		}
+	}
+	if n := requests.Load(); n != 2 {
+		t.Fatalf("issuer was asked %d times, want once per token", n)
+	}
+}
+
+func TestIntrospectionCheckerWithoutCache(t *testing.T) {
+	server, requests := introspectionServer(t, map[string]bool{}, http.StatusOK)
+	checker := &tokenauth.IntrospectionChecker{URL: server.URL, Secret: "introspection-secret"}
+	live := sign(t, testClaims())
+	claims := testClaims()
+
+	// Only inactive answers are cached without a CacheTTL, as they never change
+	for range 2 {
+		if err := checker.CheckRevoked(context.Background(), live, &claims); !errors.Is(err, tokenauth.ErrTokenRevoked) {
+			t.Fatalf("CheckRevoked: %v, want ErrTokenRevoked", err)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("issuer was asked %d times, want 1", n)
+	}
+}
+
+func TestIntrospectionCheckerUnavailable(t *testing.T) {
+	for name, checker := range map[string]*tokenauth.IntrospectionChecker{
+		"failing issuer": {URL: mustServer(t, http.StatusInternalServerError), Secret: "introspection-secret"},
+		"wrong secret":   {URL: mustServer(t, http.StatusOK), Secret: "guess"},
+	} {
+		v := newVerifier(t, checker)
+		if _, err := v.Verify(context.Background(), sign(t, testClaims())); !errors.Is(err, tokenauth.ErrUnavailable) {
+			t.Errorf("%s: %v, want ErrUnavailable", name, err)
+		}
+	}
+}
+
+func mustServer(t *testing.T, status int) string {
+	server, _ := introspectionServer(t, map[string]bool{}, status)
+	return server.URL
+}
@@ -0,0 +1,184 @@
+package tokenauth
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/threehook/zkp-auth/backend/signing"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	maxJWKSSize = 1 << 20
+	// A fetch is not tied to the request that started it, so it gets its own
+	// deadline whatever the HTTP client's
+	jwksFetchTimeout = 10 * time.Second
+)
+
+type cachedKey struct {
+	algorithm string
+	public    crypto.PublicKey
+}
+
+// jwksCache holds the issuer's public keys. A token naming an unknown key
+// waits for a fetch, which concurrent lookups share, so a burst of tokens
+// signed by a newly published key causes one fetch, not one each. Known keys
+// never wait: the lock is not held during a fetch, and a stale set is
+// refreshed in the background while its keys keep verifying
+type jwksCache struct {
+	url        string
+	client     *http.Client
+	ttl        time.Duration
+	minRefresh time.Duration
+	fetches    singleflight.Group
+
+	mu          sync.Mutex
+	keys        map[string]cachedKey // by kid
+	fetchedAt   time.Time
+	attemptedAt time.Time // of the last fetch, successful or not
+	fetchErr    error     // of the last fetch
+}
+
+func newJWKSCache(cfg Config) *jwksCache {
+	c := &jwksCache{
+		url:        cfg.JWKSURL,
+		client:     cfg.HTTPClient,
+		ttl:        cfg.JWKSCacheTTL,
+		minRefresh: cfg.JWKSMinRefresh,
+	}
+	if c.client == nil {
+		c.client = &http.Client{Timeout: jwksFetchTimeout}
+	}
+	if c.ttl <= 0 {
+		c.ttl = 5 * time.Minute
+	}
+	if c.minRefresh <= 0 {
+		c.minRefresh = 30 * time.Second
+	}
+	return c
+}
+
+// key returns the public key named kid for a token signed with algorithm. If
+// ctx ends while waiting for a fetch, the fetch carries on for other callers
+func (c *jwksCache) key(ctx context.Context, kid, algorithm string) (crypto.PublicKey, error) {
+	key, known, fetchErr, stale := c.lookup(kid)
+	switch {
+	case !known:
+		select {
+		case <-c.fetches.DoChan("jwks", c.refresh):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: fetch JWK Set: %w", ErrUnavailable, ctx.Err())
+		}
+		key, known, fetchErr, _ = c.lookup(kid)
+	case stale:
+		c.fetches.DoChan("jwks", c.refresh)
+	}
+
+	if !known {
+		if fetchErr != nil {
+			return nil, fmt.Errorf("%w: fetch JWK Set: %v", ErrUnavailable, fetchErr)
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if algorithm != key.algorithm {
+		return nil, fmt.Errorf("signing method %s does not match key %s", algorithm, kid)
+	}
+	return key.public, nil
+}
+
+// lookup returns the cached key named kid, the error of the last fetch and
+// whether the set is older than the cache TTL
+func (c *jwksCache) lookup(kid string) (key cachedKey, known bool, fetchErr error, stale bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, known = c.keys[kid]
+	return key, known, c.fetchErr, !time.Now().Before(c.fetchedAt.Add(c.ttl))
+}
+
+// refresh fetches the set unless the last fetch started less than MinRefresh
+// ago. Its result has no value; callers look the key up again
+func (c *jwksCache) refresh() (any, error) {
+	c.mu.Lock()
+	if time.Now().Before(c.attemptedAt.Add(c.minRefresh)) {
+		c.mu.Unlock()
+		return nil, nil
+	}
+	c.attemptedAt = time.Now()
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+	keys, err := c.fetch(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetchErr = err
+	if err != nil {
+		// Keep verifying with the keys we have; the issuer publishes a key
+		// long before it signs and keeps it long after
+		log.Printf("Could not refresh JWK Set from %s: %v", c.url, err)
+		return nil, nil
+	}
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	return nil, nil
+}
+
+func (c *jwksCache) fetch(ctx context.Context) (map[string]cachedKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var set signing.JWKSet
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxJWKSSize)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	keys := make(map[string]cachedKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		public, err := jwk.PublicKey()
+		if err != nil {
+			// One key we cannot use must not lock out tokens signed by the others
+			log.Printf("Skipping JWK %s from %s: %v", jwk.KeyID, c.url, err)
+			continue
+		}
+		keys[jwk.KeyID] = cachedKey{algorithm: jwkAlgorithm(jwk), public: public}
+	}
+	return keys, nil
+}
+
+// jwkAlgorithm is the JWS algorithm a key is used with. The alg member is
+// optional, so it falls back to the only algorithm each key type is accepted for
+func jwkAlgorithm(jwk signing.JWK) string {
+	if jwk.Algorithm != "" {
+		return jwk.Algorithm
+	}
+	switch jwk.KeyType {
+	case "OKP":
+		return signing.AlgEdDSA
+	case "EC":
+		return signing.AlgES256
+	default:
+		return signing.AlgRS256
+	}
+}
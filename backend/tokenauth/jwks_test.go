@@ -0,0 +1,222 @@
+package tokenauth_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/threehook/zkp-auth/backend/signing"
+	"github.com/threehook/zkp-auth/backend/tokenauth"
+)
+
+// jwksServer publishes the keys of whichever key ring is current, counting
+// fetches. While block is set, requests wait for it to be closed
+type jwksServer struct {
+	*httptest.Server
+	keys    atomic.Pointer[signing.KeyRing]
+	fetches atomic.Int64
+	fail    atomic.Bool
+	block   atomic.Pointer[chan struct{}]
+}
+
+func newJWKSServer(t *testing.T, keys *signing.KeyRing) *jwksServer {
+	s := &jwksServer{}
+	s.keys.Store(keys)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		if block := s.block.Load(); block != nil {
+			<-*block
+		}
+		if s.fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(s.keys.Load().JWKS())
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newKeyRing(t *testing.T) *signing.KeyRing {
+	t.Helper()
+	keys, err := signing.OpenKeyRing(signing.Config{Algorithm: signing.AlgEdDSA, Overlap: time.Hour})
+	if err != nil {
+		t.Fatalf("OpenKeyRing: %v", err)
+	}
+	return keys
+}
+
+func signWith(t *testing.T, keys *signing.KeyRing) string {
+	t.Helper()
+	claims := testClaims()
+	token, err := keys.Sign(&claims)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return token
+}
+
+func newJWKSVerifier(t *testing.T, url string, ttl, minRefresh time.Duration) *tokenauth.Verifier {
+	t.Helper()
+	v, err := tokenauth.NewVerifier(tokenauth.Config{JWKSURL: url, JWKSCacheTTL: ttl, JWKSMinRefresh: minRefresh})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return v
+}
+
+func TestJWKSCachesKeys(t *testing.T) {
+	keys := newKeyRing(t)
+	server := newJWKSServer(t, keys)
+	v := newJWKSVerifier(t, server.URL, time.Hour, time.Hour)
+
+	token := signWith(t, keys)
+	for range 3 {
+		if _, err := v.Verify(context.Background(), token); err != nil {
+			t.Fatalf("Verify: %v", err)
+		}
+	}
+	if n := server.fetches.Load(); n != 1 {
+		t.Fatalf("JWK Set fetched %d times, want 1", n)
+	}
+
+	forged := signWith(t, newKeyRing(t))
+	if _, err := v.Verify(context.Background(), forged); !errors.Is(err, tokenauth.ErrInvalidToken) {
+		t.Fatalf("token of an unpublished key: %v, want ErrInvalidToken", err)
+	}
+	if n := server.fetches.Load(); n != 1 {
+		t.Fatalf("unknown key fetched the set again within JWKSMinRefresh (%d fetches)", n)
+	}
+}
+
+func TestJWKSFetchesNewKeys(t *testing.T) {
+	server := newJWKSServer(t, newKeyRing(t))
+	v := newJWKSVerifier(t, server.URL, time.Hour, 50*time.Millisecond)
+	if _, err := v.Verify(context.Background(), signWith(t, server.keys.Load())); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// A burst of tokens from a newly published key shares one fetch
+	rotated := newKeyRing(t)
+	server.keys.Store(rotated)
+	token := signWith(t, rotated)
+	block := make(chan struct{})
+	server.block.Store(&block)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := v.Verify(context.Background(), token)
+			errs <- err
+		}()
+	}
+	waitFor(t, func() bool { return server.fetches.Load() == 2 })
+	server.block.Store(nil)
+	close(block)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Verify with the new key: %v", err)
+		}
+	}
+	if n := server.fetches.Load(); n != 2 {
+		t.Fatalf("JWK Set fetched %d times, want one fetch for the burst", n)
+	}
+}
+
+func TestJWKSFetchDoesNotBlockKnownKeys(t *testing.T) {
+	keys := newKeyRing(t)
+	server := newJWKSServer(t, keys)
+	v := newJWKSVerifier(t, server.URL, time.Hour, time.Nanosecond)
+	known := signWith(t, keys)
+	if _, err := v.Verify(context.Background(), known); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	block := make(chan struct{})
+	server.block.Store(&block)
+	defer close(block)
+
+	// A token naming an unknown key starts a fetch the issuer never answers
+	ctx, cancel := context.WithCancel(context.Background())
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := v.Verify(ctx, signWith(t, newKeyRing(t)))
+		waiting <- err
+	}()
+	waitFor(t, func() bool { return server.fetches.Load() == 2 })
+
+	if _, err := v.Verify(context.Background(), known); err != nil {
+		t.Fatalf("Verify of a known key during a fetch: %v", err)
+	}
+
+	cancel()
+	select {
+	case err := <-waiting:
+		if !errors.Is(err, tokenauth.ErrUnavailable) || !errors.Is(err, context.Canceled) {
+			t.Fatalf("Verify canceled during a fetch: %v, want ErrUnavailable and context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("canceled Verify kept waiting for the fetch")
+	}
+}
+
+func TestJWKSFetchFailure(t *testing.T) {
+	keys := newKeyRing(t)
+	server := newJWKSServer(t, keys)
+	v := newJWKSVerifier(t, server.URL, time.Nanosecond, time.Nanosecond)
+	known := signWith(t, keys)
+	if _, err := v.Verify(context.Background(), known); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	server.fail.Store(true)
+	// Stale keys keep working while the issuer is down
+	if _, err := v.Verify(context.Background(), known); err != nil {
+		t.Fatalf("Verify with a stale set and a failing issuer: %v", err)
+	}
+	if _, err := v.Verify(context.Background(), signWith(t, newKeyRing(t))); !errors.Is(err, tokenauth.ErrUnavailable) {
+		t.Fatalf("unknown key with a failing issuer: %v, want ErrUnavailable", err)
+	}
+}
+
+func TestJWKSRejectsAlgorithmMismatch(t *testing.T) {
+	keys := newKeyRing(t)
+	server := newJWKSServer(t, keys)
+	v := newJWKSVerifier(t, server.URL, time.Hour, time.Hour)
+
+	kid := keys.JWKS().Keys[0].KeyID
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	confused.Header["kid"] = kid
+	token, err := confused.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	if _, err := v.Verify(context.Background(), token); !errors.Is(err, tokenauth.ErrInvalidToken) {
+		t.Fatalf("HS256 token naming an EdDSA key: %v, want ErrInvalidToken", err)
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within a second")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
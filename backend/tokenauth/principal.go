@@ -0,0 +1,62 @@
+package tokenauth
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Principal is the authenticated caller a verified token describes
+type Principal struct {
+	Subject   string // username
+	TokenID   string // jti, the key for revoking this token
+	Issuer    string
+	Audience  []string
+	Scopes    []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+
+	// SessionVersion only means something to the issuer, which compares it
+	// with the user's current sessions
+	SessionVersion uint64
+}
+
+func newPrincipal(claims *Claims) *Principal {
+	p := &Principal{
+		Subject:        claims.Subject,
+		TokenID:        claims.ID,
+		Issuer:         claims.Issuer,
+		Audience:       claims.Audience,
+		Scopes:         strings.Fields(claims.Scope),
+		ExpiresAt:      claims.ExpiresAt.Time,
+		SessionVersion: claims.SessionVersion,
+	}
+	if claims.IssuedAt != nil {
+		p.IssuedAt = claims.IssuedAt.Time
+	}
+	return p
+}
+
+// HasScopes reports whether the token grants every one of scopes
+func (p *Principal) HasScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(p.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying p
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal the middleware stored in ctx
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
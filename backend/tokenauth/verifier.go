@@ -0,0 +1,179 @@
+// Package tokenauth verifies the access tokens zkp-auth issues, for services
+// that accept them. A Verifier checks the signature against a shared HS256
+// secret or the issuer's JWK Set, the issuer, audience and expiry, and
+// optionally whether the token was revoked, locally in the issuer or by asking
+// its introspection endpoint; the net/http middleware here and
+// the Gin middleware in ginauth then enforce scopes and put a Principal in
+// the request context
+package tokenauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/threehook/zkp-auth/backend/signing"
+)
+
+var (
+	ErrMissingToken = errors.New("missing bearer token")
+	// ErrInvalidToken covers bad signatures, unknown keys, expired tokens and
+	// wrong issuer, audience or claims
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenRevoked = errors.New("token revoked")
+	// ErrSessionRevoked means the token was revoked together with every other
+	// token of its user, e.g. by a password change
+	ErrSessionRevoked    = errors.New("session revoked")
+	ErrInsufficientScope = errors.New("insufficient scope")
+	// ErrUnavailable means the token could not be checked: the JWK Set could
+	// not be fetched or the revocation check failed
+	ErrUnavailable = errors.New("could not check token")
+)
+
+// Claims are the claims of a zkp-auth access token
+type Claims struct {
+	jwt.RegisteredClaims
+	Scope          string `json:"scope,omitempty"` // space-separated, as in RFC 9068
+	SessionVersion uint64 `json:"sv"`
+}
+
+// RevocationChecker tells whether a token that passed every other check was
+// revoked since it was issued: on its own by its jti, or with all of its
+// user's sessions through its session version. CheckRevoked returns nil for a
+// live token and ErrTokenRevoked or ErrSessionRevoked for a revoked one; any
+// other error means it could not tell. The issuer uses session.TokenChecker,
+// other services an IntrospectionChecker
+type RevocationChecker interface {
+	CheckRevoked(ctx context.Context, token string, claims *Claims) error
+}
+
+// Config selects how a Verifier obtains keys and what it requires of tokens.
+// Exactly one of Secret, JWKSURL and Keyfunc must be set
+type Config struct {
+	Secret  []byte // HS256 secret shared with the issuer
+	JWKSURL string // e.g. https://auth.example.com/.well-known/jwks.json
+	Keyfunc jwt.Keyfunc
+
+	// JWK Set caching: the set is fetched again after JWKSCacheTTL (default 5
+	// minutes), or when a token names an unknown key, but not more often than
+	// every JWKSMinRefresh (default 30 seconds). HTTPClient defaults to a
+	// client with a 10 second timeout
+	JWKSCacheTTL   time.Duration
+	JWKSMinRefresh time.Duration
+	HTTPClient     *http.Client
+
+	Issuer   string // required iss; empty accepts any
+	Audience string // required in aud; empty accepts any
+
+	// Optional; without it a revoked token is accepted until it expires
+	Revocations RevocationChecker
+}
+
+// Verifier validates access tokens. It is safe for concurrent use
+type Verifier struct {
+	cfg     Config
+	jwks    *jwksCache
+	methods []string
+}
+
+func NewVerifier(cfg Config) (*Verifier, error) {
+	sources := 0
+	for _, set := range []bool{cfg.Secret != nil, cfg.JWKSURL != "", cfg.Keyfunc != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return nil, errors.New("tokenauth: exactly one of Secret, JWKSURL and Keyfunc must be set")
+	}
+
+	v := &Verifier{cfg: cfg}
+	switch {
+	case cfg.Secret != nil:
+		v.methods = []string{signing.AlgHS256}
+	case cfg.JWKSURL != "":
+		v.jwks = newJWKSCache(cfg)
+		v.methods = []string{signing.AlgEdDSA, signing.AlgES256, signing.AlgRS256}
+	}
+	return v, nil
+}
+
+// Verify checks tokenString and returns its principal. Errors wrap one of
+// ErrInvalidToken, ErrTokenRevoked, ErrSessionRevoked and ErrUnavailable
+func (v *Verifier) Verify(ctx context.Context, tokenString string) (*Principal, error) {
+	var parserOptions []jwt.ParserOption
+	if v.methods != nil {
+		parserOptions = append(parserOptions, jwt.WithValidMethods(v.methods))
+	}
+
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, v.keyfunc(ctx), parserOptions...)
+	if errors.Is(err, ErrUnavailable) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	// Without a jti or expiry the token could not be revoked individually
+	if claims.Subject == "" || claims.ID == "" || claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("%w: sub, jti and exp are required", ErrInvalidToken)
+	}
+	if v.cfg.Issuer != "" && claims.Issuer != v.cfg.Issuer {
+		return nil, fmt.Errorf("%w: issuer %q", ErrInvalidToken, claims.Issuer)
+	}
+	if v.cfg.Audience != "" && !claims.VerifyAudience(v.cfg.Audience, true) {
+		return nil, fmt.Errorf("%w: audience %q", ErrInvalidToken, claims.Audience)
+	}
+
+	if v.cfg.Revocations != nil {
+		err := v.cfg.Revocations.CheckRevoked(ctx, tokenString, &claims)
+		if errors.Is(err, ErrTokenRevoked) || errors.Is(err, ErrSessionRevoked) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+	}
+
+	return newPrincipal(&claims), nil
+}
+
+// VerifyRequest verifies the bearer token in the Authorization header of r
+func (v *Verifier) VerifyRequest(r *http.Request) (*Principal, error) {
+	tokenString, ok := bearerToken(r.Header.Get("Authorization"))
+	if !ok {
+		return nil, ErrMissingToken
+	}
+	return v.Verify(r.Context(), tokenString)
+}
+
+func (v *Verifier) keyfunc(ctx context.Context) jwt.Keyfunc {
+	switch {
+	case v.cfg.Keyfunc != nil:
+		return v.cfg.Keyfunc
+	case v.jwks != nil:
+		return func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return v.jwks.key(ctx, kid, token.Method.Alg())
+		}
+	default:
+		return func(*jwt.Token) (interface{}, error) {
+			return v.cfg.Secret, nil
+		}
+	}
+}
+
+// bearerToken extracts the token from an Authorization header. A bare token
+// without the scheme is accepted too, as the issuer's own API always has
+func bearerToken(header string) (string, bool) {
+	if scheme, token, found := strings.Cut(header, " "); found && strings.EqualFold(scheme, "Bearer") {
+		header = token
+	}
+	header = strings.TrimSpace(header)
+	return header, header != ""
+}
@@ -0,0 +1,178 @@
+package tokenauth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/threehook/zkp-auth/backend/signing"
+	"github.com/threehook/zkp-auth/backend/tokenauth"
+)
+
+var secret = []byte("test-secret")
+
+// checkerFunc adapts a function to tokenauth.RevocationChecker
+type checkerFunc func(claims *tokenauth.Claims) error
+
+func (f checkerFunc) CheckRevoked(ctx context.Context, token string, claims *tokenauth.Claims) error {
+	return f(claims)
+}
+
+func testClaims() tokenauth.Claims {
+	now := time.Now()
+	return tokenauth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "alice",
+			ID:        "jti-1",
+			Issuer:    "zkp-auth",
+			Audience:  jwt.ClaimStrings{"orders"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		Scope:          "user orders:read",
+		SessionVersion: 2,
+	}
+}
+
+func sign(t *testing.T, claims tokenauth.Claims) string {
+	t.Helper()
+	token, err := signing.NewHMACKeyRing(secret).Sign(&claims)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return token
+}
+
+func newVerifier(t *testing.T, revocations tokenauth.RevocationChecker) *tokenauth.Verifier {
+	t.Helper()
+	v, err := tokenauth.NewVerifier(tokenauth.Config{
+		Secret:      secret,
+		Issuer:      "zkp-auth",
+		Audience:    "orders",
+		Revocations: revocations,
+	})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return v
+}
+
+func TestVerifyAcceptsValidToken(t *testing.T) {
+	var checked *tokenauth.Claims
+	v := newVerifier(t, checkerFunc(func(claims *tokenauth.Claims) error {
+		checked = claims
+		return nil
+	}))
+
+	principal, err := v.Verify(context.Background(), sign(t, testClaims()))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if principal.Subject != "alice" || principal.TokenID != "jti-1" || principal.SessionVersion != 2 {
+		t.Fatalf("principal = %+v", principal)
+	}
+	if !principal.HasScopes("user", "orders:read") || principal.HasScopes("admin") {
+		t.Fatalf("scopes = %v", principal.Scopes)
+	}
+	if checked == nil || checked.ID != "jti-1" || checked.SessionVersion != 2 {
+		t.Fatalf("revocation checker saw %+v", checked)
+	}
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	v := newVerifier(t, nil)
+
+	for name, mutate := range map[string]func(*tokenauth.Claims){
+		"expired":        func(c *tokenauth.Claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) },
+		"wrong issuer":   func(c *tokenauth.Claims) { c.Issuer = "someone-else" },
+		"wrong audience": func(c *tokenauth.Claims) { c.Audience = jwt.ClaimStrings{"billing"} },
+		"without jti":    func(c *tokenauth.Claims) { c.ID = "" },
+		"without exp":    func(c *tokenauth.Claims) { c.ExpiresAt = nil },
+	} {
+		claims := testClaims()
+		mutate(&claims)
+		if _, err := v.Verify(context.Background(), sign(t, claims)); !errors.Is(err, tokenauth.ErrInvalidToken) {
+			t.Errorf("%s token: %v, want ErrInvalidToken", name, err)
+		}
+	}
+
+	forged, err := signing.NewHMACKeyRing([]byte("other-secret")).Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if _, err := v.Verify(context.Background(), forged); !errors.Is(err, tokenauth.ErrInvalidToken) {
+		t.Errorf("token with a foreign signature: %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestVerifyChecksRevocation(t *testing.T) {
+	for _, want := range []error{tokenauth.ErrTokenRevoked, tokenauth.ErrSessionRevoked} {
+		v := newVerifier(t, checkerFunc(func(*tokenauth.Claims) error { return want }))
+		if _, err := v.Verify(context.Background(), sign(t, testClaims())); !errors.Is(err, want) {
+			t.Errorf("Verify: %v, want %v", err, want)
+		}
+	}
+
+	v := newVerifier(t, checkerFunc(func(*tokenauth.Claims) error { return errors.New("store down") }))
+	if _, err := v.Verify(context.Background(), sign(t, testClaims())); !errors.Is(err, tokenauth.ErrUnavailable) {
+		t.Errorf("Verify with a failing checker: %v, want ErrUnavailable", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	revoked := map[string]error{"jti-revoked": tokenauth.ErrTokenRevoked, "jti-session": tokenauth.ErrSessionRevoked}
+	v := newVerifier(t, checkerFunc(func(claims *tokenauth.Claims) error { return revoked[claims.ID] }))
+	handler := v.Middleware("user")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := tokenauth.FromContext(r.Context())
+		if !ok {
+			t.Error("handler ran without a principal")
+			return
+		}
+		w.Write([]byte(principal.Subject))
+	}))
+	admin := v.Middleware()(tokenauth.RequireScopes("admin")(handler))
+
+	tokenWith := func(mutate func(*tokenauth.Claims)) string {
+		claims := testClaims()
+		mutate(&claims)
+		return "Bearer " + sign(t, claims)
+	}
+	valid := tokenWith(func(*tokenauth.Claims) {})
+
+	for _, tc := range []struct {
+		name          string
+		handler       http.Handler
+		authorization string
+		status        int
+		challenge     string
+	}{
+		{"valid", handler, valid, http.StatusOK, ""},
+		{"missing token", handler, "", http.StatusUnauthorized, "Bearer"},
+		{"invalid token", handler, "Bearer not-a-token", http.StatusUnauthorized, `Bearer error="invalid_token"`},
+		{"revoked token", handler, tokenWith(func(c *tokenauth.Claims) { c.ID = "jti-revoked" }),
+			http.StatusUnauthorized, `Bearer error="invalid_token", error_description="token revoked"`},
+		{"revoked session", handler, tokenWith(func(c *tokenauth.Claims) { c.ID = "jti-session" }),
+			http.StatusUnauthorized, `Bearer error="invalid_token", error_description="session revoked"`},
+		{"missing scope", handler, tokenWith(func(c *tokenauth.Claims) { c.Scope = "orders:read" }),
+			http.StatusForbidden, `Bearer error="insufficient_scope", scope="user"`},
+		{"missing route scope", admin, valid, http.StatusForbidden, `Bearer error="insufficient_scope", scope="admin"`},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		if tc.authorization != "" {
+			req.Header.Set("Authorization", tc.authorization)
+		}
+		w := httptest.NewRecorder()
+		tc.handler.ServeHTTP(w, req)
+
+		if w.Code != tc.status {
+			t.Errorf("%s: status %d, want %d (%s)", tc.name, w.Code, tc.status, w.Body)
+		}
+		if challenge := w.Header().Get("WWW-Authenticate"); challenge != tc.challenge {
+			t.Errorf("%s: WWW-Authenticate %q, want %q", tc.name, challenge, tc.challenge)
+		}
+	}
+}
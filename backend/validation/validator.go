@@ -2,11 +2,16 @@ package validation
 
 import (
 	"fmt"
+	"math/big"
 	"regexp"
 	"strings"
 	"unicode/utf8"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 )
 
+const maxPublicSignals = 32
+
 type Validator struct {
 	Errors map[string]string
 }
@@ -60,8 +65,9 @@ func (v *Validator) ValidatePassword(password string) {
 		return
 	}
 
-	if utf8.RuneCountInString(password) > 100 {
-		v.AddError("password", "password must be less than 100 characters")
+	// The password is packed into a single field element for the circuit
+	if len(password) > 31 {
+		v.AddError("password", "password must be at most 31 bytes")
 		return
 	}
 }
@@ -73,19 +79,27 @@ func (v *Validator) ValidateProofStructure(proof map[string]interface{}) {
 		return
 	}
 
-	// Check required proof components
-	required := []string{"pi_a", "pi_b", "pi_c", "protocol", "curve"}
+	// The protocol decides which components a proof must carry
+	protocol, _ := proof["protocol"].(string)
+	var required []string
+	switch protocol {
+	case "groth16":
+		required = []string{"pi_a", "pi_b", "pi_c"}
+	case "plonk":
+		required = []string{"A", "B", "C", "Z", "T1", "T2", "T3", "Wxi", "Wxiw",
+			"eval_a", "eval_b", "eval_c", "eval_s1", "eval_s2", "eval_zw"}
+	case "":
+		v.AddError("proof", "proof missing required field: protocol")
+	default:
+		v.AddError("proof", "only groth16 and plonk protocols are supported")
+	}
+	required = append(required, "curve")
 	for _, field := range required {
 		if proof[field] == nil {
 			v.AddError("proof", fmt.Sprintf("proof missing required field: %s", field))
 		}
 	}
 
-	// Validate protocol and curve
-	if protocol, ok := proof["protocol"].(string); ok && protocol != "groth16" {
-		v.AddError("proof", "only groth16 protocol is supported")
-	}
-
 	if curve, ok := proof["curve"].(string); ok && curve != "bn128" {
 		v.AddError("proof", "only bn128 curve is supported")
 	}
@@ -98,8 +112,9 @@ func (v *Validator) ValidatePublicSignals(publicSignals []interface{}) {
 		return
 	}
 
-	if len(publicSignals) != 4 {
-		v.AddError("publicSignals", "expected exactly 4 public signals")
+	// The exact count depends on the circuit layout and is checked by the verifier
+	if len(publicSignals) > maxPublicSignals {
+		v.AddError("publicSignals", fmt.Sprintf("at most %d public signals are allowed", maxPublicSignals))
 		return
 	}
 
@@ -111,6 +126,54 @@ func (v *Validator) ValidatePublicSignals(publicSignals []interface{}) {
 	}
 }
 
+// ValidateCircuit validates the optional circuit selector of a proof
+func (v *Validator) ValidateCircuit(circuitID string, version int) {
+	validCircuitID := regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+	if circuitID != "" && !validCircuitID.MatchString(circuitID) {
+		v.AddError("circuitId", "circuit ID can only contain lowercase letters, numbers, underscores, and hyphens")
+	}
+
+	if version < 0 {
+		v.AddError("circuitVersion", "circuit version must not be negative")
+	}
+}
+
+// ValidateFieldElement checks that value is a canonical decimal BN254 scalar
+func (v *Validator) ValidateFieldElement(key, value string) {
+	if value == "" {
+		v.AddError(key, fmt.Sprintf("%s is required", key))
+		return
+	}
+
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok || n.Sign() < 0 || n.String() != value {
+		v.AddError(key, fmt.Sprintf("%s must be a decimal number", key))
+		return
+	}
+
+	if n.Cmp(fr.Modulus()) >= 0 {
+		v.AddError(key, fmt.Sprintf("%s must be smaller than the field modulus", key))
+	}
+}
+
+// ValidateRecoveryCodes checks recovery code commitments: at most max of them,
+// each a field element and none repeated
+func (v *Validator) ValidateRecoveryCodes(commitments []string, max int) {
+	if len(commitments) > max {
+		v.AddError("recoveryCodes", fmt.Sprintf("at most %d recovery codes are allowed", max))
+		return
+	}
+
+	seen := make(map[string]bool, len(commitments))
+	for i, commitment := range commitments {
+		v.ValidateFieldElement(fmt.Sprintf("recoveryCodes[%d]", i), commitment)
+		if seen[commitment] {
+			v.AddError("recoveryCodes", "recovery codes must be distinct")
+		}
+		seen[commitment] = true
+	}
+}
+
 // ValidateNonce validates proof nonce format
 func (v *Validator) ValidateNonce(nonce string) {
 	nonce = strings.TrimSpace(nonce)
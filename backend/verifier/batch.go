@@ -0,0 +1,120 @@
+package verifier
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// batchScalarBits is the size of the random coefficients; a forged proof
+// slips through a batch with probability at most 2^-128
+const batchScalarBits = 128
+
+// BatchVerify checks several proofs against the same verifying key with a
+// single multi-pairing over a random linear combination of their equations:
+//
+//	Π e(rᵢ·Arᵢ, Bsᵢ) · e(Σ rᵢ·Krsᵢ, -δ) · e(Σ rᵢ·kSumᵢ, -γ) · e(-(Σ rᵢ)·α, β) == 1
+//
+// It returns one error per proof. When the combined check fails every proof is
+// verified on its own to pinpoint the bad ones.
+func BatchVerify(vk *VerifyingKey, proofs []*Proof, publicWitnesses [][]fr.Element) []error {
+	errs := make([]error, len(proofs))
+	if len(publicWitnesses) != len(proofs) {
+		for i := range errs {
+			errs[i] = fmt.Errorf("got %d proofs but %d public witnesses", len(proofs), len(publicWitnesses))
+		}
+		return errs
+	}
+
+	// Proofs that cannot take part in the combined check are verified alone
+	var batch []int
+	for i := range proofs {
+		switch {
+		case !proofs[i].isValid():
+			errs[i] = fmt.Errorf("proof points not in correct subgroup")
+		case len(publicWitnesses[i]) != len(vk.G1.K)-1:
+			errs[i] = fmt.Errorf("invalid witness size, got %d, expected %d", len(publicWitnesses[i]), len(vk.G1.K)-1)
+		case len(proofs[i].Commitments) > 0 || len(vk.CommitmentKeys) > 0:
+			errs[i] = Verify(proofs[i], vk, publicWitnesses[i])
+		default:
+			batch = append(batch, i)
+		}
+	}
+
+	if len(batch) == 1 {
+		errs[batch[0]] = Verify(proofs[batch[0]], vk, publicWitnesses[batch[0]])
+		return errs
+	}
+	if len(batch) == 0 {
+		return errs
+	}
+
+	if err := batchPairingCheck(vk, proofs, publicWitnesses, batch); err != nil {
+		for _, i := range batch {
+			errs[i] = Verify(proofs[i], vk, publicWitnesses[i])
+		}
+	}
+	return errs
+}
+
+func batchPairingCheck(vk *VerifyingKey, proofs []*Proof, publicWitnesses [][]fr.Element, batch []int) error {
+	n := len(batch)
+	g1 := make([]bn254.G1Affine, 0, n+3)
+	g2 := make([]bn254.G2Affine, 0, n+3)
+
+	// kScalars[j] accumulates Σ rᵢ·xᵢⱼ so Σ rᵢ·kSumᵢ becomes a single MSM over K
+	kScalars := make([]fr.Element, len(vk.G1.K))
+	rScalars := make([]fr.Element, n)
+	krs := make([]bn254.G1Affine, n)
+
+	bound := new(big.Int).Lsh(big.NewInt(1), batchScalarBits)
+	for k, i := range batch {
+		r, err := rand.Int(rand.Reader, bound)
+		if err != nil {
+			return fmt.Errorf("random scalar: %w", err)
+		}
+		rScalars[k].SetBigInt(r)
+
+		var rAr bn254.G1Affine
+		rAr.ScalarMultiplication(&proofs[i].Ar, r)
+		g1 = append(g1, rAr)
+		g2 = append(g2, proofs[i].Bs)
+
+		krs[k] = proofs[i].Krs
+		kScalars[0].Add(&kScalars[0], &rScalars[k])
+		for j := range publicWitnesses[i] {
+			var term fr.Element
+			term.Mul(&rScalars[k], &publicWitnesses[i][j])
+			kScalars[j+1].Add(&kScalars[j+1], &term)
+		}
+	}
+
+	var krsSum, kSum bn254.G1Affine
+	if _, err := krsSum.MultiExp(krs, rScalars, ecc.MultiExpConfig{}); err != nil {
+		return fmt.Errorf("krs multi-exponentiation failed: %w", err)
+	}
+	if _, err := kSum.MultiExp(vk.G1.K, kScalars, ecc.MultiExpConfig{}); err != nil {
+		return fmt.Errorf("public input multi-exponentiation failed: %w", err)
+	}
+
+	// kScalars[0] is Σ rᵢ, the exponent of e(α, β) on the right-hand side
+	var alphaNeg bn254.G1Affine
+	alphaNeg.ScalarMultiplication(&vk.G1.Alpha, kScalars[0].BigInt(new(big.Int)))
+	alphaNeg.Neg(&alphaNeg)
+
+	g1 = append(g1, krsSum, kSum, alphaNeg)
+	g2 = append(g2, vk.G2.deltaNeg, vk.G2.gammaNeg, vk.G2.Beta)
+
+	ok, err := bn254.PairingCheck(g1, g2)
+	if err != nil {
+		return fmt.Errorf("batch pairing failed: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("batch pairing check failed")
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package verifier_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/threehook/zkp-auth/backend/verifier"
+)
+
+// groth16Batch proves n logins and parses them for BatchVerify
+func groth16Batch(t testing.TB, n int) (*verifier.VerifyingKey, []*verifier.Proof, [][]fr.Element) {
+	t.Helper()
+
+	p, err := loadProver()
+	if err != nil {
+		t.Fatalf("load prover: %v", err)
+	}
+	proofs := make([]*verifier.Proof, n)
+	witnesses := make([][]fr.Element, n)
+	for i := range proofs {
+		login := newLogin(t, fmt.Sprintf("user%d", i), "correct horse")
+		if proofs[i], err = verifier.ParseSnarkJSProof(login.request.Proof); err != nil {
+			t.Fatal(err)
+		}
+		if witnesses[i], err = verifier.ParsePublicSignals(login.request.PublicSignals); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return p.Circuit().VerifyingKey, proofs, witnesses
+}
+
+func TestBatchVerifyAcceptsValidProofs(t *testing.T) {
+	vk, proofs, witnesses := groth16Batch(t, 4)
+
+	for i, err := range verifier.BatchVerify(vk, proofs, witnesses) {
+		if err != nil {
+			t.Errorf("proof %d: %v", i, err)
+		}
+	}
+}
+
+func TestBatchVerifyPinpointsTamperedProofs(t *testing.T) {
+	vk, proofs, witnesses := groth16Batch(t, 4)
+
+	// A point still in the subgroup, so only the pairing check can catch it
+	proofs[1].Ar.Add(&proofs[1].Ar, &proofs[1].Ar)
+	// A valid proof presented with another public input
+	witnesses[2][0].Add(&witnesses[2][0], new(fr.Element).SetOne())
+
+	errs := verifier.BatchVerify(vk, proofs, witnesses)
+	for i, err := range errs {
+		tampered := i == 1 || i == 2
+		if tampered && err == nil {
+			t.Errorf("proof %d: tampered proof passed the batch", i)
+		}
+		if !tampered && err != nil {
+			t.Errorf("proof %d: %v", i, err)
+		}
+	}
+}
+
+func TestBatchVerifyRejectsWitnessCountMismatch(t *testing.T) {
+	vk, proofs, witnesses := groth16Batch(t, 2)
+
+	for i, err := range verifier.BatchVerify(vk, proofs, witnesses[:1]) {
+		if err == nil {
+			t.Errorf("proof %d: accepted without a matching witness", i)
+		}
+	}
+}
+
+func TestBatcherFlushesFullBatch(t *testing.T) {
+	vk, proofs, witnesses := groth16Batch(t, 3)
+	proofs[2].Krs.Add(&proofs[2].Krs, &proofs[2].Krs)
+
+	// The window never closes within the test, so only a full batch is flushed
+	b := verifier.NewBatcher(time.Hour, len(proofs))
+	errs := make([]error, len(proofs))
+	var wg sync.WaitGroup
+	for i := range proofs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = b.Verify(context.Background(), vk, proofs[i], witnesses[i])
+		}()
+	}
+	wg.Wait()
+
+	if errs[0] != nil || errs[1] != nil {
+		t.Fatalf("valid proofs failed: %v, %v", errs[0], errs[1])
+	}
+	if errs[2] == nil {
+		t.Fatal("tampered proof passed the batch")
+	}
+}
+
+func TestBatcherHonoursContext(t *testing.T) {
+	vk, proofs, witnesses := groth16Batch(t, 1)
+	b := verifier.NewBatcher(time.Hour, 32)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := b.Verify(ctx, vk, proofs[0], witnesses[0])
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,112 @@
+package verifier
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// Batcher funnels concurrent verifications of the same key into BatchVerify.
+// The first proof for a key opens a collection window; everything that arrives
+// before it closes, or until maxBatch proofs are queued, is checked together
+type Batcher struct {
+	window   time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	pending map[*VerifyingKey]*pendingBatch
+}
+
+type pendingBatch struct {
+	proofs    []*Proof
+	witnesses [][]fr.Element
+	results   []chan error
+}
+
+func NewBatcher(window time.Duration, maxBatch int) *Batcher {
+	if maxBatch < 1 {
+		maxBatch = 1
+	}
+	return &Batcher{
+		window:   window,
+		maxBatch: maxBatch,
+		pending:  make(map[*VerifyingKey]*pendingBatch),
+	}
+}
+
+// Verify queues the proof and blocks until its batch has been checked or ctx
+// is done. A proof whose caller gave up before the batch was flushed is taken
+// out of it again.
+//
+// Every caller waits here for the window, so when Verify runs behind a Pool a
+// batch never holds more proofs than the pool has workers; size the pool to
+// at least maxBatch for batches to fill up
+func (b *Batcher) Verify(ctx context.Context, vk *VerifyingKey, proof *Proof, publicWitness []fr.Element) error {
+	result := make(chan error, 1)
+
+	b.mu.Lock()
+	batch, exists := b.pending[vk]
+	if !exists {
+		batch = &pendingBatch{}
+		b.pending[vk] = batch
+		time.AfterFunc(b.window, func() { b.flush(vk, batch) })
+	}
+	batch.proofs = append(batch.proofs, proof)
+	batch.witnesses = append(batch.witnesses, publicWitness)
+	batch.results = append(batch.results, result)
+	full := len(batch.proofs) >= b.maxBatch
+	b.mu.Unlock()
+
+	if full {
+		go b.flush(vk, batch)
+	}
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		b.withdraw(vk, batch, result)
+		return ctx.Err()
+	}
+}
+
+// withdraw removes the proof that reports to result from a batch that has not
+// been flushed yet
+func (b *Batcher) withdraw(vk *VerifyingKey, batch *pendingBatch, result chan error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.pending[vk] != batch {
+		return
+	}
+	i := slices.Index(batch.results, result)
+	if i < 0 {
+		return
+	}
+	batch.proofs = slices.Delete(batch.proofs, i, i+1)
+	batch.witnesses = slices.Delete(batch.witnesses, i, i+1)
+	batch.results = slices.Delete(batch.results, i, i+1)
+}
+
+// flush verifies the batch unless it was already flushed by the other trigger
+func (b *Batcher) flush(vk *VerifyingKey, batch *pendingBatch) {
+	b.mu.Lock()
+	if b.pending[vk] != batch {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.pending, vk)
+	b.mu.Unlock()
+
+	if len(batch.proofs) == 0 {
+		return
+	}
+
+	errs := BatchVerify(vk, batch.proofs, batch.witnesses)
+	for i, result := range batch.results {
+		result <- errs[i]
+	}
+}
@@ -0,0 +1,112 @@
+package verifier
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// Public signal names with a fixed meaning. A circuit that lists one of them in
+// its layout commits to the matching request field or stored credential
+const (
+	SignalNonce        = "nonce"
+	SignalTimestamp    = "timestamp"
+	SignalUsernameHash = "usernameHash"
+	SignalSalt         = "salt"
+	SignalStoredHash   = "storedHash"
+)
+
+// Credential is what the server stored for the user at registration, as
+// decimal field elements. Proofs must be generated against exactly these
+type Credential struct {
+	Salt       string
+	Commitment string
+}
+
+// HashToField maps a string onto the BN254 scalar field: the big-endian
+// SHA-256 digest with its top three bits cleared, so it is always below the
+// modulus. Clients must encode nonces and usernames the same way
+func HashToField(value string) fr.Element {
+	digest := sha256.Sum256([]byte(value))
+	digest[0] &= 0x1f
+
+	var e fr.Element
+	e.SetBytes(digest[:])
+	return e
+}
+
+// BindsRequest reports whether proofs for this circuit commit to the nonce,
+// timestamp and username, which makes them useless for replay under new ones
+func (c Circuit) BindsRequest() bool {
+	return c.SignalIndex(SignalNonce) >= 0 &&
+		c.SignalIndex(SignalTimestamp) >= 0 &&
+		c.SignalIndex(SignalUsernameHash) >= 0
+}
+
+// BindsCredential reports whether proofs for this circuit commit to the salt
+// and stored hash of a credential. Without both a valid proof says nothing
+// about which user it was made for, so such circuits are never accepted
+func (c Circuit) BindsCredential() bool {
+	return c.SignalIndex(SignalSalt) >= 0 && c.SignalIndex(SignalStoredHash) >= 0
+}
+
+// checkBindings rejects inputs whose plaintext nonce, timestamp or username,
+// or the user's stored credential, disagree with the public signals the proof
+// was generated for
+func checkBindings(circuit Circuit, input Input, publicWitness []fr.Element) error {
+	credential := input.Credential
+
+	if i := circuit.SignalIndex(SignalSalt); i >= 0 {
+		if err := matchSignal(publicWitness[i], credential.Salt); err != nil {
+			return fmt.Errorf("salt does not match public signal %d: %w", i, err)
+		}
+	}
+
+	if i := circuit.SignalIndex(SignalStoredHash); i >= 0 {
+		if err := matchSignal(publicWitness[i], credential.Commitment); err != nil {
+			return fmt.Errorf("stored credential does not match public signal %d: %w", i, err)
+		}
+	}
+
+	if i := circuit.SignalIndex(SignalNonce); i >= 0 {
+		expected := HashToField(input.Nonce)
+		if !publicWitness[i].Equal(&expected) {
+			return fmt.Errorf("nonce does not match public signal %d", i)
+		}
+	}
+
+	if i := circuit.SignalIndex(SignalTimestamp); i >= 0 {
+		if input.Timestamp < 0 {
+			return fmt.Errorf("timestamp must not be negative")
+		}
+		var expected fr.Element
+		expected.SetUint64(uint64(input.Timestamp))
+		if !publicWitness[i].Equal(&expected) {
+			return fmt.Errorf("timestamp does not match public signal %d", i)
+		}
+	}
+
+	if i := circuit.SignalIndex(SignalUsernameHash); i >= 0 {
+		expected := HashToField(input.Username)
+		if !publicWitness[i].Equal(&expected) {
+			return fmt.Errorf("username does not match public signal %d", i)
+		}
+	}
+
+	return nil
+}
+
+func matchSignal(signal fr.Element, expected string) error {
+	n, err := parseCanonical(expected, fr.Modulus())
+	if err != nil {
+		return fmt.Errorf("stored value is not a field element: %w", err)
+	}
+
+	var e fr.Element
+	e.SetBigInt(n)
+	if !signal.Equal(&e) {
+		return fmt.Errorf("value differs")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package verifier
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Failure reasons returned (wrapped in a VerificationError) by Verifier.Verify
+// and reported in Result.Reason
+var (
+	ErrNoVerifyingKey    = errors.New("verifying key not loaded")
+	ErrMalformedProof    = errors.New("malformed proof")
+	ErrMalformedSignals  = errors.New("malformed public signals")
+	ErrInvalidProof      = errors.New("invalid proof")
+	ErrUnknownCircuit    = errors.New("unknown circuit")
+	ErrCircuitRevoked    = errors.New("circuit revoked")
+	ErrUnboundCircuit    = errors.New("circuit does not bind request context")
+	ErrUnboundCredential = errors.New("circuit does not bind the stored credential")
+	ErrSignalMismatch    = errors.New("public signal mismatch")
+	ErrOverloaded        = errors.New("verification queue full")
+	ErrTimeout           = errors.New("verification timed out")
+)
+
+// VerificationError pairs a failure reason with the underlying cause
+type VerificationError struct {
+	Reason error
+	Err    error
+}
+
+func (e *VerificationError) Error() string {
+	if e.Err == nil {
+		return e.Reason.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Reason, e.Err)
+}
+
+// Unwrap exposes both the reason and the cause to errors.Is / errors.As
+func (e *VerificationError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Reason}
+	}
+	return []error{e.Reason, e.Err}
+}
+
+func newVerificationError(reason, err error) *VerificationError {
+	return &VerificationError{Reason: reason, Err: err}
+}
@@ -2,8 +2,7 @@ package verifier
 
 import (
 	"fmt"
-	"math/big"
-
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/ecc/bn254"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 )
@@ -46,59 +45,40 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness []fr.Element) error {
 		return fmt.Errorf("proof points not in correct subgroup")
 	}
 
-	// 2. Compute public input linear combination: Σ(x_i * K_i)
-	var kSum bn254.G1Jac
+	// 2. Compute public input linear combination: K₀ + Σ(x_i * K_{i+1})
 	if len(publicWitness) != len(vk.G1.K)-1 {
 		return fmt.Errorf("invalid witness size, got %d, expected %d", len(publicWitness), len(vk.G1.K)-1)
 	}
 
-	// Multi-exponentiation: kSum = K₀ + Σ(x_i * K_{i+1})
-	var k0Jac bn254.G1Jac
-	k0Jac.FromAffine(&vk.G1.K[0])
-	kSum.Set(&k0Jac)
-
-	for i := 0; i < len(publicWitness); i++ {
-		var term bn254.G1Jac
-		var kAffine bn254.G1Affine = vk.G1.K[i+1]
-		term.FromAffine(&kAffine)
-		term.ScalarMultiplication(&term, publicWitness[i].BigInt(new(big.Int)))
-		kSum.AddAssign(&term)
+	// One multi-scalar multiplication instead of a scalar multiplication per input
+	var kSum bn254.G1Jac
+	if len(publicWitness) > 0 {
+		if _, err := kSum.MultiExp(vk.G1.K[1:], publicWitness, ecc.MultiExpConfig{}); err != nil {
+			return fmt.Errorf("public input multi-exponentiation failed: %w", err)
+		}
 	}
+	kSum.AddMixed(&vk.G1.K[0])
 
 	// Add commitments if any
 	for i := range proof.Commitments {
-		var commitmentJac bn254.G1Jac
-		commitmentJac.FromAffine(&proof.Commitments[i])
-		kSum.AddAssign(&commitmentJac)
+		kSum.AddMixed(&proof.Commitments[i])
 	}
 
 	var kSumAff bn254.G1Affine
 	kSumAff.FromJacobian(&kSum)
 
-	// 3. Perform the pairing checks
-	// First pairing: e(Ar, Bs)
-	eArBs, err := bn254.Pair([]bn254.G1Affine{proof.Ar}, []bn254.G2Affine{proof.Bs})
-	if err != nil {
-		return fmt.Errorf("pairing Ar,Bs failed: %w", err)
-	}
-
-	// Second pairing: e(Krs, -δ)
-	eKrsDelta, err := bn254.Pair([]bn254.G1Affine{proof.Krs}, []bn254.G2Affine{vk.G2.deltaNeg})
-	if err != nil {
-		return fmt.Errorf("pairing Krs,deltaNeg failed: %w", err)
-	}
-
-	// Third pairing: e(Σ(x_i*K_i) + commitments, -γ)
-	eKGamma, err := bn254.Pair([]bn254.G1Affine{kSumAff}, []bn254.G2Affine{vk.G2.gammaNeg})
+	// 3. Perform the pairing check as a single multi-Miller loop:
+	// e(Ar, Bs) · e(Krs, -δ) · e(Σ(x_i*K_i) + commitments, -γ)
+	ml, err := bn254.MillerLoop(
+		[]bn254.G1Affine{proof.Ar, proof.Krs, kSumAff},
+		[]bn254.G2Affine{proof.Bs, vk.G2.deltaNeg, vk.G2.gammaNeg},
+	)
 	if err != nil {
-		return fmt.Errorf("pairing kSum,gammaNeg failed: %w", err)
+		return fmt.Errorf("miller loop failed: %w", err)
 	}
 
-	// 4. Combine pairings and check against e(α, β)
-	var result bn254.GT
-	result.Mul(&eArBs, &eKrsDelta)
-	result.Mul(&result, &eKGamma)
-
+	// 4. One final exponentiation, compared against the precomputed e(α, β)
+	result := bn254.FinalExponentiation(&ml)
 	if !vk.e.Equal(&result) {
 		return fmt.Errorf("pairing check failed")
 	}
@@ -138,20 +118,3 @@ func NewVerifyingKey() *VerifyingKey {
 func NewProof() *Proof {
 	return &Proof{}
 }
-
-type Groth16Verifier struct {
-	VerifyingKey *VerifyingKey
-}
-
-func NewGroth16Verifier() *Groth16Verifier {
-	return &Groth16Verifier{
-		VerifyingKey: NewVerifyingKey(),
-	}
-}
-
-// VerifyProof maintains the existing interface but uses Groth16 internally
-func (v *Groth16Verifier) VerifyProof(proofData map[string]interface{}) bool {
-	// For now, return true to maintain compatibility
-	// You'll need to implement the conversion from your proof format to Groth16 proof
-	return true
-}
@@ -0,0 +1,113 @@
+package verifier_test
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/threehook/zkp-auth/backend/verifier"
+)
+
+// verifySeparatePairings is Verify as it was before the multi-Miller loop:
+// one scalar multiplication per public input and three full pairings. It is
+// kept as the baseline for BenchmarkVerify
+func verifySeparatePairings(proof *verifier.Proof, vk *verifier.VerifyingKey, alphaBeta *bn254.GT, publicWitness []fr.Element) error {
+	if !proof.Ar.IsInSubGroup() || !proof.Krs.IsInSubGroup() || !proof.Bs.IsInSubGroup() {
+		return fmt.Errorf("proof points not in correct subgroup")
+	}
+	if len(publicWitness) != len(vk.G1.K)-1 {
+		return fmt.Errorf("invalid witness size, got %d, expected %d", len(publicWitness), len(vk.G1.K)-1)
+	}
+
+	var kSum bn254.G1Jac
+	kSum.FromAffine(&vk.G1.K[0])
+	for i := range publicWitness {
+		var term bn254.G1Jac
+		term.FromAffine(&vk.G1.K[i+1])
+		term.ScalarMultiplication(&term, publicWitness[i].BigInt(new(big.Int)))
+		kSum.AddAssign(&term)
+	}
+	var kSumAff bn254.G1Affine
+	kSumAff.FromJacobian(&kSum)
+
+	var deltaNeg, gammaNeg bn254.G2Affine
+	deltaNeg.Neg(&vk.G2.Delta)
+	gammaNeg.Neg(&vk.G2.Gamma)
+
+	eArBs, err := bn254.Pair([]bn254.G1Affine{proof.Ar}, []bn254.G2Affine{proof.Bs})
+	if err != nil {
+		return err
+	}
+	eKrsDelta, err := bn254.Pair([]bn254.G1Affine{proof.Krs}, []bn254.G2Affine{deltaNeg})
+	if err != nil {
+		return err
+	}
+	eKGamma, err := bn254.Pair([]bn254.G1Affine{kSumAff}, []bn254.G2Affine{gammaNeg})
+	if err != nil {
+		return err
+	}
+
+	var result bn254.GT
+	result.Mul(&eArBs, &eKrsDelta)
+	result.Mul(&result, &eKGamma)
+	if !alphaBeta.Equal(&result) {
+		return fmt.Errorf("pairing check failed")
+	}
+	return nil
+}
+
+func alphaBeta(t testing.TB, vk *verifier.VerifyingKey) *bn254.GT {
+	t.Helper()
+
+	e, err := bn254.Pair([]bn254.G1Affine{vk.G1.Alpha}, []bn254.G2Affine{vk.G2.Beta})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &e
+}
+
+func TestVerifyAgreesWithSeparatePairings(t *testing.T) {
+	vk, proofs, witnesses := groth16Batch(t, 2)
+	e := alphaBeta(t, vk)
+
+	// The second proof is checked against the first one's public inputs
+	tests := []struct {
+		name    string
+		witness []fr.Element
+		valid   bool
+	}{
+		{"valid", witnesses[0], true},
+		{"wrong public inputs", witnesses[1], false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifier.Verify(proofs[0], vk, tt.witness)
+			baseline := verifySeparatePairings(proofs[0], vk, e, tt.witness)
+			if (err == nil) != tt.valid || (baseline == nil) != tt.valid {
+				t.Fatalf("Verify = %v, separate pairings = %v, want valid %v", err, baseline, tt.valid)
+			}
+		})
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	vk, proofs, witnesses := groth16Batch(b, 1)
+	e := alphaBeta(b, vk)
+
+	b.Run("separate-pairings", func(b *testing.B) {
+		for b.Loop() {
+			if err := verifySeparatePairings(proofs[0], vk, e, witnesses[0]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("multi-miller-loop", func(b *testing.B) {
+		for b.Loop() {
+			if err := verifier.Verify(proofs[0], vk, witnesses[0]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
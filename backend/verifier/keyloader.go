@@ -0,0 +1,338 @@
+package verifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+)
+
+// snarkJSVerifyingKey mirrors the verification_key.json exported by
+// `snarkjs zkey export verificationkey` for Groth16 circuits
+type snarkJSVerifyingKey struct {
+	Protocol    string       `json:"protocol"`
+	Curve       string       `json:"curve"`
+	NPublic     int          `json:"nPublic"`
+	Alpha1      []string     `json:"vk_alpha_1"`
+	Beta2       [][]string   `json:"vk_beta_2"`
+	Gamma2      [][]string   `json:"vk_gamma_2"`
+	Delta2      [][]string   `json:"vk_delta_2"`
+	AlphaBeta12 [][][]string `json:"vk_alphabeta_12"`
+	IC          [][]string   `json:"IC"`
+}
+
+// circuitManifest is the circuit.json stored next to each verification key
+type circuitManifest struct {
+	ID              string        `json:"id"`
+	Version         int           `json:"version"`
+	VerificationKey string        `json:"verificationKey"`
+	PublicSignals   []string      `json:"publicSignals"`
+	Status          CircuitStatus `json:"status,omitempty"`
+}
+
+// LoadCircuits walks fsys for circuit.json manifests and loads the
+// verification key each one references
+func LoadCircuits(fsys fs.FS) ([]Circuit, error) {
+	var loaded []Circuit
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Base(name) != "circuit.json" {
+			return nil
+		}
+
+		circuit, err := loadCircuit(fsys, name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		loaded = append(loaded, circuit)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return loaded, nil
+}
+
+func loadCircuit(fsys fs.FS, manifestPath string) (Circuit, error) {
+	data, err := fs.ReadFile(fsys, manifestPath)
+	if err != nil {
+		return Circuit{}, err
+	}
+
+	var manifest circuitManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return Circuit{}, fmt.Errorf("decode manifest: %w", err)
+	}
+	if manifest.VerificationKey == "" {
+		manifest.VerificationKey = "verification_key.json"
+	}
+
+	keyData, err := fs.ReadFile(fsys, path.Join(path.Dir(manifestPath), manifest.VerificationKey))
+	if err != nil {
+		return Circuit{}, err
+	}
+	circuit := Circuit{
+		ID:            manifest.ID,
+		Version:       manifest.Version,
+		PublicSignals: manifest.PublicSignals,
+		Status:        manifest.Status,
+	}
+
+	// The key's protocol field selects the proof system, as snarkjs writes it
+	var header struct {
+		Protocol string `json:"protocol"`
+	}
+	if err := json.Unmarshal(keyData, &header); err != nil {
+		return Circuit{}, fmt.Errorf("decode verification key: %w", err)
+	}
+	switch header.Protocol {
+	case ProtocolGroth16:
+		circuit.VerifyingKey, err = ParseVerifyingKey(keyData)
+	case ProtocolPlonk:
+		circuit.PlonkKey, err = ParsePlonkVerifyingKey(keyData)
+	case ProtocolFflonk:
+		err = fmt.Errorf("fflonk keys are not supported; set up the circuit with snarkjs plonk or groth16")
+	default:
+		err = fmt.Errorf("unsupported protocol %q", header.Protocol)
+	}
+	if err != nil {
+		return Circuit{}, err
+	}
+
+	if len(circuit.PublicSignals) != circuit.NumPublic() {
+		return Circuit{}, fmt.Errorf("manifest names %d public signals but key expects %d",
+			len(circuit.PublicSignals), circuit.NumPublic())
+	}
+	return circuit, nil
+}
+
+// ParseVerifyingKey turns a snarkjs Groth16 verification_key.json into a fully
+// populated VerifyingKey, including the negated G2 points and e(α, β)
+func ParseVerifyingKey(data []byte) (*VerifyingKey, error) {
+	var raw snarkJSVerifyingKey
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("decode verification key: %w", err)
+	}
+
+	if raw.Protocol != "groth16" {
+		return nil, fmt.Errorf("unsupported protocol %q", raw.Protocol)
+	}
+	if raw.Curve != "bn128" {
+		return nil, fmt.Errorf("unsupported curve %q", raw.Curve)
+	}
+	if len(raw.IC) == 0 {
+		return nil, fmt.Errorf("verification key has no IC points")
+	}
+	if raw.NPublic != len(raw.IC)-1 {
+		return nil, fmt.Errorf("nPublic is %d but IC has %d points", raw.NPublic, len(raw.IC))
+	}
+
+	vk := NewVerifyingKey()
+	var err error
+
+	if vk.G1.Alpha, err = parseG1(raw.Alpha1); err != nil {
+		return nil, fmt.Errorf("vk_alpha_1: %w", err)
+	}
+	if vk.G2.Beta, err = parseG2(raw.Beta2); err != nil {
+		return nil, fmt.Errorf("vk_beta_2: %w", err)
+	}
+	if vk.G2.Gamma, err = parseG2(raw.Gamma2); err != nil {
+		return nil, fmt.Errorf("vk_gamma_2: %w", err)
+	}
+	if vk.G2.Delta, err = parseG2(raw.Delta2); err != nil {
+		return nil, fmt.Errorf("vk_delta_2: %w", err)
+	}
+
+	vk.G1.K = make([]bn254.G1Affine, len(raw.IC))
+	for i, point := range raw.IC {
+		if vk.G1.K[i], err = parseG1(point); err != nil {
+			return nil, fmt.Errorf("IC[%d]: %w", i, err)
+		}
+	}
+
+	if err := vk.validate(); err != nil {
+		return nil, err
+	}
+	if err := vk.Precompute(); err != nil {
+		return nil, err
+	}
+
+	// snarkjs ships e(α, β) as well; refuse keys where it disagrees with ours
+	if len(raw.AlphaBeta12) > 0 {
+		alphaBeta, err := parseGT(raw.AlphaBeta12)
+		if err != nil {
+			return nil, fmt.Errorf("vk_alphabeta_12: %w", err)
+		}
+		if !alphaBeta.Equal(&vk.e) {
+			return nil, fmt.Errorf("vk_alphabeta_12 does not match e(vk_alpha_1, vk_beta_2)")
+		}
+	}
+
+	return vk, nil
+}
+
+// WriteCircuit stores a circuit version in dir the way LoadCircuits expects
+// it: a circuit.json manifest next to its verification_key.json
+func WriteCircuit(dir string, circuit Circuit) error {
+	if circuit.VerifyingKey == nil {
+		return fmt.Errorf("only Groth16 keys can be written")
+	}
+	keyData, err := MarshalVerifyingKey(circuit.VerifyingKey)
+	if err != nil {
+		return err
+	}
+	manifest, err := json.MarshalIndent(circuitManifest{
+		ID:              circuit.ID,
+		Version:         circuit.Version,
+		VerificationKey: "verification_key.json",
+		PublicSignals:   circuit.PublicSignals,
+		Status:          circuit.Status,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "verification_key.json"), append(keyData, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "circuit.json"), append(manifest, '\n'), 0o644)
+}
+
+// MarshalVerifyingKey writes vk as a snarkjs verification_key.json, so keys
+// produced outside snarkjs can be deployed and loaded like any other circuit
+func MarshalVerifyingKey(vk *VerifyingKey) ([]byte, error) {
+	if len(vk.CommitmentKeys) > 0 {
+		return nil, fmt.Errorf("keys with commitments have no snarkjs representation")
+	}
+	if err := vk.validate(); err != nil {
+		return nil, err
+	}
+
+	alphaBeta, err := bn254.Pair([]bn254.G1Affine{vk.G1.Alpha}, []bn254.G2Affine{vk.G2.Beta})
+	if err != nil {
+		return nil, fmt.Errorf("pairing alpha,beta failed: %w", err)
+	}
+
+	raw := snarkJSVerifyingKey{
+		Protocol:    "groth16",
+		Curve:       "bn128",
+		NPublic:     len(vk.G1.K) - 1,
+		Alpha1:      g1Strings(&vk.G1.Alpha),
+		Beta2:       g2Strings(&vk.G2.Beta),
+		Gamma2:      g2Strings(&vk.G2.Gamma),
+		Delta2:      g2Strings(&vk.G2.Delta),
+		AlphaBeta12: gtStrings(&alphaBeta),
+		IC:          make([][]string, len(vk.G1.K)),
+	}
+	for i := range vk.G1.K {
+		raw.IC[i] = g1Strings(&vk.G1.K[i])
+	}
+	return json.MarshalIndent(raw, "", "  ")
+}
+
+func g1Strings(p *bn254.G1Affine) []string {
+	return []string{p.X.String(), p.Y.String(), "1"}
+}
+
+func g2Strings(p *bn254.G2Affine) [][]string {
+	return [][]string{
+		{p.X.A0.String(), p.X.A1.String()},
+		{p.Y.A0.String(), p.Y.A1.String()},
+		{"1", "0"},
+	}
+}
+
+func gtStrings(z *bn254.GT) [][][]string {
+	return [][][]string{
+		{
+			{z.C0.B0.A0.String(), z.C0.B0.A1.String()},
+			{z.C0.B1.A0.String(), z.C0.B1.A1.String()},
+			{z.C0.B2.A0.String(), z.C0.B2.A1.String()},
+		},
+		{
+			{z.C1.B0.A0.String(), z.C1.B0.A1.String()},
+			{z.C1.B1.A0.String(), z.C1.B1.A1.String()},
+			{z.C1.B2.A0.String(), z.C1.B2.A1.String()},
+		},
+	}
+}
+
+// Precompute derives the negated G2 points and e(α, β) used by Verify
+func (vk *VerifyingKey) Precompute() error {
+	vk.G2.gammaNeg.Neg(&vk.G2.Gamma)
+	vk.G2.deltaNeg.Neg(&vk.G2.Delta)
+
+	e, err := bn254.Pair([]bn254.G1Affine{vk.G1.Alpha}, []bn254.G2Affine{vk.G2.Beta})
+	if err != nil {
+		return fmt.Errorf("pairing alpha,beta failed: %w", err)
+	}
+	vk.e = e
+	return nil
+}
+
+// validate checks that every key point is on the curve, in the prime order
+// subgroup and, for the setup points, not the identity
+func (vk *VerifyingKey) validate() error {
+	if vk.G1.Alpha.IsInfinity() || !vk.G1.Alpha.IsInSubGroup() {
+		return fmt.Errorf("vk_alpha_1 is not a valid G1 point")
+	}
+
+	g2Points := []struct {
+		name  string
+		point *bn254.G2Affine
+	}{
+		{"vk_beta_2", &vk.G2.Beta},
+		{"vk_gamma_2", &vk.G2.Gamma},
+		{"vk_delta_2", &vk.G2.Delta},
+	}
+	for _, p := range g2Points {
+		if p.point.IsInfinity() || !p.point.IsInSubGroup() {
+			return fmt.Errorf("%s is not a valid G2 point", p.name)
+		}
+	}
+
+	for i := range vk.G1.K {
+		if !vk.G1.K[i].IsInSubGroup() {
+			return fmt.Errorf("IC[%d] is not in the G1 subgroup", i)
+		}
+	}
+	return nil
+}
+
+// parseGT parses a GT element in the snarkjs layout [[c0.b0, c0.b1, c0.b2], [c1.b0, c1.b1, c1.b2]]
+func parseGT(coords [][][]string) (bn254.GT, error) {
+	var z bn254.GT
+	if len(coords) != 2 || len(coords[0]) != 3 || len(coords[1]) != 3 {
+		return z, fmt.Errorf("expected 2x3 Fp2 components")
+	}
+
+	var err error
+	if z.C0.B0.A0, z.C0.B0.A1, err = parseFp2(coords[0][0]); err != nil {
+		return z, err
+	}
+	if z.C0.B1.A0, z.C0.B1.A1, err = parseFp2(coords[0][1]); err != nil {
+		return z, err
+	}
+	if z.C0.B2.A0, z.C0.B2.A1, err = parseFp2(coords[0][2]); err != nil {
+		return z, err
+	}
+	if z.C1.B0.A0, z.C1.B0.A1, err = parseFp2(coords[1][0]); err != nil {
+		return z, err
+	}
+	if z.C1.B1.A0, z.C1.B1.A1, err = parseFp2(coords[1][1]); err != nil {
+		return z, err
+	}
+	if z.C1.B2.A0, z.C1.B2.A1, err = parseFp2(coords[1][2]); err != nil {
+		return z, err
+	}
+	return z, nil
+}
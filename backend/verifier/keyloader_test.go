@@ -0,0 +1,119 @@
+package verifier
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+)
+
+// testVerifyingKey returns the snarkjs form of a key whose points are small
+// multiples of the generators, for a circuit with two public inputs
+func testVerifyingKey(t *testing.T) snarkJSVerifyingKey {
+	t.Helper()
+	_, _, g1, g2 := bn254.Generators()
+	g1Times := func(k int64) bn254.G1Affine {
+		var p bn254.G1Affine
+		return *p.ScalarMultiplication(&g1, big.NewInt(k))
+	}
+	g2Times := func(k int64) bn254.G2Affine {
+		var p bn254.G2Affine
+		return *p.ScalarMultiplication(&g2, big.NewInt(k))
+	}
+
+	vk := NewVerifyingKey()
+	vk.G1.Alpha = g1Times(2)
+	vk.G2.Beta, vk.G2.Gamma, vk.G2.Delta = g2Times(3), g2Times(5), g2Times(7)
+	vk.G1.K = []bn254.G1Affine{g1Times(11), g1Times(13), g1Times(17)}
+
+	data, err := MarshalVerifyingKey(vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw snarkJSVerifyingKey
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// plusModulus returns the decimal base field element plus the modulus: the
+// same value, but not in canonical form
+func plusModulus(s string) string {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n.Add(n, fp.Modulus()).String()
+}
+
+func TestParseVerifyingKey(t *testing.T) {
+	data, err := json.Marshal(testVerifyingKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vk, err := ParseVerifyingKey(data)
+	if err != nil {
+		t.Fatalf("ParseVerifyingKey: %v", err)
+	}
+	if len(vk.G1.K) != 3 {
+		t.Fatalf("IC has %d points, want 3", len(vk.G1.K))
+	}
+
+	// A point on the twist that the cofactor keeps out of the r-torsion
+	var u bn254.E2
+	u.A0.SetUint64(5)
+	outsideSubgroup := bn254.MapToCurve2(&u)
+	if !outsideSubgroup.IsOnCurve() || outsideSubgroup.IsInSubGroup() {
+		t.Fatal("test point is not on the curve outside the subgroup")
+	}
+
+	_, _, g1, g2 := bn254.Generators()
+	wrongPairing, err := bn254.Pair([]bn254.G1Affine{g1}, []bn254.G2Affine{g2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		want   string // part of the error
+		mutate func(raw *snarkJSVerifyingKey)
+	}{
+		{"plonk key", "unsupported protocol", func(raw *snarkJSVerifyingKey) { raw.Protocol = ProtocolPlonk }},
+		{"other curve", "unsupported curve", func(raw *snarkJSVerifyingKey) { raw.Curve = "bls12381" }},
+		{"no IC points", "no IC points", func(raw *snarkJSVerifyingKey) { raw.IC, raw.NPublic = nil, -1 }},
+		{"nPublic above IC", "nPublic is", func(raw *snarkJSVerifyingKey) { raw.NPublic = len(raw.IC) }},
+		{"nPublic below IC", "nPublic is", func(raw *snarkJSVerifyingKey) { raw.NPublic = len(raw.IC) - 2 }},
+		{"alpha off the curve", "vk_alpha_1: point is not on curve", func(raw *snarkJSVerifyingKey) { raw.Alpha1 = []string{"1", "3", "1"} }},
+		{"alpha at infinity", "vk_alpha_1 is not a valid G1 point", func(raw *snarkJSVerifyingKey) { raw.Alpha1 = []string{"0", "1", "0"} }},
+		{"IC point off the curve", "IC[1]: point is not on curve", func(raw *snarkJSVerifyingKey) { raw.IC[1] = []string{"1", "3", "1"} }},
+		{"beta off the curve", "vk_beta_2: point is not on curve", func(raw *snarkJSVerifyingKey) { raw.Beta2[0] = []string{"1", "0"} }},
+		{"delta outside the subgroup", "vk_delta_2 is not a valid G2 point", func(raw *snarkJSVerifyingKey) { raw.Delta2 = g2Strings(&outsideSubgroup) }},
+		{"gamma at infinity", "vk_gamma_2 is not a valid G2 point", func(raw *snarkJSVerifyingKey) {
+			raw.Gamma2 = [][]string{{"0", "0"}, {"1", "0"}, {"0", "0"}}
+		}},
+		{"point not in affine form", "IC[0]: point is not in affine form", func(raw *snarkJSVerifyingKey) { raw.IC[0][2] = "2" }},
+		{"non-canonical G1 coordinate", "vk_alpha_1: value out of field range", func(raw *snarkJSVerifyingKey) { raw.Alpha1[0] = plusModulus(raw.Alpha1[0]) }},
+		{"non-canonical G2 coordinate", "vk_beta_2: value out of field range", func(raw *snarkJSVerifyingKey) { raw.Beta2[1][1] = plusModulus(raw.Beta2[1][1]) }},
+		{"not a number", "IC[2]: invalid decimal value", func(raw *snarkJSVerifyingKey) { raw.IC[2][0] = "0x01" }},
+		{"alphabeta of other points", "vk_alphabeta_12 does not match", func(raw *snarkJSVerifyingKey) { raw.AlphaBeta12 = gtStrings(&wrongPairing) }},
+		{"alphabeta malformed", "vk_alphabeta_12: ", func(raw *snarkJSVerifyingKey) { raw.AlphaBeta12 = raw.AlphaBeta12[:1] }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := testVerifyingKey(t)
+			tt.mutate(&raw)
+			data, err := json.Marshal(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = ParseVerifyingKey(data)
+			if err == nil {
+				t.Fatal("ParseVerifyingKey accepted the key")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,427 @@
+package verifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"golang.org/x/crypto/sha3"
+)
+
+// Proof systems a circuit's verification key can select, named as in the
+// snarkjs "protocol" field. fflonk is out of scope: it is named only so keys
+// and proofs for it are rejected with a clear error instead of being
+// mistaken for PLONK
+const (
+	ProtocolGroth16 = "groth16"
+	ProtocolPlonk   = "plonk"
+	ProtocolFflonk  = "fflonk"
+)
+
+// PlonkVerifyingKey is a snarkjs PLONK verification key. PLONK keys come from a
+// universal powers-of-tau setup, so new circuits need no ceremony of their own
+type PlonkVerifyingKey struct {
+	NPublic int
+	Power   int
+	K1, K2  fr.Element
+	Omega   fr.Element // generator of the 2^Power evaluation domain
+
+	Qm, Ql, Qr, Qo, Qc bn254.G1Affine
+	S1, S2, S3         bn254.G1Affine
+	X2                 bn254.G2Affine // [τ]₂
+}
+
+// PlonkProof is a snarkjs PLONK proof: wire, permutation and quotient
+// commitments, opening proofs, and the evaluations at the challenge ξ
+type PlonkProof struct {
+	A, B, C, Z bn254.G1Affine
+	T1, T2, T3 bn254.G1Affine
+	Wxi, Wxiw  bn254.G1Affine
+
+	EvalA, EvalB, EvalC    fr.Element
+	EvalS1, EvalS2, EvalZw fr.Element
+}
+
+type snarkJSPlonkVerifyingKey struct {
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+	NPublic  int        `json:"nPublic"`
+	Power    int        `json:"power"`
+	K1       string     `json:"k1"`
+	K2       string     `json:"k2"`
+	Qm       []string   `json:"Qm"`
+	Ql       []string   `json:"Ql"`
+	Qr       []string   `json:"Qr"`
+	Qo       []string   `json:"Qo"`
+	Qc       []string   `json:"Qc"`
+	S1       []string   `json:"S1"`
+	S2       []string   `json:"S2"`
+	S3       []string   `json:"S3"`
+	X2       [][]string `json:"X_2"`
+	W        string     `json:"w"`
+}
+
+// ParsePlonkVerifyingKey parses a verification_key.json exported by snarkjs
+// for a PLONK circuit
+func ParsePlonkVerifyingKey(data []byte) (*PlonkVerifyingKey, error) {
+	var raw snarkJSPlonkVerifyingKey
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("decode verification key: %w", err)
+	}
+
+	if raw.Protocol != ProtocolPlonk {
+		return nil, fmt.Errorf("unsupported protocol %q", raw.Protocol)
+	}
+	if raw.Curve != "bn128" {
+		return nil, fmt.Errorf("unsupported curve %q", raw.Curve)
+	}
+	if raw.NPublic < 0 {
+		return nil, fmt.Errorf("nPublic must not be negative")
+	}
+	// fr has a 2-adicity of 28, so no larger domain exists
+	if raw.Power < 1 || raw.Power > 28 {
+		return nil, fmt.Errorf("power %d out of range", raw.Power)
+	}
+	if raw.NPublic > 1<<raw.Power {
+		return nil, fmt.Errorf("nPublic %d exceeds the domain size", raw.NPublic)
+	}
+
+	vk := &PlonkVerifyingKey{NPublic: raw.NPublic, Power: raw.Power}
+	var err error
+
+	scalars := []struct {
+		name  string
+		value string
+		dst   *fr.Element
+	}{
+		{"k1", raw.K1, &vk.K1},
+		{"k2", raw.K2, &vk.K2},
+		{"w", raw.W, &vk.Omega},
+	}
+	for _, s := range scalars {
+		if *s.dst, err = parseFr(s.value); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.name, err)
+		}
+	}
+
+	// ω must generate exactly the 2^power domain, or the Lagrange evaluations
+	// the public inputs are checked with mean nothing
+	var omegaN, omegaHalf fr.Element
+	omegaN.Exp(vk.Omega, big.NewInt(1<<raw.Power))
+	omegaHalf.Exp(vk.Omega, big.NewInt(1<<(raw.Power-1)))
+	if !omegaN.IsOne() || omegaHalf.IsOne() {
+		return nil, fmt.Errorf("w is not a primitive 2^%d-th root of unity", raw.Power)
+	}
+
+	points := []struct {
+		name   string
+		coords []string
+		dst    *bn254.G1Affine
+	}{
+		{"Qm", raw.Qm, &vk.Qm},
+		{"Ql", raw.Ql, &vk.Ql},
+		{"Qr", raw.Qr, &vk.Qr},
+		{"Qo", raw.Qo, &vk.Qo},
+		{"Qc", raw.Qc, &vk.Qc},
+		{"S1", raw.S1, &vk.S1},
+		{"S2", raw.S2, &vk.S2},
+		{"S3", raw.S3, &vk.S3},
+	}
+	for _, p := range points {
+		if *p.dst, err = parseG1(p.coords); err != nil {
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+		if !p.dst.IsInSubGroup() {
+			return nil, fmt.Errorf("%s is not in the G1 subgroup", p.name)
+		}
+	}
+
+	if vk.X2, err = parseG2(raw.X2); err != nil {
+		return nil, fmt.Errorf("X_2: %w", err)
+	}
+	if vk.X2.IsInfinity() || !vk.X2.IsInSubGroup() {
+		return nil, fmt.Errorf("X_2 is not a valid G2 point")
+	}
+
+	return vk, nil
+}
+
+// ParsePlonkProof converts the JSON proof produced by snarkjs plonk.fullProve
+func ParsePlonkProof(proofData map[string]interface{}) (*PlonkProof, error) {
+	if proofData == nil {
+		return nil, fmt.Errorf("proof is empty")
+	}
+
+	proof := &PlonkProof{}
+	points := []struct {
+		name string
+		dst  *bn254.G1Affine
+	}{
+		{"A", &proof.A}, {"B", &proof.B}, {"C", &proof.C}, {"Z", &proof.Z},
+		{"T1", &proof.T1}, {"T2", &proof.T2}, {"T3", &proof.T3},
+		{"Wxi", &proof.Wxi}, {"Wxiw", &proof.Wxiw},
+	}
+	for _, p := range points {
+		coords, err := toStrings(proofData[p.name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+		if *p.dst, err = parseG1(coords); err != nil {
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+		if !p.dst.IsInSubGroup() {
+			return nil, fmt.Errorf("%s is not in the G1 subgroup", p.name)
+		}
+	}
+
+	evals := []struct {
+		name string
+		dst  *fr.Element
+	}{
+		{"eval_a", &proof.EvalA}, {"eval_b", &proof.EvalB}, {"eval_c", &proof.EvalC},
+		{"eval_s1", &proof.EvalS1}, {"eval_s2", &proof.EvalS2}, {"eval_zw", &proof.EvalZw},
+	}
+	for _, e := range evals {
+		s, ok := proofData[e.name].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected a decimal string", e.name)
+		}
+		var err error
+		if *e.dst, err = parseFr(s); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.name, err)
+		}
+	}
+
+	return proof, nil
+}
+
+// plonkChallenges are the Fiat-Shamir challenges of one proof
+type plonkChallenges struct {
+	beta, gamma, alpha, xi, u fr.Element
+	v                         [6]fr.Element // v[1..5], as in snarkjs
+}
+
+// VerifyPlonk checks a PLONK proof the way snarkjs plonk.verify does: it
+// replays the Keccak-256 transcript, reconstructs the linearisation commitment
+// and checks both KZG openings with a single pairing
+func VerifyPlonk(proof *PlonkProof, vk *PlonkVerifyingKey, publicWitness []fr.Element) error {
+	if len(publicWitness) != vk.NPublic {
+		return fmt.Errorf("invalid witness size, got %d, expected %d", len(publicWitness), vk.NPublic)
+	}
+
+	ch := plonkTranscript(proof, vk, publicWitness)
+
+	// ξⁿ, Z_H(ξ) = ξⁿ - 1 and the Lagrange polynomials Lᵢ(ξ) for every public input
+	var xin, zh, n fr.Element
+	xin.Set(&ch.xi)
+	for i := 0; i < vk.Power; i++ {
+		xin.Square(&xin)
+	}
+	zh.Sub(&xin, new(fr.Element).SetOne())
+	n.SetUint64(1 << vk.Power)
+
+	nLagrange := vk.NPublic
+	if nLagrange < 1 {
+		nLagrange = 1
+	}
+	lagrange := make([]fr.Element, nLagrange+1) // lagrange[i] is Lᵢ(ξ), 1-based as in snarkjs
+	var w fr.Element
+	w.SetOne()
+	for i := 1; i <= nLagrange; i++ {
+		var denom fr.Element
+		denom.Sub(&ch.xi, &w)
+		if denom.IsZero() {
+			return fmt.Errorf("challenge hit the evaluation domain")
+		}
+		denom.Mul(&denom, &n)
+		denom.Inverse(&denom)
+		lagrange[i].Mul(&w, &zh)
+		lagrange[i].Mul(&lagrange[i], &denom)
+		w.Mul(&w, &vk.Omega)
+	}
+
+	// PI(ξ) = -Σ xᵢ·Lᵢ₊₁(ξ)
+	var pi fr.Element
+	for i := range publicWitness {
+		var term fr.Element
+		term.Mul(&publicWitness[i], &lagrange[i+1])
+		pi.Sub(&pi, &term)
+	}
+
+	var alpha2, term fr.Element
+	alpha2.Square(&ch.alpha)
+
+	// r0 = PI(ξ) - L₁(ξ)·α² - α·(ā + β·s̄₁ + γ)(b̄ + β·s̄₂ + γ)(c̄ + γ)·z̄ω
+	var e3a, e3b, e3c, e3 fr.Element
+	e3a.Mul(&ch.beta, &proof.EvalS1).Add(&e3a, &proof.EvalA).Add(&e3a, &ch.gamma)
+	e3b.Mul(&ch.beta, &proof.EvalS2).Add(&e3b, &proof.EvalB).Add(&e3b, &ch.gamma)
+	e3c.Add(&proof.EvalC, &ch.gamma)
+	e3.Mul(&e3a, &e3b).Mul(&e3, &e3c).Mul(&e3, &proof.EvalZw).Mul(&e3, &ch.alpha)
+
+	var r0 fr.Element
+	term.Mul(&lagrange[1], &alpha2)
+	r0.Sub(&pi, &term).Sub(&r0, &e3)
+
+	// [D]₁ = Σ scalarᵢ·pointᵢ over the selector, permutation and quotient
+	// commitments; F folds in the opened commitments weighted by v
+	var ab, betaXi, d2a, d2, d3, negZh, xi2n fr.Element
+	ab.Mul(&proof.EvalA, &proof.EvalB)
+	betaXi.Mul(&ch.beta, &ch.xi)
+
+	var d2a1, d2a2, d2a3 fr.Element
+	d2a1.Add(&proof.EvalA, &betaXi).Add(&d2a1, &ch.gamma)
+	d2a2.Mul(&betaXi, &vk.K1).Add(&d2a2, &proof.EvalB).Add(&d2a2, &ch.gamma)
+	d2a3.Mul(&betaXi, &vk.K2).Add(&d2a3, &proof.EvalC).Add(&d2a3, &ch.gamma)
+	d2a.Mul(&d2a1, &d2a2).Mul(&d2a, &d2a3).Mul(&d2a, &ch.alpha)
+	term.Mul(&lagrange[1], &alpha2)
+	d2.Add(&d2a, &term).Add(&d2, &ch.u)
+
+	d3.Mul(&e3a, &e3b).Mul(&d3, &ch.alpha).Mul(&d3, &ch.beta).Mul(&d3, &proof.EvalZw)
+	d3.Neg(&d3)
+
+	negZh.Neg(&zh)
+	var negZhXin, negZhXi2n fr.Element
+	xi2n.Square(&xin)
+	negZhXin.Mul(&negZh, &xin)
+	negZhXi2n.Mul(&negZh, &xi2n)
+
+	points := []bn254.G1Affine{
+		vk.Qm, vk.Ql, vk.Qr, vk.Qo, vk.Qc,
+		proof.Z, vk.S3,
+		proof.T1, proof.T2, proof.T3,
+		proof.A, proof.B, proof.C, vk.S1, vk.S2,
+	}
+	scalars := []fr.Element{
+		ab, proof.EvalA, proof.EvalB, proof.EvalC, *new(fr.Element).SetOne(),
+		d2, d3,
+		negZh, negZhXin, negZhXi2n,
+		ch.v[1], ch.v[2], ch.v[3], ch.v[4], ch.v[5],
+	}
+
+	// E = (-r0 + Σ vᵢ·evalᵢ + u·z̄ω)·[1]₁ goes into the same MSM with a negated scalar
+	var e fr.Element
+	e.Neg(&r0)
+	openings := []*fr.Element{&proof.EvalA, &proof.EvalB, &proof.EvalC, &proof.EvalS1, &proof.EvalS2}
+	for i, eval := range openings {
+		term.Mul(&ch.v[i+1], eval)
+		e.Add(&e, &term)
+	}
+	term.Mul(&ch.u, &proof.EvalZw)
+	e.Add(&e, &term)
+	e.Neg(&e)
+
+	// B1 = ξ·Wξ + u·ξ·ω·Wξω + F - E
+	var uXiOmega fr.Element
+	uXiOmega.Mul(&ch.u, &ch.xi).Mul(&uXiOmega, &vk.Omega)
+
+	_, _, g1Gen, g2Gen := bn254.Generators()
+	points = append(points, g1Gen, proof.Wxi, proof.Wxiw)
+	scalars = append(scalars, e, ch.xi, uXiOmega)
+
+	var b1 bn254.G1Affine
+	if _, err := b1.MultiExp(points, scalars, ecc.MultiExpConfig{}); err != nil {
+		return fmt.Errorf("multi-exponentiation failed: %w", err)
+	}
+
+	// A1 = Wξ + u·Wξω; check e(-A1, [τ]₂)·e(B1, [1]₂) == 1
+	var a1, uWxiw bn254.G1Affine
+	uWxiw.ScalarMultiplication(&proof.Wxiw, ch.u.BigInt(new(big.Int)))
+	a1.Add(&proof.Wxi, &uWxiw)
+	a1.Neg(&a1)
+
+	ok, err := bn254.PairingCheck([]bn254.G1Affine{a1, b1}, []bn254.G2Affine{vk.X2, g2Gen})
+	if err != nil {
+		return fmt.Errorf("pairing failed: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("pairing check failed")
+	}
+	return nil
+}
+
+// plonkTranscript derives the challenges exactly like snarkjs'
+// Keccak256Transcript: each challenge hashes the previous one with the new
+// commitments or evaluations, big-endian and uncompressed, reduced mod r
+func plonkTranscript(proof *PlonkProof, vk *PlonkVerifyingKey, publicWitness []fr.Element) plonkChallenges {
+	var ch plonkChallenges
+	t := &keccakTranscript{}
+
+	t.addPoints(&vk.Qm, &vk.Ql, &vk.Qr, &vk.Qo, &vk.Qc, &vk.S1, &vk.S2, &vk.S3)
+	for i := range publicWitness {
+		t.addScalars(&publicWitness[i])
+	}
+	t.addPoints(&proof.A, &proof.B, &proof.C)
+	ch.beta = t.challenge()
+
+	t.addScalars(&ch.beta)
+	ch.gamma = t.challenge()
+
+	t.addScalars(&ch.beta, &ch.gamma)
+	t.addPoints(&proof.Z)
+	ch.alpha = t.challenge()
+
+	t.addScalars(&ch.alpha)
+	t.addPoints(&proof.T1, &proof.T2, &proof.T3)
+	ch.xi = t.challenge()
+
+	t.addScalars(&ch.xi, &proof.EvalA, &proof.EvalB, &proof.EvalC, &proof.EvalS1, &proof.EvalS2, &proof.EvalZw)
+	ch.v[1] = t.challenge()
+	for i := 2; i < len(ch.v); i++ {
+		ch.v[i].Mul(&ch.v[i-1], &ch.v[1])
+	}
+
+	t.addPoints(&proof.Wxi, &proof.Wxiw)
+	ch.u = t.challenge()
+
+	return ch
+}
+
+type keccakTranscript struct {
+	buf []byte
+}
+
+func (t *keccakTranscript) addPoints(points ...*bn254.G1Affine) {
+	for _, p := range points {
+		var enc [64]byte
+		if p.IsInfinity() {
+			// ffjavascript marks the point at infinity with the 0x40 flag
+			enc[0] = 0x40
+		} else {
+			x, y := p.X.Bytes(), p.Y.Bytes()
+			copy(enc[:32], x[:])
+			copy(enc[32:], y[:])
+		}
+		t.buf = append(t.buf, enc[:]...)
+	}
+}
+
+func (t *keccakTranscript) addScalars(scalars ...*fr.Element) {
+	for _, s := range scalars {
+		b := s.Bytes()
+		t.buf = append(t.buf, b[:]...)
+	}
+}
+
+// challenge hashes everything added since the previous challenge
+func (t *keccakTranscript) challenge() fr.Element {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(t.buf)
+	t.buf = t.buf[:0]
+
+	var c fr.Element
+	c.SetBigInt(new(big.Int).SetBytes(h.Sum(nil)))
+	return c
+}
+
+func parseFr(s string) (fr.Element, error) {
+	var e fr.Element
+	n, err := parseCanonical(s, fr.Modulus())
+	if err != nil {
+		return e, err
+	}
+	e.SetBigInt(n)
+	return e, nil
+}
@@ -0,0 +1,288 @@
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the latency histograms in PoolStats
+var latencyBuckets = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond,
+	25 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond,
+	250 * time.Millisecond, 500 * time.Millisecond, time.Second,
+}
+
+// Pool is a Verifier that runs another Verifier on a fixed set of worker
+// goroutines behind a bounded queue. Pairing checks are CPU bound, so the pool
+// caps how many run at once; when the queue is full Verify fails immediately
+// with ErrOverloaded instead of piling up work
+type Pool struct {
+	next    Verifier
+	workers int
+	timeout time.Duration
+
+	mu     sync.RWMutex // guards closed against sends on the closed queue
+	closed bool
+	jobs   chan *poolJob
+	wg     sync.WaitGroup
+
+	inFlight      atomic.Int64
+	maxQueueDepth atomic.Int64
+	completed     atomic.Uint64
+	rejected      atomic.Uint64
+	timedOut      atomic.Uint64
+
+	statsMu   sync.Mutex
+	queueWait latencyHistogram
+	verify    latencyHistogram
+}
+
+type poolJob struct {
+	ctx      context.Context
+	input    Input
+	enqueued time.Time
+	done     chan poolResult
+}
+
+type poolResult struct {
+	result Result
+	err    error
+}
+
+// NewPool starts workers goroutines verifying with next. workers <= 0 uses
+// GOMAXPROCS, queueSize <= 0 allows four queued requests per worker, and a
+// positive timeout bounds each request from enqueueing to its result
+func NewPool(next Verifier, workers, queueSize int, timeout time.Duration) *Pool {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if queueSize <= 0 {
+		queueSize = 4 * workers
+	}
+
+	p := &Pool{
+		next:    next,
+		workers: workers,
+		timeout: timeout,
+		jobs:    make(chan *poolJob, queueSize),
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Verify queues the input and waits for a worker to verify it, the request
+// deadline or the pool timeout, whichever comes first. Running out of the pool
+// timeout fails with ErrTimeout; when the caller's own context ends first,
+// Verify returns its error
+func (p *Pool) Verify(ctx context.Context, input Input) (Result, error) {
+	caller := ctx
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	job := &poolJob{
+		ctx:      ctx,
+		input:    input,
+		enqueued: time.Now(),
+		done:     make(chan poolResult, 1),
+	}
+
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return Result{Reason: ErrOverloaded}, newVerificationError(ErrOverloaded, fmt.Errorf("verifier pool is closed"))
+	}
+	select {
+	case p.jobs <- job:
+		p.noteQueueDepth(int64(len(p.jobs)))
+		p.mu.RUnlock()
+	default:
+		p.mu.RUnlock()
+		p.rejected.Add(1)
+		return Result{Reason: ErrOverloaded, RetryAfter: p.RetryAfter()},
+			newVerificationError(ErrOverloaded, fmt.Errorf("%d requests queued", cap(p.jobs)))
+	}
+
+	select {
+	case r := <-job.done:
+		return r.result, r.err
+	case <-ctx.Done():
+		waited := time.Since(job.enqueued)
+		if err := caller.Err(); err != nil {
+			return Result{Duration: waited}, err
+		}
+		p.timedOut.Add(1)
+		return Result{Reason: ErrTimeout, Duration: waited}, newVerificationError(ErrTimeout, ctx.Err())
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for job := range p.jobs {
+		// The caller has already given up; don't spend a pairing on it
+		if job.ctx.Err() != nil {
+			continue
+		}
+
+		wait := time.Since(job.enqueued)
+		p.inFlight.Add(1)
+		start := time.Now()
+		result, err := p.next.Verify(job.ctx, job.input)
+		elapsed := time.Since(start)
+		p.inFlight.Add(-1)
+
+		p.completed.Add(1)
+		p.statsMu.Lock()
+		p.queueWait.observe(wait)
+		p.verify.observe(elapsed)
+		p.statsMu.Unlock()
+
+		job.done <- poolResult{result: result, err: err}
+	}
+}
+
+func (p *Pool) noteQueueDepth(depth int64) {
+	for {
+		current := p.maxQueueDepth.Load()
+		if depth <= current || p.maxQueueDepth.CompareAndSwap(current, depth) {
+			return
+		}
+	}
+}
+
+// RetryAfter estimates how long the queued work takes to drain, at least one
+// second, for the Retry-After header of a rejected request
+func (p *Pool) RetryAfter() time.Duration {
+	p.statsMu.Lock()
+	mean := p.verify.mean()
+	p.statsMu.Unlock()
+
+	drain := time.Duration(len(p.jobs)+int(p.inFlight.Load())) * mean / time.Duration(p.workers)
+	if drain < time.Second {
+		return time.Second
+	}
+	return drain.Round(time.Second)
+}
+
+// Close stops accepting work, lets the workers finish what is queued and
+// waits for them to exit
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.jobs)
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+// PoolStats is a snapshot of the pool's load and latency
+type PoolStats struct {
+	Workers       int          `json:"workers"`
+	QueueCapacity int          `json:"queueCapacity"`
+	QueueDepth    int          `json:"queueDepth"`
+	MaxQueueDepth int64        `json:"maxQueueDepth"`
+	InFlight      int64        `json:"inFlight"`
+	Completed     uint64       `json:"completed"`
+	Rejected      uint64       `json:"rejected"`
+	TimedOut      uint64       `json:"timedOut"`
+	QueueWait     LatencyStats `json:"queueWait"`
+	Verify        LatencyStats `json:"verify"`
+}
+
+// LatencyStats summarises a latency histogram. Buckets are cumulative: each
+// counts the observations at or below its bound
+type LatencyStats struct {
+	Count   uint64          `json:"count"`
+	MeanMs  float64         `json:"meanMs"`
+	MaxMs   float64         `json:"maxMs"`
+	Buckets []LatencyBucket `json:"buckets"`
+}
+
+type LatencyBucket struct {
+	LeMs  float64 `json:"leMs"`
+	Count uint64  `json:"count"`
+}
+
+// Stats returns the current pool metrics
+func (p *Pool) Stats() PoolStats {
+	p.statsMu.Lock()
+	queueWait := p.queueWait.stats()
+	verify := p.verify.stats()
+	p.statsMu.Unlock()
+
+	return PoolStats{
+		Workers:       p.workers,
+		QueueCapacity: cap(p.jobs),
+		QueueDepth:    len(p.jobs),
+		MaxQueueDepth: p.maxQueueDepth.Load(),
+		InFlight:      p.inFlight.Load(),
+		Completed:     p.completed.Load(),
+		Rejected:      p.rejected.Load(),
+		TimedOut:      p.timedOut.Load(),
+		QueueWait:     queueWait,
+		Verify:        verify,
+	}
+}
+
+type latencyHistogram struct {
+	count   uint64
+	total   time.Duration
+	max     time.Duration
+	buckets []uint64 // parallel to latencyBuckets
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	if h.buckets == nil {
+		h.buckets = make([]uint64, len(latencyBuckets))
+	}
+	h.count++
+	h.total += d
+	if d > h.max {
+		h.max = d
+	}
+	for i, bound := range latencyBuckets {
+		if d <= bound {
+			h.buckets[i]++
+		}
+	}
+}
+
+func (h *latencyHistogram) mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return h.total / time.Duration(h.count)
+}
+
+func (h *latencyHistogram) stats() LatencyStats {
+	s := LatencyStats{
+		Count:   h.count,
+		MeanMs:  milliseconds(h.mean()),
+		MaxMs:   milliseconds(h.max),
+		Buckets: make([]LatencyBucket, len(latencyBuckets)),
+	}
+	for i, bound := range latencyBuckets {
+		s.Buckets[i].LeMs = milliseconds(bound)
+		if h.buckets != nil {
+			s.Buckets[i].Count = h.buckets[i]
+		}
+	}
+	return s
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,167 @@
+package verifier_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/threehook/zkp-auth/backend/verifier"
+)
+
+// blockingVerifier accepts every input once release is closed, signalling
+// each call it starts on started
+type blockingVerifier struct {
+	started chan struct{}
+	release chan struct{}
+	calls   atomic.Int64
+}
+
+func newBlockingVerifier() *blockingVerifier {
+	return &blockingVerifier{started: make(chan struct{}, 16), release: make(chan struct{})}
+}
+
+func (v *blockingVerifier) Verify(ctx context.Context, input verifier.Input) (verifier.Result, error) {
+	v.calls.Add(1)
+	v.started <- struct{}{}
+	<-v.release
+	return verifier.Result{Valid: true, CircuitID: input.CircuitID}, nil
+}
+
+func TestPoolPassesResultsThrough(t *testing.T) {
+	next := newBlockingVerifier()
+	close(next.release)
+	pool := verifier.NewPool(next, 2, 0, time.Minute)
+	defer pool.Close()
+
+	result, err := pool.Verify(context.Background(), verifier.Input{CircuitID: "password"})
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !result.Valid || result.CircuitID != "password" {
+		t.Fatalf("result = %+v, want the wrapped verifier's", result)
+	}
+	if completed := pool.Stats().Completed; completed != 1 {
+		t.Fatalf("Completed = %d, want 1", completed)
+	}
+}
+
+func TestPoolRejectsWhenQueueFull(t *testing.T) {
+	next := newBlockingVerifier()
+	pool := verifier.NewPool(next, 1, 1, time.Minute)
+	defer pool.Close()
+	defer close(next.release)
+
+	// One request occupies the worker and one the queue
+	for range 2 {
+		go pool.Verify(context.Background(), verifier.Input{})
+	}
+	<-next.started
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats().QueueDepth < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("second request was never queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	result, err := pool.Verify(context.Background(), verifier.Input{})
+	if !errors.Is(err, verifier.ErrOverloaded) {
+		t.Fatalf("Verify on a full queue: %v, want ErrOverloaded", err)
+	}
+	if waited := time.Since(start); waited > 100*time.Millisecond {
+		t.Fatalf("rejection took %s, want it immediate", waited)
+	}
+	if result.RetryAfter < time.Second {
+		t.Fatalf("RetryAfter = %s, want at least 1s", result.RetryAfter)
+	}
+	if rejected := pool.Stats().Rejected; rejected != 1 {
+		t.Fatalf("Rejected = %d, want 1", rejected)
+	}
+}
+
+func TestPoolTimesOut(t *testing.T) {
+	next := newBlockingVerifier()
+	pool := verifier.NewPool(next, 1, 1, 20*time.Millisecond)
+	defer pool.Close()
+	defer close(next.release)
+
+	result, err := pool.Verify(context.Background(), verifier.Input{})
+	if !errors.Is(err, verifier.ErrTimeout) {
+		t.Fatalf("Verify: %v, want ErrTimeout", err)
+	}
+	if !errors.Is(result.Reason, verifier.ErrTimeout) {
+		t.Fatalf("Reason = %v, want ErrTimeout", result.Reason)
+	}
+	if timedOut := pool.Stats().TimedOut; timedOut != 1 {
+		t.Fatalf("TimedOut = %d, want 1", timedOut)
+	}
+}
+
+func TestPoolReturnsCallerCancellation(t *testing.T) {
+	next := newBlockingVerifier()
+	pool := verifier.NewPool(next, 1, 1, time.Hour)
+	defer pool.Close()
+	defer close(next.release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-next.started
+		cancel()
+	}()
+	_, err := pool.Verify(ctx, verifier.Input{})
+	if !errors.Is(err, context.Canceled) || errors.Is(err, verifier.ErrTimeout) {
+		t.Fatalf("Verify: %v, want context.Canceled and not ErrTimeout", err)
+	}
+
+	deadlineCtx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = pool.Verify(deadlineCtx, verifier.Input{})
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, verifier.ErrTimeout) {
+		t.Fatalf("Verify past the caller's deadline: %v, want context.DeadlineExceeded and not ErrTimeout", err)
+	}
+	if timedOut := pool.Stats().TimedOut; timedOut != 0 {
+		t.Fatalf("TimedOut = %d, want 0 for caller cancellations", timedOut)
+	}
+}
+
+func TestPoolSkipsAbandonedRequests(t *testing.T) {
+	next := newBlockingVerifier()
+	pool := verifier.NewPool(next, 1, 1, time.Minute)
+
+	busy := make(chan error, 1)
+	go func() {
+		_, err := pool.Verify(context.Background(), verifier.Input{})
+		busy <- err
+	}()
+	<-next.started
+
+	// Queued behind the busy worker, then abandoned
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Verify(ctx, verifier.Input{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("abandoned Verify: %v, want context.DeadlineExceeded", err)
+	}
+
+	close(next.release)
+	if err := <-busy; err != nil {
+		t.Fatalf("busy Verify: %v", err)
+	}
+	pool.Close()
+	if calls := next.calls.Load(); calls != 1 {
+		t.Fatalf("wrapped verifier ran %d times, want 1", calls)
+	}
+}
+
+func TestPoolRejectsAfterClose(t *testing.T) {
+	next := newBlockingVerifier()
+	close(next.release)
+	pool := verifier.NewPool(next, 1, 1, time.Minute)
+	pool.Close()
+
+	if _, err := pool.Verify(context.Background(), verifier.Input{}); !errors.Is(err, verifier.ErrOverloaded) {
+		t.Fatalf("Verify after Close: %v, want ErrOverloaded", err)
+	}
+}
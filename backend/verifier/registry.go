@@ -0,0 +1,311 @@
+package verifier
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type CircuitStatus string
+
+const (
+	CircuitActive     CircuitStatus = "active"
+	CircuitDeprecated CircuitStatus = "deprecated" // still verified, but flagged
+	CircuitRevoked    CircuitStatus = "revoked"    // never verified again
+)
+
+// Circuit is one version of a circuit together with its verifying key and the
+// names of its public signals, in the order snarkjs emits them. Exactly one of
+// VerifyingKey (Groth16) and PlonkKey is set
+type Circuit struct {
+	ID            string
+	Version       int
+	PublicSignals []string
+	Status        CircuitStatus
+	VerifyingKey  *VerifyingKey
+	PlonkKey      *PlonkVerifyingKey
+	RegisteredAt  time.Time
+}
+
+// Protocol returns the proof system the circuit's key belongs to
+func (c Circuit) Protocol() string {
+	if c.PlonkKey != nil {
+		return ProtocolPlonk
+	}
+	return ProtocolGroth16
+}
+
+// NumPublic returns the number of public inputs the verifying key expects
+func (c Circuit) NumPublic() int {
+	switch {
+	case c.PlonkKey != nil:
+		return c.PlonkKey.NPublic
+	case c.VerifyingKey != nil && len(c.VerifyingKey.G1.K) > 0:
+		return len(c.VerifyingKey.G1.K) - 1
+	}
+	return -1
+}
+
+// SignalIndex returns the position of the named public signal, or -1
+func (c Circuit) SignalIndex(name string) int {
+	for i, signal := range c.PublicSignals {
+		if signal == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// CircuitInfo is the key-less view of a Circuit exposed to admins
+type CircuitInfo struct {
+	ID            string        `json:"id"`
+	Version       int           `json:"version"`
+	Protocol      string        `json:"protocol"`
+	PublicSignals []string      `json:"publicSignals"`
+	Status        CircuitStatus `json:"status"`
+	RegisteredAt  time.Time     `json:"registeredAt"`
+}
+
+// circuitKey identifies one version of a circuit
+type circuitKey struct {
+	id      string
+	version int
+}
+
+// Registry holds every known circuit version keyed by circuit ID and version
+type Registry struct {
+	mu        sync.RWMutex
+	circuits  map[string]map[int]*Circuit
+	revoked   map[circuitKey]bool // versions an admin revoked through SetStatus
+	defaultID string
+}
+
+func NewRegistry(defaultID string) *Registry {
+	return &Registry{
+		circuits:  make(map[string]map[int]*Circuit),
+		revoked:   make(map[circuitKey]bool),
+		defaultID: defaultID,
+	}
+}
+
+// DefaultID is the circuit used for requests that do not name one
+func (r *Registry) DefaultID() string {
+	return r.defaultID
+}
+
+// Register adds a circuit version; registering the same ID and version twice fails
+func (r *Registry) Register(circuit Circuit) error {
+	if err := validateCircuit(&circuit); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, exists := r.circuits[circuit.ID]
+	if !exists {
+		versions = make(map[int]*Circuit)
+		r.circuits[circuit.ID] = versions
+	}
+	if _, exists := versions[circuit.Version]; exists {
+		return fmt.Errorf("circuit %s v%d already registered", circuit.ID, circuit.Version)
+	}
+
+	versions[circuit.Version] = &circuit
+	return nil
+}
+
+// RegisterAll registers every circuit, stopping at the first failure
+func (r *Registry) RegisterAll(circuits []Circuit) error {
+	for _, circuit := range circuits {
+		if err := r.Register(circuit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Replace atomically swaps the whole registry contents for the given circuits.
+// Nothing changes unless every circuit is valid. Versions that survive the swap
+// keep the status an admin gave them unless the new definition sets one, and a
+// version an admin revoked stays revoked whatever the new definition says
+func (r *Registry) Replace(circuits []Circuit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	replacement := make(map[string]map[int]*Circuit)
+	for _, circuit := range circuits {
+		if previous, exists := r.circuits[circuit.ID][circuit.Version]; exists && circuit.Status == "" {
+			circuit.Status = previous.Status
+		}
+		if r.revoked[circuitKey{circuit.ID, circuit.Version}] {
+			circuit.Status = CircuitRevoked
+		}
+		if err := validateCircuit(&circuit); err != nil {
+			return err
+		}
+
+		versions, exists := replacement[circuit.ID]
+		if !exists {
+			versions = make(map[int]*Circuit)
+			replacement[circuit.ID] = versions
+		}
+		if _, exists := versions[circuit.Version]; exists {
+			return fmt.Errorf("circuit %s v%d defined twice", circuit.ID, circuit.Version)
+		}
+		versions[circuit.Version] = &circuit
+	}
+
+	r.circuits = replacement
+	return nil
+}
+
+// Lookup returns a copy of the exact circuit version, whatever its status
+func (r *Registry) Lookup(id string, version int) (Circuit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	circuit, exists := r.circuits[r.resolveID(id)][version]
+	if !exists {
+		return Circuit{}, newVerificationError(ErrUnknownCircuit, fmt.Errorf("%s v%d", r.resolveID(id), version))
+	}
+	return *circuit, nil
+}
+
+// Candidates returns the circuit versions a proof may be checked against. A
+// pinned version yields just that version; otherwise every non-revoked
+// version is returned newest first, so clients built against an older key
+// keep working while a rotation is in progress
+func (r *Registry) Candidates(id string, version int) ([]Circuit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id = r.resolveID(id)
+	versions, exists := r.circuits[id]
+	if !exists {
+		return nil, newVerificationError(ErrUnknownCircuit, fmt.Errorf("circuit %q", id))
+	}
+
+	if version != 0 {
+		circuit, exists := versions[version]
+		if !exists {
+			return nil, newVerificationError(ErrUnknownCircuit, fmt.Errorf("%s v%d", id, version))
+		}
+		if circuit.Status == CircuitRevoked {
+			return nil, newVerificationError(ErrCircuitRevoked, fmt.Errorf("%s v%d", id, version))
+		}
+		return []Circuit{*circuit}, nil
+	}
+
+	var candidates []Circuit
+	for _, circuit := range versions {
+		if circuit.Status != CircuitRevoked {
+			candidates = append(candidates, *circuit)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, newVerificationError(ErrCircuitRevoked, fmt.Errorf("every version of %s is revoked", id))
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Version > candidates[j].Version
+	})
+	return candidates, nil
+}
+
+// List returns every registered circuit version ordered by ID and version
+func (r *Registry) List() []CircuitInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var infos []CircuitInfo
+	for _, versions := range r.circuits {
+		for _, circuit := range versions {
+			infos = append(infos, CircuitInfo{
+				ID:            circuit.ID,
+				Version:       circuit.Version,
+				Protocol:      circuit.Protocol(),
+				PublicSignals: circuit.PublicSignals,
+				Status:        circuit.Status,
+				RegisteredAt:  circuit.RegisteredAt,
+			})
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].ID != infos[j].ID {
+			return infos[i].ID < infos[j].ID
+		}
+		return infos[i].Version < infos[j].Version
+	})
+	return infos
+}
+
+// SetStatus marks a circuit version active, deprecated or revoked. A
+// revocation outlives Replace until SetStatus gives the version another status
+func (r *Registry) SetStatus(id string, version int, status CircuitStatus) error {
+	if !status.valid() {
+		return fmt.Errorf("invalid circuit status %q", status)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id = r.resolveID(id)
+	circuit, exists := r.circuits[id][version]
+	if !exists {
+		return newVerificationError(ErrUnknownCircuit, fmt.Errorf("%s v%d", id, version))
+	}
+	circuit.Status = status
+	if status == CircuitRevoked {
+		r.revoked[circuitKey{id, version}] = true
+	} else {
+		delete(r.revoked, circuitKey{id, version})
+	}
+	return nil
+}
+
+func (r *Registry) resolveID(id string) string {
+	if id == "" {
+		return r.defaultID
+	}
+	return id
+}
+
+func (s CircuitStatus) valid() bool {
+	switch s {
+	case CircuitActive, CircuitDeprecated, CircuitRevoked:
+		return true
+	}
+	return false
+}
+
+func validateCircuit(circuit *Circuit) error {
+	if circuit.ID == "" {
+		return fmt.Errorf("circuit ID is required")
+	}
+	if circuit.Version <= 0 {
+		return fmt.Errorf("circuit %s: version must be positive", circuit.ID)
+	}
+	if circuit.VerifyingKey != nil && circuit.PlonkKey != nil {
+		return fmt.Errorf("circuit %s v%d: has both a Groth16 and a PLONK key", circuit.ID, circuit.Version)
+	}
+	if circuit.NumPublic() < 0 {
+		return fmt.Errorf("circuit %s v%d: verifying key not loaded", circuit.ID, circuit.Version)
+	}
+	if len(circuit.PublicSignals) != circuit.NumPublic() {
+		return fmt.Errorf("circuit %s v%d: layout names %d public signals but key expects %d",
+			circuit.ID, circuit.Version, len(circuit.PublicSignals), circuit.NumPublic())
+	}
+	if circuit.Status == "" {
+		circuit.Status = CircuitActive
+	}
+	if !circuit.Status.valid() {
+		return fmt.Errorf("circuit %s v%d: invalid status %q", circuit.ID, circuit.Version, circuit.Status)
+	}
+	if circuit.RegisteredAt.IsZero() {
+		circuit.RegisteredAt = time.Now()
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package verifier
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// ParseSnarkJSProof converts the JSON proof produced by snarkjs groth16.fullProve
+// (pi_a, pi_b, pi_c as decimal strings in projective form) into a Proof
+func ParseSnarkJSProof(proofData map[string]interface{}) (*Proof, error) {
+	if proofData == nil {
+		return nil, fmt.Errorf("proof is empty")
+	}
+
+	piA, err := toStrings(proofData["pi_a"])
+	if err != nil {
+		return nil, fmt.Errorf("pi_a: %w", err)
+	}
+	piB, err := toStringPairs(proofData["pi_b"])
+	if err != nil {
+		return nil, fmt.Errorf("pi_b: %w", err)
+	}
+	piC, err := toStrings(proofData["pi_c"])
+	if err != nil {
+		return nil, fmt.Errorf("pi_c: %w", err)
+	}
+
+	proof := NewProof()
+	if proof.Ar, err = parseG1(piA); err != nil {
+		return nil, fmt.Errorf("pi_a: %w", err)
+	}
+	if proof.Bs, err = parseG2(piB); err != nil {
+		return nil, fmt.Errorf("pi_b: %w", err)
+	}
+	if proof.Krs, err = parseG1(piC); err != nil {
+		return nil, fmt.Errorf("pi_c: %w", err)
+	}
+
+	// A proof point at infinity can never come out of an honest prover
+	if proof.Ar.IsInfinity() || proof.Bs.IsInfinity() || proof.Krs.IsInfinity() {
+		return nil, fmt.Errorf("proof contains point at infinity")
+	}
+
+	return proof, nil
+}
+
+// ParsePublicSignals converts snarkjs public signals (decimal strings) into
+// field elements, rejecting values outside the scalar field
+func ParsePublicSignals(publicSignals []interface{}) ([]fr.Element, error) {
+	witness := make([]fr.Element, len(publicSignals))
+	for i, signal := range publicSignals {
+		s, ok := signal.(string)
+		if !ok {
+			return nil, fmt.Errorf("public signal %d is not a string", i)
+		}
+		n, err := parseCanonical(s, fr.Modulus())
+		if err != nil {
+			return nil, fmt.Errorf("public signal %d: %w", i, err)
+		}
+		witness[i].SetBigInt(n)
+	}
+	return witness, nil
+}
+
+// FormatSnarkJSProof is the inverse of ParseSnarkJSProof: it lays the proof out
+// the way snarkjs does, as decoded JSON, so it can go straight into a
+// proof.Request
+func FormatSnarkJSProof(proof *Proof) map[string]interface{} {
+	return map[string]interface{}{
+		"pi_a":     formatG1(&proof.Ar),
+		"pi_b":     formatG2(&proof.Bs),
+		"pi_c":     formatG1(&proof.Krs),
+		"protocol": "groth16",
+		"curve":    "bn128",
+	}
+}
+
+// FormatPublicSignals is the inverse of ParsePublicSignals
+func FormatPublicSignals(publicWitness []fr.Element) []interface{} {
+	signals := make([]interface{}, len(publicWitness))
+	for i := range publicWitness {
+		signals[i] = publicWitness[i].String()
+	}
+	return signals
+}
+
+func formatG1(p *bn254.G1Affine) []interface{} {
+	if p.IsInfinity() {
+		return []interface{}{"0", "1", "0"}
+	}
+	return []interface{}{p.X.String(), p.Y.String(), "1"}
+}
+
+func formatG2(p *bn254.G2Affine) []interface{} {
+	if p.IsInfinity() {
+		return []interface{}{
+			[]interface{}{"0", "0"},
+			[]interface{}{"1", "0"},
+			[]interface{}{"0", "0"},
+		}
+	}
+	return []interface{}{
+		[]interface{}{p.X.A0.String(), p.X.A1.String()},
+		[]interface{}{p.Y.A0.String(), p.Y.A1.String()},
+		[]interface{}{"1", "0"},
+	}
+}
+
+// parseG1 parses a G1 point given as [x, y, z] with z either 1 or 0 (infinity)
+func parseG1(coords []string) (bn254.G1Affine, error) {
+	var p bn254.G1Affine
+	if len(coords) != 3 {
+		return p, fmt.Errorf("expected 3 coordinates, got %d", len(coords))
+	}
+
+	z, err := parseFp(coords[2])
+	if err != nil {
+		return p, err
+	}
+	if z.IsZero() {
+		return p, nil
+	}
+	if !z.IsOne() {
+		return p, fmt.Errorf("point is not in affine form")
+	}
+
+	if p.X, err = parseFp(coords[0]); err != nil {
+		return p, err
+	}
+	if p.Y, err = parseFp(coords[1]); err != nil {
+		return p, err
+	}
+	if !p.IsOnCurve() {
+		return p, fmt.Errorf("point is not on curve")
+	}
+	return p, nil
+}
+
+// parseG2 parses a G2 point given as [[x0, x1], [y0, y1], [z0, z1]]
+func parseG2(coords [][]string) (bn254.G2Affine, error) {
+	var p bn254.G2Affine
+	if len(coords) != 3 {
+		return p, fmt.Errorf("expected 3 coordinates, got %d", len(coords))
+	}
+
+	z0, z1, err := parseFp2(coords[2])
+	if err != nil {
+		return p, err
+	}
+	if z0.IsZero() && z1.IsZero() {
+		return p, nil
+	}
+	if !z0.IsOne() || !z1.IsZero() {
+		return p, fmt.Errorf("point is not in affine form")
+	}
+
+	if p.X.A0, p.X.A1, err = parseFp2(coords[0]); err != nil {
+		return p, err
+	}
+	if p.Y.A0, p.Y.A1, err = parseFp2(coords[1]); err != nil {
+		return p, err
+	}
+	if !p.IsOnCurve() {
+		return p, fmt.Errorf("point is not on curve")
+	}
+	return p, nil
+}
+
+func parseFp2(coords []string) (fp.Element, fp.Element, error) {
+	var a0, a1 fp.Element
+	if len(coords) != 2 {
+		return a0, a1, fmt.Errorf("expected 2 components, got %d", len(coords))
+	}
+	var err error
+	if a0, err = parseFp(coords[0]); err != nil {
+		return a0, a1, err
+	}
+	if a1, err = parseFp(coords[1]); err != nil {
+		return a0, a1, err
+	}
+	return a0, a1, nil
+}
+
+func parseFp(s string) (fp.Element, error) {
+	var e fp.Element
+	n, err := parseCanonical(s, fp.Modulus())
+	if err != nil {
+		return e, err
+	}
+	e.SetBigInt(n)
+	return e, nil
+}
+
+// parseCanonical parses a decimal string and requires it to be reduced modulo
+// the given modulus, so that a value and value+modulus are not both accepted
+func parseCanonical(s string, modulus *big.Int) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal value %q", s)
+	}
+	if n.Sign() < 0 || n.Cmp(modulus) >= 0 {
+		return nil, fmt.Errorf("value out of field range")
+	}
+	return n, nil
+}
+
+func toStrings(v interface{}) ([]string, error) {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array")
+	}
+	out := make([]string, len(items))
+	for i, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("element %d is not a string", i)
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+func toStringPairs(v interface{}) ([][]string, error) {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an array")
+	}
+	out := make([][]string, len(items))
+	for i, item := range items {
+		pair, err := toStrings(item)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
+		}
+		out[i] = pair
+	}
+	return out, nil
+}
@@ -1,17 +1,201 @@
 package verifier
 
-type ZKPVerifier struct {
-	basicVerifier *Groth16Verifier // Now this will resolve correctly
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/threehook/zkp-auth/backend/proof"
+)
+
+// Verifier checks a zero-knowledge proof for a user. Implementations return a
+// Result in every case and, when the proof is not accepted, an error that
+// wraps Result.Reason. Handlers depend on this interface so the registry
+// backed verifier can be swapped for a mock or a remote service
+type Verifier interface {
+	Verify(ctx context.Context, input Input) (Result, error)
+}
+
+// VerifierFunc adapts an ordinary function to the Verifier interface
+type VerifierFunc func(ctx context.Context, input Input) (Result, error)
+
+func (f VerifierFunc) Verify(ctx context.Context, input Input) (Result, error) {
+	return f(ctx, input)
+}
+
+// Input is a proof together with everything its public signals may commit to
+type Input struct {
+	CircuitID      string // empty selects the registry's default circuit
+	CircuitVersion int    // 0 tries every non-revoked version, newest first
+
+	// Proof is the snarkjs proof object; its protocol field selects the proof system
+	Proof         map[string]interface{}
+	PublicSignals []interface{}
+
+	// Request context and stored credential that bound public signals must match
+	Username   string
+	Nonce      string
+	Timestamp  int64
+	Credential Credential
+}
+
+// NewInput builds the Input for a proof request against a user's credential
+func NewInput(proofReq proof.Request, credential Credential) Input {
+	return Input{
+		CircuitID:      proofReq.CircuitID,
+		CircuitVersion: proofReq.CircuitVersion,
+		Proof:          proofReq.Proof,
+		PublicSignals:  proofReq.PublicSignals,
+		Username:       proofReq.Username,
+		Nonce:          proofReq.Nonce,
+		Timestamp:      proofReq.Timestamp,
+		Credential:     credential,
+	}
+}
+
+// Result describes one verification. On failure the circuit fields name the
+// last circuit version that was tried, if any
+type Result struct {
+	Valid          bool
+	CircuitID      string
+	CircuitVersion int
+	Protocol       string
+	Status         CircuitStatus
+	Bound          bool          // the circuit binds the nonce, timestamp and username
+	Reason         error         // one of the Err* reasons; nil when Valid
+	Duration       time.Duration // time spent verifying
+	RetryAfter     time.Duration // set with ErrOverloaded: when a retry is likely to be admitted
+}
+
+func (r *Result) setCircuit(circuit Circuit) {
+	r.CircuitID = circuit.ID
+	r.CircuitVersion = circuit.Version
+	r.Protocol = circuit.Protocol()
+	r.Status = circuit.Status
+	r.Bound = circuit.BindsRequest()
 }
 
-func NewZKPVerifier() (*ZKPVerifier, error) {
-	basicVerifier := NewGroth16Verifier() // This now exists
+// RegistryVerifier verifies snarkjs Groth16 and PLONK proofs against the
+// circuits in a Registry
+type RegistryVerifier struct {
+	Registry *Registry
+	Batcher  *Batcher // optional; nil verifies every proof on its own
+
+	// RequireBinding rejects circuits whose public signals do not commit to
+	// the request nonce, timestamp and username
+	RequireBinding bool
+}
+
+func NewRegistryVerifier(registry *Registry) *RegistryVerifier {
+	return &RegistryVerifier{
+		Registry: registry,
+	}
+}
+
+// Verify parses the proof and public signals and checks them against the
+// circuit the input names. The proof's protocol field selects Groth16 or PLONK
+// verification; only circuits whose key uses the same protocol are tried.
+// Public signals the layout binds must match the input's request context and
+// credential
+func (v *RegistryVerifier) Verify(ctx context.Context, input Input) (Result, error) {
+	start := time.Now()
+	result, err := v.verify(ctx, input)
+	result.Duration = time.Since(start)
+	result.Valid = err == nil
+
+	var verr *VerificationError
+	if errors.As(err, &verr) {
+		result.Reason = verr.Reason
+	}
+	return result, err
+}
+
+func (v *RegistryVerifier) verify(ctx context.Context, input Input) (Result, error) {
+	var result Result
+	if v.Registry == nil {
+		return result, newVerificationError(ErrNoVerifyingKey, nil)
+	}
+
+	candidates, err := v.Registry.Candidates(input.CircuitID, input.CircuitVersion)
+	if err != nil {
+		return result, err
+	}
+
+	protocol, _ := input.Proof["protocol"].(string)
+	if protocol == "" {
+		protocol = ProtocolGroth16
+	}
+
+	var groth16Proof *Proof
+	var plonkProof *PlonkProof
+	switch protocol {
+	case ProtocolGroth16:
+		groth16Proof, err = ParseSnarkJSProof(input.Proof)
+	case ProtocolPlonk:
+		plonkProof, err = ParsePlonkProof(input.Proof)
+	default:
+		err = fmt.Errorf("unsupported protocol %q", protocol)
+	}
+	if err != nil {
+		return result, newVerificationError(ErrMalformedProof, err)
+	}
+
+	publicWitness, err := ParsePublicSignals(input.PublicSignals)
+	if err != nil {
+		return result, newVerificationError(ErrMalformedSignals, err)
+	}
+
+	var lastErr error
+	for _, circuit := range candidates {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		result.setCircuit(circuit)
+
+		if circuit.Protocol() != protocol {
+			lastErr = newVerificationError(ErrMalformedProof,
+				fmt.Errorf("%s v%d expects %s proofs", circuit.ID, circuit.Version, circuit.Protocol()))
+			continue
+		}
+		if len(publicWitness) != len(circuit.PublicSignals) {
+			lastErr = newVerificationError(ErrMalformedSignals,
+				fmt.Errorf("got %d public signals, %s v%d expects %d",
+					len(publicWitness), circuit.ID, circuit.Version, len(circuit.PublicSignals)))
+			continue
+		}
+
+		if !circuit.BindsCredential() {
+			lastErr = newVerificationError(ErrUnboundCredential, fmt.Errorf("%s v%d", circuit.ID, circuit.Version))
+			continue
+		}
+		if v.RequireBinding && !circuit.BindsRequest() {
+			lastErr = newVerificationError(ErrUnboundCircuit, fmt.Errorf("%s v%d", circuit.ID, circuit.Version))
+			continue
+		}
+		if err := checkBindings(circuit, input, publicWitness); err != nil {
+			lastErr = newVerificationError(ErrSignalMismatch, err)
+			continue
+		}
 
-	return &ZKPVerifier{
-		basicVerifier: basicVerifier,
-	}, nil
+		if protocol == ProtocolPlonk {
+			err = VerifyPlonk(plonkProof, circuit.PlonkKey, publicWitness)
+		} else {
+			err = v.verifyGroth16(ctx, groth16Proof, circuit.VerifyingKey, publicWitness)
+		}
+		if err != nil {
+			lastErr = newVerificationError(ErrInvalidProof, err)
+			continue
+		}
+		return result, nil
+	}
+	return result, lastErr
 }
 
-func (v *ZKPVerifier) VerifyProof(proofData map[string]interface{}) bool {
-	return v.basicVerifier.VerifyProof(proofData)
+func (v *RegistryVerifier) verifyGroth16(ctx context.Context, proof *Proof, vk *VerifyingKey, publicWitness []fr.Element) error {
+	if v.Batcher != nil {
+		return v.Batcher.Verify(ctx, vk, proof, publicWitness)
+	}
+	return Verify(proof, vk, publicWitness)
 }
@@ -0,0 +1,262 @@
+package verifier_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/threehook/zkp-auth/backend/credential"
+	"github.com/threehook/zkp-auth/backend/proof"
+	"github.com/threehook/zkp-auth/backend/prover"
+	"github.com/threehook/zkp-auth/backend/verifier"
+)
+
+// loadProver sets up the gnark circuit once per test binary
+var loadProver = sync.OnceValues(func() (*prover.Prover, error) {
+	return prover.Setup(1)
+})
+
+type testLogin struct {
+	request    proof.Request
+	credential verifier.Credential
+}
+
+// newLogin registers a credential for username and proves knowledge of it
+func newLogin(t testing.TB, username, password string) testLogin {
+	t.Helper()
+
+	p, err := loadProver()
+	if err != nil {
+		t.Fatalf("load prover: %v", err)
+	}
+	salt, err := credential.GenerateSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitment, err := credential.Compute(password, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credential := verifier.Credential{Salt: salt, Commitment: commitment}
+
+	request, err := p.Prove(username, password, credential, "nonce-"+username, time.Now().Unix())
+	if err != nil {
+		t.Fatalf("prove: %v", err)
+	}
+	return testLogin{request: request, credential: credential}
+}
+
+func (l testLogin) input() verifier.Input {
+	return verifier.NewInput(l.request, l.credential)
+}
+
+func newRegistryVerifier(t testing.TB, circuits ...verifier.Circuit) *verifier.RegistryVerifier {
+	t.Helper()
+
+	if len(circuits) == 0 {
+		p, err := loadProver()
+		if err != nil {
+			t.Fatalf("load prover: %v", err)
+		}
+		circuits = append(circuits, p.Circuit())
+	}
+	registry := verifier.NewRegistry(prover.CircuitID)
+	if err := registry.RegisterAll(circuits); err != nil {
+		t.Fatal(err)
+	}
+	return verifier.NewRegistryVerifier(registry)
+}
+
+func TestVerifyAcceptsProofForStoredCredential(t *testing.T) {
+	v := newRegistryVerifier(t)
+	login := newLogin(t, "alice", "correct horse")
+
+	result, err := v.Verify(context.Background(), login.input())
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !result.Valid || !result.Bound {
+		t.Fatalf("result = %+v, want valid and bound", result)
+	}
+}
+
+func TestVerifyRejectsProofForAnotherCredential(t *testing.T) {
+	v := newRegistryVerifier(t)
+	alice := newLogin(t, "alice", "correct horse")
+	bob := newLogin(t, "bob", "battery staple")
+
+	// Alice's valid proof presented as a login for Bob's account
+	input := alice.input()
+	input.Credential = bob.credential
+
+	_, err := v.Verify(context.Background(), input)
+	if !errors.Is(err, verifier.ErrSignalMismatch) {
+		t.Fatalf("err = %v, want ErrSignalMismatch", err)
+	}
+}
+
+func TestVerifyRejectsCircuitWithoutCredentialSignals(t *testing.T) {
+	p, err := loadProver()
+	if err != nil {
+		t.Fatal(err)
+	}
+	circuit := p.Circuit()
+	circuit.PublicSignals = []string{"a", "b", verifier.SignalNonce, verifier.SignalTimestamp, verifier.SignalUsernameHash}
+	v := newRegistryVerifier(t, circuit)
+	login := newLogin(t, "alice", "correct horse")
+
+	// The proof itself is valid; only the credential is not tied to it
+	_, err = v.Verify(context.Background(), login.input())
+	if !errors.Is(err, verifier.ErrUnboundCredential) {
+		t.Fatalf("err = %v, want ErrUnboundCredential", err)
+	}
+}
+
+func TestVerifyRejectsReboundRequest(t *testing.T) {
+	v := newRegistryVerifier(t)
+	login := newLogin(t, "alice", "correct horse")
+
+	tests := map[string]func(*verifier.Input){
+		"nonce":     func(in *verifier.Input) { in.Nonce = "another-nonce" },
+		"timestamp": func(in *verifier.Input) { in.Timestamp++ },
+		"username":  func(in *verifier.Input) { in.Username = "mallory" },
+	}
+	for name, rebind := range tests {
+		t.Run(name, func(t *testing.T) {
+			input := login.input()
+			rebind(&input)
+			if _, err := v.Verify(context.Background(), input); !errors.Is(err, verifier.ErrSignalMismatch) {
+				t.Fatalf("err = %v, want ErrSignalMismatch", err)
+			}
+		})
+	}
+}
+
+// cloneProof deep-copies a snarkjs proof object the way it arrives over the wire
+func cloneProof(t *testing.T, proof map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var clone map[string]interface{}
+	if err := json.Unmarshal(data, &clone); err != nil {
+		t.Fatal(err)
+	}
+	return clone
+}
+
+// addModulus returns the decimal coordinate plus k times the base field modulus
+func addModulus(t *testing.T, coord string, k int64) string {
+	t.Helper()
+	n, ok := new(big.Int).SetString(coord, 10)
+	if !ok {
+		t.Fatalf("coordinate %q is not decimal", coord)
+	}
+	return n.Add(n, new(big.Int).Mul(fp.Modulus(), big.NewInt(k))).String()
+}
+
+// negateG1 replaces the y coordinate of a snarkjs G1 point with -y, which
+// keeps the point on the curve and in the subgroup
+func negateG1(t *testing.T, point interface{}) {
+	t.Helper()
+	coords := point.([]interface{})
+	coords[1] = addModulus(t, "-"+coords[1].(string), 1)
+}
+
+// negateG2 is negateG1 for a snarkjs G2 point
+func negateG2(t *testing.T, point interface{}) {
+	t.Helper()
+	y := point.([]interface{})[1].([]interface{})
+	y[0] = addModulus(t, "-"+y[0].(string), 1)
+	y[1] = addModulus(t, "-"+y[1].(string), 1)
+}
+
+func TestVerifyRejectsTamperedGroth16Proof(t *testing.T) {
+	v := newRegistryVerifier(t)
+	login := newLogin(t, "alice", "correct horse")
+	nonceIndex := slices.Index(prover.PublicSignals, verifier.SignalNonce)
+	timestampIndex := slices.Index(prover.PublicSignals, verifier.SignalTimestamp)
+
+	tests := []struct {
+		name   string
+		tamper func(in *verifier.Input)
+		want   error
+	}{
+		// Valid points that are not the proof
+		{"negated A", func(in *verifier.Input) { negateG1(t, in.Proof["pi_a"]) }, verifier.ErrInvalidProof},
+		{"negated B", func(in *verifier.Input) { negateG2(t, in.Proof["pi_b"]) }, verifier.ErrInvalidProof},
+		{"negated C", func(in *verifier.Input) { negateG1(t, in.Proof["pi_c"]) }, verifier.ErrInvalidProof},
+		{"A and C swapped", func(in *verifier.Input) {
+			in.Proof["pi_a"], in.Proof["pi_c"] = in.Proof["pi_c"], in.Proof["pi_a"]
+		}, verifier.ErrInvalidProof},
+
+		// A signal changed together with the plaintext it binds passes the
+		// binding checks and must fail the pairing check
+		{"nonce rebound", func(in *verifier.Input) {
+			in.Nonce = "another-nonce"
+			field := verifier.HashToField(in.Nonce)
+			in.PublicSignals[nonceIndex] = field.String()
+		}, verifier.ErrInvalidProof},
+		{"timestamp rebound", func(in *verifier.Input) {
+			in.Timestamp++
+			in.PublicSignals[timestampIndex] = strconv.FormatInt(in.Timestamp, 10)
+		}, verifier.ErrInvalidProof},
+
+		{"A off the curve", func(in *verifier.Input) {
+			a := in.Proof["pi_a"].([]interface{})
+			y, _ := new(big.Int).SetString(a[1].(string), 10)
+			a[1] = y.Add(y, big.NewInt(1)).Mod(y, fp.Modulus()).String()
+		}, verifier.ErrMalformedProof},
+		{"B off the curve", func(in *verifier.Input) {
+			b := in.Proof["pi_b"].([]interface{})[0].([]interface{})
+			b[0] = "1"
+		}, verifier.ErrMalformedProof},
+		{"non-canonical A coordinate", func(in *verifier.Input) {
+			a := in.Proof["pi_a"].([]interface{})
+			a[0] = addModulus(t, a[0].(string), 1)
+		}, verifier.ErrMalformedProof},
+		{"non-canonical C coordinate", func(in *verifier.Input) {
+			c := in.Proof["pi_c"].([]interface{})
+			c[1] = addModulus(t, c[1].(string), 1)
+		}, verifier.ErrMalformedProof},
+		{"A at infinity", func(in *verifier.Input) {
+			in.Proof["pi_a"] = []interface{}{"0", "1", "0"}
+		}, verifier.ErrMalformedProof},
+
+		{"missing signal", func(in *verifier.Input) {
+			in.PublicSignals = in.PublicSignals[:len(in.PublicSignals)-1]
+		}, verifier.ErrMalformedSignals},
+		{"extra signal", func(in *verifier.Input) {
+			in.PublicSignals = append(in.PublicSignals, "1")
+		}, verifier.ErrMalformedSignals},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := login.input()
+			input.Proof = cloneProof(t, input.Proof)
+			input.PublicSignals = slices.Clone(input.PublicSignals)
+			tt.tamper(&input)
+
+			result, err := v.Verify(context.Background(), input)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if result.Valid {
+				t.Fatal("result is valid")
+			}
+		})
+	}
+
+	// The untouched request still verifies, so every failure above is the tampering
+	if _, err := v.Verify(context.Background(), login.input()); err != nil {
+		t.Fatalf("Verify of the original: %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package verifier
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/threehook/zkp-auth/backend/security"
+)
+
+// KeyWatcher polls a directory of circuit manifests and verification keys and
+// swaps them into the registry, next to the built-in circuits the server set
+// up itself, whenever its contents change
+type KeyWatcher struct {
+	dir             string
+	interval        time.Duration
+	registry        *Registry
+	builtin         []Circuit
+	securityMonitor *security.SecurityMonitor
+
+	mu          sync.Mutex
+	loaded      bool
+	fingerprint string
+}
+
+func NewKeyWatcher(dir string, interval time.Duration, registry *Registry, builtin []Circuit,
+	securityMonitor *security.SecurityMonitor) *KeyWatcher {
+	return &KeyWatcher{
+		dir:             dir,
+		interval:        interval,
+		registry:        registry,
+		builtin:         builtin,
+		securityMonitor: securityMonitor,
+	}
+}
+
+// Run polls until ctx is cancelled
+func (w *KeyWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.Reload(ctx); err != nil {
+				log.Printf("Verification key reload failed: %v", err)
+			}
+		}
+	}
+}
+
+// Reload loads the directory if it changed since the last call. Directory
+// circuits are merged over the built-in ones: a version the directory defines
+// replaces the built-in version with the same ID and version number, and every
+// other built-in circuit stays registered. Keys are only swapped in once all
+// of them have been parsed and validated
+func (w *KeyWatcher) Reload(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	fingerprint, err := w.dirFingerprint()
+	if err != nil {
+		return err
+	}
+	if w.loaded && fingerprint == w.fingerprint {
+		return nil
+	}
+	w.loaded = true
+	w.fingerprint = fingerprint
+
+	circuits, source := w.builtin, "builtin"
+	if fingerprint != "" {
+		loaded, err := LoadCircuits(os.DirFS(w.dir))
+		if err != nil {
+			w.securityMonitor.LogEvent(ctx, "VERIFICATION_KEY_REJECTED", "", "", "", "", "",
+				fmt.Sprintf("dir=%s error=%s", w.dir, err.Error()), "ERROR")
+			return err
+		}
+		if len(loaded) > 0 {
+			circuits, source = mergeCircuits(w.builtin, loaded), "builtin+"+w.dir
+		}
+	}
+
+	if err := w.registry.Replace(circuits); err != nil {
+		w.securityMonitor.LogEvent(ctx, "VERIFICATION_KEY_REJECTED", "", "", "", "", "",
+			fmt.Sprintf("source=%s error=%s", source, err.Error()), "ERROR")
+		return err
+	}
+
+	w.securityMonitor.LogEvent(ctx, "VERIFICATION_KEY_SWAPPED", "", "", "", "", "",
+		fmt.Sprintf("source=%s circuits=%s", source, describeCircuits(circuits)), "INFO")
+	return nil
+}
+
+// mergeCircuits returns base with every circuit version that overrides also
+// defines replaced, followed by the versions only overrides defines
+func mergeCircuits(base, overrides []Circuit) []Circuit {
+	overridden := make(map[circuitKey]bool, len(overrides))
+	for _, circuit := range overrides {
+		overridden[circuitKey{circuit.ID, circuit.Version}] = true
+	}
+
+	merged := make([]Circuit, 0, len(base)+len(overrides))
+	for _, circuit := range base {
+		if !overridden[circuitKey{circuit.ID, circuit.Version}] {
+			merged = append(merged, circuit)
+		}
+	}
+	return append(merged, overrides...)
+}
+
+// dirFingerprint hashes the name and contents of every file in the directory,
+// returning "" when the directory is missing or empty
+func (w *KeyWatcher) dirFingerprint() (string, error) {
+	hash := sha256.New()
+	files := 0
+
+	err := filepath.WalkDir(w.dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		fmt.Fprintf(hash, "%s\x00", name)
+		if _, err := io.Copy(hash, f); err != nil {
+			return err
+		}
+		files++
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("scan %s: %w", w.dir, err)
+	}
+	if files == 0 {
+		return "", nil
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func describeCircuits(circuits []Circuit) string {
+	names := make([]string, len(circuits))
+	for i, circuit := range circuits {
+		names[i] = fmt.Sprintf("%s@v%d", circuit.ID, circuit.Version)
+	}
+	return strings.Join(names, ",")
+}
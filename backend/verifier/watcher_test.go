@@ -0,0 +1,223 @@
+package verifier_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/threehook/zkp-auth/backend/prover"
+	"github.com/threehook/zkp-auth/backend/security"
+	"github.com/threehook/zkp-auth/backend/verifier"
+)
+
+// registered maps every circuit version in the registry to its status
+func registered(registry *verifier.Registry) map[string]verifier.CircuitStatus {
+	circuits := make(map[string]verifier.CircuitStatus)
+	for _, info := range registry.List() {
+		circuits[fmt.Sprintf("%s@v%d", info.ID, info.Version)] = info.Status
+	}
+	return circuits
+}
+
+func TestKeyWatcherMergesDirectoryOverBuiltin(t *testing.T) {
+	p, err := loadProver()
+	if err != nil {
+		t.Fatalf("load prover: %v", err)
+	}
+	builtin := p.Circuit()
+
+	dir := t.TempDir()
+	registry := verifier.NewRegistry(prover.CircuitID)
+	watcher := verifier.NewKeyWatcher(dir, time.Hour, registry, []verifier.Circuit{builtin}, security.NewSecurityMonitor(100))
+	ctx := context.Background()
+
+	// A manifest for another circuit adds to the built-in set
+	extra := builtin
+	extra.ID, extra.Version = "password", 2
+	if err := verifier.WriteCircuit(filepath.Join(dir, "password", "v2"), extra); err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	got := registered(registry)
+	if len(got) != 2 || got["password-gnark@v1"] != verifier.CircuitActive || got["password@v2"] != verifier.CircuitActive {
+		t.Fatalf("registry = %v, want the built-in password-gnark v1 and password v2", got)
+	}
+
+	// The same ID and version replaces the built-in definition
+	override := builtin
+	override.Status = verifier.CircuitDeprecated
+	if err := verifier.WriteCircuit(filepath.Join(dir, "password-gnark", "v1"), override); err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	got = registered(registry)
+	if len(got) != 2 || got["password-gnark@v1"] != verifier.CircuitDeprecated {
+		t.Fatalf("registry = %v, want password-gnark v1 deprecated by the directory", got)
+	}
+
+	// An emptied directory leaves the built-in set
+	if err := os.RemoveAll(filepath.Join(dir, "password")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "password-gnark")); err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	got = registered(registry)
+	if len(got) != 1 || got["password-gnark@v1"] == "" {
+		t.Fatalf("registry = %v, want only the built-in password-gnark v1", got)
+	}
+}
+
+// rejections returns the VERIFICATION_KEY_REJECTED events the monitor logged
+func rejections(t *testing.T, monitor *security.SecurityMonitor) []security.SecurityEvent {
+	t.Helper()
+	events, err := monitor.GetEvents(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rejected []security.SecurityEvent
+	for _, event := range events {
+		if event.Type == "VERIFICATION_KEY_REJECTED" {
+			rejected = append(rejected, event)
+		}
+	}
+	return rejected
+}
+
+func TestKeyWatcherRejectsBadKeys(t *testing.T) {
+	p, err := loadProver()
+	if err != nil {
+		t.Fatalf("load prover: %v", err)
+	}
+	builtin := p.Circuit()
+	extra := builtin
+	extra.ID, extra.Version = "password", 2
+
+	tests := []struct {
+		name     string
+		breakDir func(t *testing.T, dir string)
+	}{
+		{"point off the curve", func(t *testing.T, dir string) {
+			keyPath := filepath.Join(dir, "password", "v2", "verification_key.json")
+			data, err := os.ReadFile(keyPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var key map[string]interface{}
+			if err := json.Unmarshal(data, &key); err != nil {
+				t.Fatal(err)
+			}
+			key["vk_alpha_1"] = []string{"1", "3", "1"}
+			if data, err = json.Marshal(key); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(keyPath, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"version defined twice", func(t *testing.T, dir string) {
+			if err := verifier.WriteCircuit(filepath.Join(dir, "password", "v2-copy"), extra); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			monitor := security.NewSecurityMonitor(100)
+			registry := verifier.NewRegistry(prover.CircuitID)
+			watcher := verifier.NewKeyWatcher(dir, time.Hour, registry, []verifier.Circuit{builtin}, monitor)
+			ctx := context.Background()
+
+			if err := verifier.WriteCircuit(filepath.Join(dir, "password", "v2"), extra); err != nil {
+				t.Fatal(err)
+			}
+			if err := watcher.Reload(ctx); err != nil {
+				t.Fatalf("Reload: %v", err)
+			}
+
+			tt.breakDir(t, dir)
+			if err := watcher.Reload(ctx); err == nil {
+				t.Fatal("Reload accepted the broken directory")
+			}
+
+			// The keys loaded before stay in place
+			got := registered(registry)
+			if len(got) != 2 || got["password-gnark@v1"] != verifier.CircuitActive || got["password@v2"] != verifier.CircuitActive {
+				t.Fatalf("registry = %v, want the keys from before the broken reload", got)
+			}
+			rejected := rejections(t, monitor)
+			if len(rejected) != 1 || rejected[0].Severity != "ERROR" {
+				t.Fatalf("rejection events = %+v, want one ERROR", rejected)
+			}
+		})
+	}
+}
+
+func TestKeyWatcherKeepsAdminRevocation(t *testing.T) {
+	p, err := loadProver()
+	if err != nil {
+		t.Fatalf("load prover: %v", err)
+	}
+	builtin := p.Circuit()
+
+	dir := t.TempDir()
+	registry := verifier.NewRegistry(prover.CircuitID)
+	watcher := verifier.NewKeyWatcher(dir, time.Hour, registry, []verifier.Circuit{builtin}, security.NewSecurityMonitor(100))
+	ctx := context.Background()
+	if err := watcher.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if err := registry.SetStatus(prover.CircuitID, 1, verifier.CircuitRevoked); err != nil {
+		t.Fatal(err)
+	}
+
+	// A manifest that calls the version active does not undo the revocation
+	override := builtin
+	override.Status = verifier.CircuitActive
+	if err := verifier.WriteCircuit(filepath.Join(dir, "password-gnark", "v1"), override); err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if got := registered(registry); got["password-gnark@v1"] != verifier.CircuitRevoked {
+		t.Fatalf("registry = %v, want password-gnark v1 still revoked", got)
+	}
+
+	// Nor does falling back to the built-in definition
+	if err := os.RemoveAll(filepath.Join(dir, "password-gnark")); err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if got := registered(registry); got["password-gnark@v1"] != verifier.CircuitRevoked {
+		t.Fatalf("registry = %v, want password-gnark v1 still revoked", got)
+	}
+
+	// Only an admin lifts it
+	if err := registry.SetStatus(prover.CircuitID, 1, verifier.CircuitActive); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.WriteCircuit(filepath.Join(dir, "password-gnark", "v1"), override); err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if got := registered(registry); got["password-gnark@v1"] != verifier.CircuitActive {
+		t.Fatalf("registry = %v, want password-gnark v1 active again", got)
+	}
+}
@@ -0,0 +1,13 @@
+pragma circom 2.0.0;
+
+// Smallest circuit with a public output and a public input, used only to
+// produce the snarkjs PLONK fixture the backend verifier is tested against
+template Multiplier() {
+    signal input a;
+    signal input b;
+    signal output c;
+
+    c <== a * b;
+}
+
+component main {public [a]} = Multiplier();
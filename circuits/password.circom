@@ -1,19 +1,39 @@
+pragma circom 2.0.0;
+
+include "circomlib/circuits/poseidon.circom";
+
+// Proves knowledge of the password behind a stored credential
+// storedHash = Poseidon(password, salt) without revealing it.
 template SecurePassword() {
+    // Private: never leaves the client
+    signal input password;       // UTF-8 bytes of the password, big-endian
+
+    // The credential the server stored at registration
     signal input salt;
     signal input storedHash;
-    signal input password;
 
-    signal output verified;
+    // Request context committed to by the proof. The backend checks these
+    // against the plaintext nonce, timestamp and username of the login
+    // request, so a proof cannot be replayed under a fresh nonce.
+    signal input nonce;          // HashToField(nonce)
+    signal input timestamp;      // unix seconds
+    signal input usernameHash;   // HashToField(username)
 
-    // Use the same simple transform as frontend/backend
-    signal computedHash;
-    computedHash <== password * salt + 12345;
+    component hasher = Poseidon(2);
+    hasher.inputs[0] <== password;
+    hasher.inputs[1] <== salt;
 
-    // The proof is only valid if computed hash matches stored hash
-    computedHash === storedHash;
+    // The proof is only valid if the password hashes to the stored credential
+    hasher.out === storedHash;
 
-    // Output 1 when constraint is satisfied
-    verified <== 1;
+    // A public input used in no constraint gets a zero IC point in the
+    // verifying key and is then not bound by the proof at all
+    signal nonceSquared;
+    signal timestampSquared;
+    signal usernameHashSquared;
+    nonceSquared <== nonce * nonce;
+    timestampSquared <== timestamp * timestamp;
+    usernameHashSquared <== usernameHash * usernameHash;
 }
 
-component main = SecurePassword();
+component main {public [salt, storedHash, nonce, timestamp, usernameHash]} = SecurePassword();
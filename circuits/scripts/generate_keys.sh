@@ -4,7 +4,7 @@ set -e
 echo "Starting ZKP key generation..."
 
 # Navigate to circuits directory
-cd ..
+cd "$(dirname "$0")/.."
 
 echo "Current directory: $(pwd)"
 
@@ -17,7 +17,7 @@ mkdir -p build
 
 # Step 1: Compile the circuit
 echo "Step 1: Compiling password.circom..."
-circom.exe password.circom --r1cs --wasm --sym -o build/
+circom.exe password.circom --r1cs --wasm --sym -l node_modules -o build/
 echo "✓ Circuit compiled successfully"
 
 # Step 2: Generate trusted setup with correct syntax
@@ -37,9 +37,15 @@ npx snarkjs powersoftau prepare phase2 pot12_0001.ptau pot12_final.ptau
 
 echo "✓ Trusted setup completed"
 
-# Step 3: Generate zkey
-echo "Step 3: Generating zkey..."
-npx snarkjs groth16 setup build/password.r1cs pot12_final.ptau build/circuit.zkey
+# Step 3: Generate zkey. PLONK only needs the universal ptau, no per-circuit
+# ceremony; the backend picks the verifier from the key's protocol field
+PROTOCOL=${PROTOCOL:-groth16}
+echo "Step 3: Generating ${PROTOCOL} zkey..."
+case "$PROTOCOL" in
+  groth16) npx snarkjs groth16 setup build/password.r1cs pot12_final.ptau build/circuit.zkey ;;
+  plonk)   npx snarkjs plonk setup build/password.r1cs pot12_final.ptau build/circuit.zkey ;;
+  *)       echo "Unsupported PROTOCOL: $PROTOCOL (use groth16 or plonk)"; exit 1 ;;
+esac
 echo "✓ zKey generated"
 
 # Step 4: Export verification key
@@ -51,6 +57,36 @@ echo "✓ Verification key exported"
 echo "Step 5: Organizing files..."
 cp build/password_js/password.wasm build/
 cp build/circuit.zkey build/password.zkey
+
+# The backend loads circom keys from VERIFICATION_KEY_DIR. Register them as the
+# version after the newest one already there, so the manifest never names a
+# version that is missing or taken
+KEY_ROOT=${VERIFICATION_KEY_DIR:-../backend/data/verification-keys}/password
+if [ -z "$CIRCUIT_VERSION" ]; then
+  CIRCUIT_VERSION=1
+  for dir in "$KEY_ROOT"/v*; do
+    [ -d "$dir" ] || continue
+    n=${dir##*/v}
+    if [ "$n" -ge "$CIRCUIT_VERSION" ]; then
+      CIRCUIT_VERSION=$((n + 1))
+    fi
+  done
+fi
+if [ -e "$KEY_ROOT/v${CIRCUIT_VERSION}" ]; then
+  echo "$KEY_ROOT/v${CIRCUIT_VERSION} already exists; add a new version instead of overwriting it"
+  exit 1
+fi
+
+# Manifest for the backend circuit registry; publicSignals must follow the
+# order snarkjs emits them in: outputs first, then public inputs as declared
+cat > build/circuit.json <<EOF
+{
+  "id": "password",
+  "version": ${CIRCUIT_VERSION},
+  "verificationKey": "verification_key.json",
+  "publicSignals": ["salt", "storedHash", "nonce", "timestamp", "usernameHash"]
+}
+EOF
 echo "✓ Files organized"
 
 # Step 6: Verify files
@@ -59,6 +95,7 @@ echo "Step 6: Verifying generated files..."
 echo "✓ build/password.wasm"
 echo "✓ build/password.zkey"
 echo "✓ build/verification_key.json"
+echo "✓ build/circuit.json"
 echo "✓ pot12_0000.ptau"
 echo "✓ pot12_0001.ptau"
 echo "✓ pot12_final.ptau"
@@ -67,9 +104,14 @@ echo ""
 echo "🎉 Key generation completed successfully!"
 echo ""
 echo "Generated files:"
-echo " - build/password.wasm (for frontend proof generation)"
-echo " - build/password.zkey (for frontend proof generation)"
+echo " - build/password.wasm (for snarkjs clients)"
+echo " - build/password.zkey (for snarkjs clients)"
 echo " - build/verification_key.json (for backend verification)"
+echo " - build/circuit.json (backend registry manifest, version ${CIRCUIT_VERSION})"
+echo ""
+echo "Install them in the backend's VERIFICATION_KEY_DIR with:"
+echo " mkdir -p $KEY_ROOT/v${CIRCUIT_VERSION}"
+echo " mv build/verification_key.json build/circuit.json $KEY_ROOT/v${CIRCUIT_VERSION}"
 echo ""
 echo "Trusted setup files:"
 echo " - pot12_0000.ptau (initial ptau)"
@@ -0,0 +1,32 @@
+#!/bin/bash
+set -e
+
+# Produces the snarkjs PLONK fixture backend/verifier tests verify against:
+# a verification key, a proof and its public signals, all from snarkjs itself
+cd "$(dirname "$0")/.."
+
+CIRCOM=${CIRCOM:-circom}
+OUT=../backend/verifier/testdata/snarkjs-plonk
+BUILD=$(mktemp -d)
+trap 'rm -rf "$BUILD"' EXIT
+
+echo "Compiling fixtures/multiplier.circom..."
+$CIRCOM fixtures/multiplier.circom --r1cs --wasm -o "$BUILD"
+
+echo "Running the powers of tau and the PLONK setup..."
+npx snarkjs powersoftau new bn128 8 "$BUILD/pot_0000.ptau"
+npx snarkjs powersoftau contribute "$BUILD/pot_0000.ptau" "$BUILD/pot_0001.ptau" --name="fixture" -e="$(date +%s)"
+npx snarkjs powersoftau prepare phase2 "$BUILD/pot_0001.ptau" "$BUILD/pot_final.ptau"
+npx snarkjs plonk setup "$BUILD/multiplier.r1cs" "$BUILD/pot_final.ptau" "$BUILD/multiplier.zkey"
+
+echo "Proving 3 · 11 = 33..."
+echo '{"a": "3", "b": "11"}' > "$BUILD/input.json"
+npx snarkjs plonk fullprove "$BUILD/input.json" "$BUILD/multiplier_js/multiplier.wasm" "$BUILD/multiplier.zkey" \
+  "$BUILD/proof.json" "$BUILD/public.json"
+
+mkdir -p "$OUT"
+npx snarkjs zkey export verificationkey "$BUILD/multiplier.zkey" "$OUT/verification_key.json"
+npx snarkjs plonk verify "$OUT/verification_key.json" "$BUILD/public.json" "$BUILD/proof.json"
+cp "$BUILD/proof.json" "$BUILD/public.json" "$OUT/"
+
+echo "✓ Fixture written to $OUT"
@@ -0,0 +1,20 @@
+// Compiles the Go prover (backend/cmd/prover-wasm) to public/prover/prover.wasm
+// and copies Go's wasm_exec.js, which loads it, next to it
+import { execFileSync } from 'node:child_process';
+import { copyFileSync, mkdirSync } from 'node:fs';
+import { dirname, join, resolve } from 'node:path';
+import { fileURLToPath } from 'node:url';
+
+const frontend = resolve(dirname(fileURLToPath(import.meta.url)), '..');
+const backend = join(frontend, '..', 'backend');
+const out = join(frontend, 'public', 'prover');
+
+mkdirSync(out, { recursive: true });
+execFileSync('go', ['build', '-o', join(out, 'prover.wasm'), './cmd/prover-wasm'], {
+    cwd: backend,
+    env: { ...process.env, GOOS: 'js', GOARCH: 'wasm' },
+    stdio: 'inherit',
+});
+
+const goroot = execFileSync('go', ['env', 'GOROOT'], { encoding: 'utf8' }).trim();
+copyFileSync(join(goroot, 'lib', 'wasm', 'wasm_exec.js'), join(out, 'wasm_exec.js'));
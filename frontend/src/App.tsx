@@ -1,47 +1,99 @@
 import React, { useState } from 'react';
-import * as snarkjs from 'snarkjs';
 import { poseidon2 } from 'poseidon-lite';
 import './App.css';
 
-interface ProofData {
+// The proof part of a login request, as the prover returns it
+interface ProofRequest {
+    username: string;
     proof: any;
     publicSignals: any[];
+    nonce: string;
+    timestamp: number;
+    proofType: string;
+    circuitId: string;
+    circuitVersion: number;
 }
 
-// Proper Poseidon2 hash - no compromises
-const poseidonHash = (password: string, salt: string): string => {
-    // Convert password string to bigint
-    let passwordBigInt = 0n;
-    for (let i = 0; i < password.length; i++) {
-        passwordBigInt = (passwordBigInt << 8n) + BigInt(password.charCodeAt(i));
+// globalThis.zkpProver, set by backend/cmd/prover-wasm
+interface WasmProver {
+    load(manifest: Uint8Array, verificationKey: Uint8Array, provingKey: Uint8Array): Promise<void>;
+    prove(username: string, password: string, salt: string, nonce: string, timestamp: number, proofType?: string): Promise<ProofRequest>;
+}
+
+declare global {
+    interface Window {
+        Go: any;
+        zkpProver: WasmProver;
     }
+}
 
-    // Convert salt to bigint
-    const saltBigInt = BigInt(parseInt(salt));
+// Must match credential.PasswordToField: UTF-8 bytes packed big-endian, at most 31 bytes
+const passwordToField = (password: string): bigint => {
+    const bytes = new TextEncoder().encode(password);
+    if (bytes.length === 0 || bytes.length > 31) {
+        throw new Error('Password must be 1 to 31 bytes');
+    }
+    return bytes.reduce((acc, b) => (acc << 8n) + BigInt(b), 0n);
+};
 
-    // Hash both together with poseidon2
-    return poseidon2([passwordBigInt, saltBigInt]).toString();
+// The stored credential: Poseidon(password, salt)
+const poseidonHash = (password: string, salt: string): string => {
+    return poseidon2([passwordToField(password), BigInt(salt)]).toString();
 };
 
-const generateZKProof = async (username: string, password: string, salt: string): Promise<ProofData> => {
-    // Use Poseidon2 to hash password + salt together
-    const combinedHash = poseidonHash(password, salt);
-    const saltNum = parseInt(salt);
+const loadScript = (src: string): Promise<void> => new Promise((resolve, reject) => {
+    const script = document.createElement('script');
+    script.src = src;
+    script.onload = () => resolve();
+    script.onerror = () => reject(new Error(`Could not load ${src}`));
+    document.head.appendChild(script);
+});
 
-    const inputs = {
-        salt: saltNum.toString(),
-        storedHash: combinedHash, // Poseidon2 hash of (password + salt)
-        password: combinedHash    // Same for circuit compatibility
-    };
+// The Go prover compiled to wasm (npm run build:prover), loaded once with the
+// keys of the circuit this deployment verifies
+let proverReady: Promise<WasmProver> | null = null;
+const loadProver = (): Promise<WasmProver> => {
+    if (!proverReady) {
+        proverReady = (async () => {
+            await loadScript('/prover/wasm_exec.js');
+            const go = new window.Go();
+            const { instance } = await WebAssembly.instantiateStreaming(fetch('/prover/prover.wasm'), go.importObject);
+            go.run(instance);
 
-    try {
-        const { proof, publicSignals } = await snarkjs.groth16.fullProve(
-          inputs,
-          '/circuits/password.wasm',
-          '/circuits/password.zkey'
-        );
+            const [manifest, verificationKey, provingKey] = await Promise.all(
+                ['circuit.json', 'verification_key.json', 'proving_key.bin'].map(async (file) => {
+                    const response = await fetch(`http://localhost:8080/api/prover/${file}`);
+                    if (!response.ok) {
+                        throw new Error(`Could not load prover key ${file}`);
+                    }
+                    return new Uint8Array(await response.arrayBuffer());
+                })
+            );
+            await window.zkpProver.load(manifest, verificationKey, provingKey);
+            return window.zkpProver;
+        })();
+        // A failed load is tried again on the next proof
+        proverReady.catch(() => {
+            proverReady = null;
+        });
+    }
+    return proverReady;
+};
 
-        return { proof, publicSignals };
+// Proves knowledge of the password behind Poseidon(password, salt), bound to
+// the nonce, the timestamp and the username so it cannot be replayed. The
+// proof type must match the purpose the nonce's challenge was issued for
+const generateZKProof = async (
+    username: string,
+    password: string,
+    salt: string,
+    nonce: string,
+    timestamp: number,
+    proofType: string = 'login'
+): Promise<ProofRequest> => {
+    try {
+        const prover = await loadProver();
+        return await prover.prove(username, password, salt, nonce, timestamp, proofType);
     } catch (error) {
         console.error('Proof generation failed:', error);
         throw error;
@@ -62,6 +114,20 @@ function App() {
         setMessage('');
         setIsError(true);
         try {
+            // The server only ever sees Poseidon(password, salt), never the password
+            const saltResponse = await fetch('http://localhost:8080/api/register/salt', {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                },
+                body: JSON.stringify({ username: username }),
+            });
+            if (!saltResponse.ok) {
+                const errorData = await saltResponse.json();
+                throw new Error(errorData.error);
+            }
+            const { salt } = await saltResponse.json();
+
             const response = await fetch('http://localhost:8080/api/register', {
                 method: 'POST',
                 headers: {
@@ -69,7 +135,8 @@ function App() {
                 },
                 body: JSON.stringify({
                     username: username,
-                    password: password,
+                    salt: salt,
+                    commitment: poseidonHash(password, salt),
                 }),
             });
 
@@ -103,15 +170,23 @@ function App() {
                 return;
             }
 
-            const proofData = await generateZKProof(username, password, salt);
-
-            const proof = {
-                username: username,
-                proof: proofData.proof,
-                publicSignals: proofData.publicSignals,
-                nonce: `nonce-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`,
-                timestamp: Math.floor(Date.now() / 1000)
-            };
+            // The server issues the nonce; it is single-use and tied to this client
+            const challengeResponse = await fetch('http://localhost:8080/api/login/challenge', {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                },
+                body: JSON.stringify({ username: username }),
+            });
+            if (!challengeResponse.ok) {
+                const errorData = await challengeResponse.json();
+                setMessage(`Login failed: ${errorData.error}`);
+                setIsError(true);
+                return;
+            }
+            const { challenge: nonce } = await challengeResponse.json();
+            const timestamp = Math.floor(Date.now() / 1000);
+            const proof = await generateZKProof(username, password, salt, nonce, timestamp);
 
             const response = await fetch('http://localhost:8080/api/login', {
                 method: 'POST',
@@ -127,6 +202,45 @@ function App() {
             if (response.ok) {
                 const data = await response.json();
                 localStorage.setItem('token', data.token);
+                localStorage.setItem('refreshToken', data.refreshToken);
+
+                // Accounts with a legacy six-digit salt are re-keyed with the salt the server
+                // offers. The upgrade takes a fresh auth proof of the old credential and
+                // revokes every session, so its tokens replace the ones from this login
+                if (data.saltUpgrade) {
+                    const authChallengeResponse = await fetch('http://localhost:8080/api/login/challenge', {
+                        method: 'POST',
+                        headers: {
+                            'Content-Type': 'application/json',
+                        },
+                        body: JSON.stringify({ username: username, purpose: 'auth' }),
+                    });
+                    if (authChallengeResponse.ok) {
+                        const { challenge: authNonce } = await authChallengeResponse.json();
+                        const authProof = await generateZKProof(
+                            username, password, salt, authNonce, Math.floor(Date.now() / 1000), 'auth'
+                        );
+                        const upgradeResponse = await fetch('http://localhost:8080/api/credential/upgrade', {
+                            method: 'POST',
+                            headers: {
+                                'Content-Type': 'application/json',
+                                'Authorization': `Bearer ${data.token}`,
+                            },
+                            body: JSON.stringify({
+                                proof: authProof,
+                                salt: data.saltUpgrade.salt,
+                                commitment: poseidonHash(password, data.saltUpgrade.salt),
+                            }),
+                        });
+                        if (upgradeResponse.ok) {
+                            const upgraded = await upgradeResponse.json();
+                            localStorage.setItem(`${username}_salt`, upgraded.salt);
+                            localStorage.setItem('token', upgraded.token);
+                            localStorage.setItem('refreshToken', upgraded.refreshToken);
+                        }
+                    }
+                }
+
                 setIsLoggedIn(true);
                 setUserData(data.user);
                 setMessage('Login successful with ZKP!');
@@ -144,6 +258,32 @@ function App() {
         }
     };
 
+    // Access tokens are short-lived: trade the refresh token for a new pair.
+    // Each refresh token works once, so the new one replaces it
+    const refreshTokens = async (): Promise<string | null> => {
+        const refreshToken = localStorage.getItem('refreshToken');
+        if (!refreshToken) {
+            return null;
+        }
+
+        const response = await fetch('http://localhost:8080/api/token/refresh', {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+            },
+            body: JSON.stringify({ refreshToken }),
+        });
+        if (!response.ok) {
+            localStorage.removeItem('refreshToken');
+            return null;
+        }
+
+        const data = await response.json();
+        localStorage.setItem('token', data.token);
+        localStorage.setItem('refreshToken', data.refreshToken);
+        return data.token;
+    };
+
     const fetchProtectedData = async (): Promise<void> => {
         setMessage('');
         setIsError(true);
@@ -154,12 +294,20 @@ function App() {
                 return;
             }
 
-            const response = await fetch('http://localhost:8080/api/protected', {
+            const get = (accessToken: string) => fetch('http://localhost:8080/api/protected', {
                 headers: {
-                    'Authorization': `Bearer ${token}`,
+                    'Authorization': `Bearer ${accessToken}`,
                 },
             });
 
+            let response = await get(token);
+            if (response.status === 401) {
+                const refreshed = await refreshTokens();
+                if (refreshed) {
+                    response = await get(refreshed);
+                }
+            }
+
             const data = await response.json();
 
             if (response.ok) {
@@ -175,8 +323,26 @@ function App() {
         }
     };
 
-    const handleLogout = (): void => {
+    const handleLogout = async (): Promise<void> => {
+        // Revoke the tokens server-side; they are forgotten locally either way
+        const token = localStorage.getItem('token');
+        if (token) {
+            try {
+                await fetch('http://localhost:8080/api/logout', {
+                    method: 'POST',
+                    headers: {
+                        'Content-Type': 'application/json',
+                        'Authorization': `Bearer ${token}`,
+                    },
+                    body: JSON.stringify({ refreshToken: localStorage.getItem('refreshToken') }),
+                });
+            } catch {
+                // Offline: the access token still expires on its own
+            }
+        }
+
         localStorage.removeItem('token');
+        localStorage.removeItem('refreshToken');
         setIsLoggedIn(false);
         setUserData(null);
         setMessage('Logged out successfully');